@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/BalamutDiana/crud_movie_manager/internal/config"
 	repo "github.com/BalamutDiana/crud_movie_manager/internal/repository"
 	"github.com/BalamutDiana/crud_movie_manager/internal/service"
 	rest "github.com/BalamutDiana/crud_movie_manager/internal/transport"
 	amqp_client "github.com/BalamutDiana/crud_movie_manager/internal/transport/amqp"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/blob"
 	"github.com/BalamutDiana/crud_movie_manager/pkg/database"
 	"github.com/BalamutDiana/crud_movie_manager/pkg/hash"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/keyring"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/mail"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/oidc"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/ratelimit"
 	"github.com/BalamutDiana/custom_cache"
 	"github.com/sirupsen/logrus"
 
@@ -57,14 +64,19 @@ func main() {
 	}
 	defer db.Close()
 
-	hasher := hash.NewSHA1Hasher("salt")
+	hasher := hash.NewSHA1Hasher(cfg.Auth.Salt)
 
 	cache := custom_cache.New()
 	booksRepo := repo.NewMovies(db, cache)
 	usersRepo := repo.NewUsers(db)
 	tokensRepo := repo.NewTokens(db)
 
-	auditClient, err := amqp_client.NewClient(5672)
+	auditClient, err := amqp_client.NewClient(amqp_client.ConnectionInfo{
+		Host:     cfg.AMQP.Host,
+		Port:     cfg.AMQP.Port,
+		Username: cfg.AMQP.Username,
+		Password: cfg.AMQP.Password,
+	})
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"method":  "amqp_client.NewClient",
@@ -73,9 +85,109 @@ func main() {
 	}
 	defer auditClient.CloseConnection()
 
-	usersService := service.NewUsers(usersRepo, tokensRepo, auditClient, hasher, []byte("dFscwEtgdS"))
+	keys, err := keyring.New(keyring.Options{
+		Algorithm:   cfg.Auth.SigningMethod,
+		RotateEvery: cfg.Auth.KeyRotation,
+		Retain:      cfg.Auth.KeyRetention + cfg.Auth.Leeway,
+		Dir:         cfg.Auth.KeysDir,
+	})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method":  "keyring.New",
+			"problem": "creating signing keys",
+		}).Fatal(err)
+	}
 
-	handler := rest.NewHandler(booksRepo, usersService)
+	go keys.Run(context.Background(), func(err error) {
+		logrus.WithFields(logrus.Fields{
+			"method":  "keyring.Rotate",
+			"problem": "rotating signing keys",
+		}).Error(err)
+	})
+
+	revocations, err := service.NewRevocations(context.Background(), repo.NewRevocations(db), cfg.Auth.TokenTTL+cfg.Auth.Leeway)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method":  "service.NewRevocations",
+			"problem": "loading revoked tokens",
+		}).Fatal(err)
+	}
+
+	go revocations.Run(context.Background(), time.Minute)
+
+	lockout := service.NewLockout(service.LockoutConfig{
+		EmailThreshold: cfg.Lockout.EmailThreshold,
+		IPThreshold:    cfg.Lockout.IPThreshold,
+		BaseDelay:      cfg.Lockout.BaseDelay,
+		MaxDelay:       cfg.Lockout.MaxDelay,
+		Window:         cfg.Lockout.Window,
+	})
+
+	go lockout.Run(context.Background())
+
+	mailer := newMailer(cfg.Mail)
+
+	usersService := service.NewUsers(usersRepo, tokensRepo, revocations, repo.NewUserTokens(db), repo.NewRecoveryCodes(db),
+		repo.NewIdentities(db), newIdentityProviders(cfg.OIDC), auditClient, mailer, lockout, hasher, keys,
+		service.TokenConfig{
+			AccessTTL:  cfg.Auth.TokenTTL,
+			RefreshTTL: cfg.Auth.RefreshTTL,
+			Issuer:     cfg.Auth.Issuer,
+			Audience:   cfg.Auth.Audience,
+			Leeway:     cfg.Auth.Leeway,
+
+			MFAChallengeTTL: cfg.Auth.MFATTL,
+			OIDCStateTTL:    cfg.OIDC.StateTTL,
+		},
+		service.LinksConfig{
+			VerifyURL: cfg.Mail.VerifyURL,
+			VerifyTTL: cfg.Mail.VerifyTTL,
+			ResetURL:  cfg.Mail.ResetURL,
+			ResetTTL:  cfg.Mail.ResetTTL,
+		})
+
+	limiter := ratelimit.NewMemoryStore()
+	go limiter.Run(context.Background(), time.Minute)
+
+	apiKeysRepo := repo.NewAPIKeys(db)
+	apiKeysService := service.NewAPIKeys(apiKeysRepo)
+	watchStatesRepo := repo.NewWatchStates(db)
+	watchingService := service.NewWatching(watchStatesRepo, booksRepo)
+	tagsService := service.NewTags(repo.NewTags(db), repo.NewGenres(db), booksRepo, usersRepo)
+	seriesRepo := repo.NewSeries(db)
+	seriesService := service.NewSeries(seriesRepo, booksRepo)
+	peopleService := service.NewPeople(repo.NewPeople(db), booksRepo)
+	releasesService := service.NewReleases(booksRepo, booksRepo)
+	subscriptionsRepo := repo.NewSubscriptions(db)
+	librariesRepo := repo.NewLibraries(db)
+	exportsService := service.NewExports(usersRepo, booksRepo, watchStatesRepo, seriesRepo, subscriptionsRepo, tokensRepo, apiKeysRepo, repo.NewIdentities(db),
+		librariesRepo, auditClient)
+
+	streamingServicesRepo := repo.NewStreamingServices(db)
+	streamingServices := service.NewStreamingServices(streamingServicesRepo, usersRepo)
+	availabilityService := service.NewAvailability(repo.NewAvailability(db), booksRepo, streamingServicesRepo)
+	subscriptionsService := service.NewSubscriptions(subscriptionsRepo, streamingServicesRepo)
+	postersService := service.NewPosters(repo.NewPosters(db), booksRepo, newBlobStorage(cfg.Posters), service.PostersConfig{
+		MaxSize:    cfg.Posters.MaxSize,
+		Sizes:      cfg.Posters.Sizes,
+		MaxAge:     cfg.Posters.MaxAge,
+		MaxUploads: cfg.Posters.MaxUploads,
+	})
+	duplicatesService := service.NewDuplicates(booksRepo, postersService)
+	librariesService := service.NewLibraries(librariesRepo, booksRepo, streamingServices, usersRepo, mailer,
+		service.InvitationsConfig{
+			URL: cfg.Mail.InviteURL,
+			TTL: cfg.Mail.InviteTTL,
+		})
+
+	handler := rest.NewHandler(booksRepo, usersService, apiKeysService, exportsService, librariesService,
+		watchingService, tagsService, seriesService, peopleService, streamingServices, releasesService, availabilityService,
+		subscriptionsService, duplicatesService, postersService, keys,
+		rest.CookieConfig{
+			Secure: cfg.Auth.SecureCookies,
+			MaxAge: cfg.Auth.RefreshTTL,
+		},
+		limiter, newRateLimits(cfg.RateLimit))
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
@@ -91,3 +203,72 @@ func main() {
 		}).Fatal(err)
 	}
 }
+
+func newMailer(cfg config.Mail) service.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPInfo{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	case "file":
+		return mail.NewFileMailer(cfg.File)
+	default:
+		return mail.NewLogMailer()
+	}
+}
+
+func newBlobStorage(cfg config.Posters) service.BlobStorage {
+	if cfg.Driver == "s3" {
+		return blob.NewS3(blob.S3Info{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	}
+
+	return blob.NewLocal(cfg.Dir)
+}
+
+func newIdentityProviders(cfg config.OIDC) map[string]service.IdentityProvider {
+	providers := make(map[string]service.IdentityProvider, len(cfg.Providers))
+
+	for name, p := range cfg.Providers {
+		providers[name] = oidc.New(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+
+	return providers
+}
+
+func newRateLimits(cfg config.RateLimit) rest.RateLimits {
+	toLimit := func(rule config.RateLimitRule) ratelimit.Limit {
+		return ratelimit.Limit{
+			Requests: rule.Requests,
+			Period:   rule.Period,
+			Burst:    rule.Burst,
+		}
+	}
+
+	limits := rest.RateLimits{
+		Global: toLimit(cfg.Global),
+		Routes: make(map[string]ratelimit.Limit, len(cfg.Routes)),
+	}
+
+	for route, rule := range cfg.Routes {
+		limits.Routes[route] = toLimit(rule)
+	}
+
+	return limits
+}
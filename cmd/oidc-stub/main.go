@@ -0,0 +1,44 @@
+// Command oidc-stub runs a local OpenID Connect provider which signs in a
+// single fixed user, for trying out the OIDC login without a real provider.
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/pkg/oidc/oidctest"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "public URL of the provider")
+	clientID := flag.String("client-id", "crud_movie_manager", "accepted client id")
+	clientSecret := flag.String("client-secret", "secret", "accepted client secret")
+	subject := flag.String("sub", "stub-user", "subject of the signed in user")
+	email := flag.String("email", "user@example.com", "email of the signed in user")
+	verified := flag.Bool("email-verified", true, "whether the email is verified")
+	name := flag.String("name", "Stub User", "name of the signed in user")
+	flag.Parse()
+
+	srv, err := oidctest.New(oidctest.Options{
+		Issuer:       *issuer,
+		ClientID:     *clientID,
+		ClientSecret: *clientSecret,
+		User: oidctest.User{
+			Subject:       *subject,
+			Email:         *email,
+			EmailVerified: *verified,
+			Name:          *name,
+		},
+	})
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	logrus.Infof("OIDC stub listening on %s", *addr)
+
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		logrus.Fatal(err)
+	}
+}
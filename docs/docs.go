@@ -18,7 +18,7 @@ const docTemplate = `{
     "paths": {
         "/movies": {
             "get": {
-                "description": "Get all movies list",
+                "description": "Get all movies list with the watch state of the user",
                 "consumes": [
                     "application/json"
                 ],
@@ -26,6 +26,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "summary": "Get movies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "movie or series",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "watch status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimal rating",
+                        "name": "rating_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximal rating",
+                        "name": "rating_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated tags",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "any (default) or all of the tags",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "director id or name",
+                        "name": "director",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "actor id or name",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "first release year",
+                        "name": "year_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "last release year",
+                        "name": "year_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "title, release or -release",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -56,6 +124,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/domain.MovieMainInfo"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "save even if the movie may already exist",
+                        "name": "force",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -70,6 +144,52 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/domain.MovieMainInfo"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/transport.duplicateResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/available": {
+            "get": {
+                "description": "Unwatched movies available on the subscriptions of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "What can I watch tonight",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ISO country code of the subscriptions",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximal runtime in minutes",
+                        "name": "max_runtime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "genre",
+                        "name": "genre",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AvailableMovie"
+                            }
+                        }
                     }
                 }
             }
@@ -165,26 +285,317 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/movies/{id}/merge": {
+            "post": {
+                "description": "Merge a duplicate into another movie with its tags, ratings and history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Merge movies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "movie id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "movie to keep",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.MergeMovieInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Movie"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/poster": {
+            "get": {
+                "description": "Get the original poster or one of its JPEG thumbnails",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif"
+                ],
+                "summary": "Get a poster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "movie id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "original (default) or a thumbnail size, e.g. small, medium, large",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the poster of the movie with a JPEG, PNG or GIF image sent as the body or the \"poster\" field of a multipart form",
+                "consumes": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Upload a poster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "movie id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Poster"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/releases": {
+            "put": {
+                "description": "Replace the release dates of the movie in the countries",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Set country releases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "movie id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "release dates by ISO country code",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CountryReleasesInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Movie"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
-        "domain.Movie": {
+        "domain.Availability": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "movie_id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "service": {
+                    "type": "string"
+                },
+                "service_id": {
+                    "type": "integer"
+                },
+                "until": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.AvailableMovie": {
+            "type": "object",
+            "properties": {
+                "movie_id": {
+                    "type": "integer"
+                },
+                "offers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Availability"
+                    }
+                },
+                "release": {
+                    "$ref": "#/definitions/domain.ReleaseDate"
+                },
+                "runtime": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CountryRelease": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "date": {
+                    "$ref": "#/definitions/domain.ReleaseDate"
+                }
+            }
+        },
+        "domain.CountryReleasesInput": {
+            "type": "object",
+            "properties": {
+                "releases": {
+                    "type": "array",
+                    "maxItems": 250,
+                    "items": {
+                        "$ref": "#/definitions/domain.CountryRelease"
+                    }
+                }
+            }
+        },
+        "domain.DuplicateCandidate": {
             "type": "object",
             "properties": {
                 "id": {
                     "type": "integer"
                 },
                 "release": {
+                    "$ref": "#/definitions/domain.ReleaseDate"
+                },
+                "similarity": {
+                    "type": "number"
+                },
+                "title": {
                     "type": "string"
                 },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MergeMovieInput": {
+            "type": "object",
+            "required": [
+                "into"
+            ],
+            "properties": {
+                "into": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Movie": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "libraryId": {
+                    "description": "LibraryID is set for movies of a shared library.",
+                    "type": "integer"
+                },
+                "release": {
+                    "$ref": "#/definitions/domain.ReleaseDate"
+                },
+                "releases": {
+                    "description": "Releases are the release dates in the countries.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.CountryRelease"
+                    }
+                },
+                "runtime": {
+                    "description": "Runtime is the length in minutes.",
+                    "type": "integer"
+                },
                 "savedAt": {
                     "type": "string"
                 },
                 "streamingService": {
                     "type": "string"
                 },
+                "streamingServiceId": {
+                    "description": "StreamingServiceID is the catalog entry of StreamingService.",
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "title": {
                     "type": "string"
+                },
+                "type": {
+                    "description": "Type is TitleTypeMovie or TitleTypeSeries, movies are the default.",
+                    "type": "string"
+                },
+                "watch": {
+                    "description": "Watch is the state of the movie for the user who listed movies.",
+                    "$ref": "#/definitions/domain.WatchState"
                 }
             }
         },
@@ -192,13 +603,123 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "release": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "2010-07-16"
+                },
+                "runtime": {
+                    "type": "integer",
+                    "example": 148
                 },
                 "streamingService": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Poster": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "movie_id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "urls": {
+                    "description": "URLs maps the size names, including PosterOriginal, to their URLs.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.ReleaseDate": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "precision": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.WatchEvent": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "watched_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.WatchState": {
+            "type": "object",
+            "properties": {
+                "movie_id": {
+                    "type": "integer"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "progress": {
+                    "description": "Progress is the watched part of the movie in percent.",
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "watches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.WatchEvent"
+                    }
+                }
+            }
+        },
+        "transport.duplicateResponse": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DuplicateCandidate"
+                    }
+                },
+                "error": {
+                    "type": "string"
                 }
             }
         },
@@ -1,20 +1,51 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/kelseyhightower/envconfig"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	DB Postgres
 
+	AMQP AMQP
+
+	Mail Mail
+
 	Server struct {
 		Port int `mapstructure:"port"`
 	} `mapstructure:"server"`
 
+	Lockout Lockout
+
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+
+	OIDC OIDC `mapstructure:"oidc"`
+
+	Posters Posters
+
 	Auth struct {
-		TokenTTL time.Duration `mapstructure:"token_ttl"`
+		TokenTTL   time.Duration `mapstructure:"token_ttl" split_words:"true"`
+		RefreshTTL time.Duration `mapstructure:"refresh_ttl" split_words:"true"`
+		Issuer     string        `mapstructure:"issuer"`
+		Audience   string        `mapstructure:"audience"`
+		Leeway     time.Duration `mapstructure:"leeway"`
+		MFATTL     time.Duration `mapstructure:"mfa_ttl" envconfig:"MFA_TTL"`
+		Salt       string        `mapstructure:"salt"`
+
+		SecureCookies bool `mapstructure:"secure_cookies" split_words:"true"`
+
+		SigningMethod string        `mapstructure:"signing_method" split_words:"true"`
+		KeyRotation   time.Duration `mapstructure:"key_rotation" split_words:"true"`
+		KeyRetention  time.Duration `mapstructure:"key_retention" split_words:"true"`
+		KeysDir       string        `mapstructure:"keys_dir" split_words:"true"`
 	} `mapstructure:"auth"`
 }
 
@@ -27,6 +58,105 @@ type Postgres struct {
 	Password string
 }
 
+type AMQP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// Lockout holds thresholds of the sign in brute-force protection.
+type Lockout struct {
+	EmailThreshold int           `mapstructure:"email_threshold" split_words:"true"`
+	IPThreshold    int           `mapstructure:"ip_threshold" envconfig:"IP_THRESHOLD"`
+	BaseDelay      time.Duration `mapstructure:"base_delay" split_words:"true"`
+	MaxDelay       time.Duration `mapstructure:"max_delay" split_words:"true"`
+	Window         time.Duration `mapstructure:"window"`
+}
+
+type RateLimitRule struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// RateLimit is read from the config file only, Routes maps route groups
+// (auth, movies, ...) to their limits.
+type RateLimit struct {
+	Global RateLimitRule
+	Routes map[string]RateLimitRule
+}
+
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	RedirectURL  string `mapstructure:"redirect_url"`
+	Scopes       []string
+}
+
+// OIDC configures sign in through external OpenID Connect providers.
+// Providers are read from the config file only, their client secrets can be
+// set with OIDC_<NAME>_CLIENT_SECRET or OIDC_<NAME>_CLIENT_SECRET_FILE.
+type OIDC struct {
+	StateTTL  time.Duration `mapstructure:"state_ttl"`
+	Providers map[string]OIDCProvider
+}
+
+type Mail struct {
+	// Driver is one of smtp, file or log.
+	Driver string
+	From   string
+	// File is the output of the file driver.
+	File string
+	SMTP struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+	}
+
+	VerifyURL string        `mapstructure:"verify_url" split_words:"true"`
+	VerifyTTL time.Duration `mapstructure:"verify_ttl" split_words:"true"`
+	ResetURL  string        `mapstructure:"reset_url" split_words:"true"`
+	ResetTTL  time.Duration `mapstructure:"reset_ttl" split_words:"true"`
+	InviteURL string        `mapstructure:"invite_url" split_words:"true"`
+	InviteTTL time.Duration `mapstructure:"invite_ttl" split_words:"true"`
+}
+
+// Posters configures poster uploads. Driver is local or s3; Sizes maps
+// thumbnail names to their widths in pixels and is read from the config file
+// only.
+type Posters struct {
+	Driver  string
+	Dir     string
+	MaxSize int64         `mapstructure:"max_size" split_words:"true"`
+	MaxAge  time.Duration `mapstructure:"max_age" split_words:"true"`
+	// MaxUploads is the number of uploads processed at the same time.
+	MaxUploads int            `mapstructure:"max_uploads" split_words:"true"`
+	Sizes      map[string]int `ignored:"true"`
+	S3         struct {
+		Endpoint  string
+		Region    string
+		Bucket    string
+		AccessKey string `mapstructure:"access_key" split_words:"true"`
+		SecretKey string `mapstructure:"secret_key" split_words:"true"`
+		PathStyle bool   `mapstructure:"path_style" split_words:"true"`
+	}
+}
+
+// secretFiles maps environment variables that may be provided as files
+// (Docker/Kubernetes secrets) to the config fields they fill.
+func (c *Config) secretFiles() map[string]*string {
+	return map[string]*string{
+		"DB_PASSWORD":           &c.DB.Password,
+		"AMQP_PASSWORD":         &c.AMQP.Password,
+		"MAIL_SMTP_PASSWORD":    &c.Mail.SMTP.Password,
+		"POSTERS_S3_SECRET_KEY": &c.Posters.S3.SecretKey,
+		"AUTH_SALT":             &c.Auth.Salt,
+	}
+}
+
 func New(folder, filename string) (*Config, error) {
 	cfg := new(Config)
 
@@ -41,5 +171,204 @@ func New(folder, filename string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := envconfig.Process("db", &cfg.DB); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("amqp", &cfg.AMQP); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("lockout", &cfg.Lockout); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("mail", &cfg.Mail); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("posters", &cfg.Posters); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("server", &cfg.Server); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("auth", &cfg.Auth); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.readSecretFiles(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.readOIDCSecrets(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
+
+// readSecretFiles overrides secrets with the contents of the files pointed
+// to by <NAME>_FILE environment variables.
+func (c *Config) readSecretFiles() error {
+	for name, field := range c.secretFiles() {
+		path, ok := os.LookupEnv(name + "_FILE")
+		if !ok || path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s_FILE: %w", name, err)
+		}
+
+		*field = strings.TrimRight(string(data), "\r\n")
+	}
+
+	return nil
+}
+
+// readOIDCSecrets sets client secrets of the providers from
+// OIDC_<NAME>_CLIENT_SECRET or the file in OIDC_<NAME>_CLIENT_SECRET_FILE.
+func (c *Config) readOIDCSecrets() error {
+	for name, p := range c.OIDC.Providers {
+		env := "OIDC_" + strings.ToUpper(name) + "_CLIENT_SECRET"
+
+		if secret, ok := os.LookupEnv(env); ok {
+			p.ClientSecret = secret
+		}
+
+		if path, ok := os.LookupEnv(env + "_FILE"); ok && path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading %s_FILE: %w", env, err)
+			}
+
+			p.ClientSecret = strings.TrimRight(string(data), "\r\n")
+		}
+
+		c.OIDC.Providers[name] = p
+	}
+
+	return nil
+}
+
+// requirement is a config check reported by name when it fails.
+type requirement struct {
+	name string
+	ok   bool
+}
+
+// Validate checks that all values required to start the server are present.
+func (c *Config) Validate() error {
+	required := []requirement{
+		{"server.port (SERVER_PORT)", c.Server.Port != 0},
+		{"db.host (DB_HOST)", c.DB.Host != ""},
+		{"db.port (DB_PORT)", c.DB.Port != 0},
+		{"db.username (DB_USERNAME)", c.DB.Username != ""},
+		{"db.name (DB_NAME)", c.DB.Name != ""},
+		{"amqp.host (AMQP_HOST)", c.AMQP.Host != ""},
+		{"amqp.port (AMQP_PORT)", c.AMQP.Port != 0},
+		{"auth.token_ttl (AUTH_TOKEN_TTL)", c.Auth.TokenTTL > 0},
+		{"auth.refresh_ttl (AUTH_REFRESH_TTL)", c.Auth.RefreshTTL > 0},
+		{"auth.mfa_ttl (AUTH_MFA_TTL)", c.Auth.MFATTL > 0},
+		{"auth.issuer (AUTH_ISSUER)", c.Auth.Issuer != ""},
+		{"auth.audience (AUTH_AUDIENCE)", c.Auth.Audience != ""},
+		{"auth.signing_method (AUTH_SIGNING_METHOD) as RS256 or EdDSA", c.Auth.SigningMethod == "RS256" || c.Auth.SigningMethod == "EdDSA"},
+		{"auth.key_rotation (AUTH_KEY_ROTATION)", c.Auth.KeyRotation > 0},
+		{"auth.key_retention (AUTH_KEY_RETENTION) not shorter than auth.token_ttl", c.Auth.KeyRetention >= c.Auth.TokenTTL},
+		{"auth.salt (AUTH_SALT or AUTH_SALT_FILE)", c.Auth.Salt != ""},
+		{"lockout.email_threshold (LOCKOUT_EMAIL_THRESHOLD)", c.Lockout.EmailThreshold > 0},
+		{"lockout.ip_threshold (LOCKOUT_IP_THRESHOLD)", c.Lockout.IPThreshold > 0},
+		{"lockout.base_delay (LOCKOUT_BASE_DELAY)", c.Lockout.BaseDelay > 0},
+		{"lockout.max_delay (LOCKOUT_MAX_DELAY) not shorter than lockout.base_delay", c.Lockout.MaxDelay >= c.Lockout.BaseDelay},
+		{"lockout.window (LOCKOUT_WINDOW)", c.Lockout.Window > 0},
+		{"mail.driver (MAIL_DRIVER) as smtp, file or log", c.Mail.Driver == "smtp" || c.Mail.Driver == "file" || c.Mail.Driver == "log"},
+		{"mail.verify_url (MAIL_VERIFY_URL)", c.Mail.VerifyURL != ""},
+		{"mail.verify_ttl (MAIL_VERIFY_TTL)", c.Mail.VerifyTTL > 0},
+		{"mail.reset_url (MAIL_RESET_URL)", c.Mail.ResetURL != ""},
+		{"mail.reset_ttl (MAIL_RESET_TTL)", c.Mail.ResetTTL > 0},
+		{"mail.invite_url (MAIL_INVITE_URL)", c.Mail.InviteURL != ""},
+		{"mail.invite_ttl (MAIL_INVITE_TTL)", c.Mail.InviteTTL > 0},
+		{"posters.driver (POSTERS_DRIVER) as local or s3", c.Posters.Driver == "local" || c.Posters.Driver == "s3"},
+		{"posters.max_size (POSTERS_MAX_SIZE)", c.Posters.MaxSize > 0},
+		{"posters.max_age (POSTERS_MAX_AGE)", c.Posters.MaxAge > 0},
+		{"posters.max_uploads (POSTERS_MAX_UPLOADS)", c.Posters.MaxUploads > 0},
+		{"posters.sizes", len(c.Posters.Sizes) > 0},
+	}
+
+	switch c.Mail.Driver {
+	case "smtp":
+		required = append(required,
+			requirement{"mail.from (MAIL_FROM)", c.Mail.From != ""},
+			requirement{"mail.smtp.host (MAIL_SMTP_HOST)", c.Mail.SMTP.Host != ""},
+			requirement{"mail.smtp.port (MAIL_SMTP_PORT)", c.Mail.SMTP.Port != 0},
+		)
+	case "file":
+		required = append(required, requirement{"mail.file (MAIL_FILE)", c.Mail.File != ""})
+	}
+
+	switch c.Posters.Driver {
+	case "local":
+		required = append(required, requirement{"posters.dir (POSTERS_DIR)", c.Posters.Dir != ""})
+	case "s3":
+		required = append(required,
+			requirement{"posters.s3.endpoint (POSTERS_S3_ENDPOINT)", c.Posters.S3.Endpoint != ""},
+			requirement{"posters.s3.region (POSTERS_S3_REGION)", c.Posters.S3.Region != ""},
+			requirement{"posters.s3.bucket (POSTERS_S3_BUCKET)", c.Posters.S3.Bucket != ""},
+			requirement{"posters.s3.access_key (POSTERS_S3_ACCESS_KEY)", c.Posters.S3.AccessKey != ""},
+			requirement{"posters.s3.secret_key (POSTERS_S3_SECRET_KEY or POSTERS_S3_SECRET_KEY_FILE)", c.Posters.S3.SecretKey != ""},
+		)
+	}
+
+	sizes := make([]string, 0, len(c.Posters.Sizes))
+	for name := range c.Posters.Sizes {
+		sizes = append(sizes, name)
+	}
+	sort.Strings(sizes)
+
+	for _, name := range sizes {
+		if width := c.Posters.Sizes[name]; name == "original" || width <= 0 {
+			required = append(required, requirement{"posters.sizes." + name + " as a positive width", false})
+		}
+	}
+
+	if len(c.OIDC.Providers) > 0 {
+		required = append(required, requirement{"oidc.state_ttl", c.OIDC.StateTTL > 0})
+	}
+
+	providers := make([]string, 0, len(c.OIDC.Providers))
+	for name := range c.OIDC.Providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	for _, name := range providers {
+		p := c.OIDC.Providers[name]
+		prefix := "oidc.providers." + name
+		required = append(required,
+			requirement{prefix + ".issuer", p.Issuer != ""},
+			requirement{prefix + ".client_id", p.ClientID != ""},
+			requirement{prefix + ".redirect_url", p.RedirectURL != ""},
+		)
+	}
+
+	var missing []string
+	for _, r := range required {
+		if !r.ok {
+			missing = append(missing, r.name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.New("missing or invalid config values: " + strings.Join(missing, ", "))
+	}
+
+	return nil
+}
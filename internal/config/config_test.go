@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "db_password")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DB_PASSWORD_FILE", path)
+
+	cfg := Config{DB: Postgres{Password: "from env"}}
+	cfg.Auth.Salt = "salt"
+
+	if err := cfg.readSecretFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DB.Password != "s3cret" {
+		t.Errorf("DB.Password = %q, want the file contents without the newline", cfg.DB.Password)
+	}
+
+	if cfg.Auth.Salt != "salt" {
+		t.Errorf("Auth.Salt = %q, want it unchanged without AUTH_SALT_FILE", cfg.Auth.Salt)
+	}
+}
+
+func TestReadSecretFilesMissingFile(t *testing.T) {
+	t.Setenv("AUTH_SALT_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	var cfg Config
+	if err := cfg.readSecretFiles(); err == nil || !strings.Contains(err.Error(), "AUTH_SALT_FILE") {
+		t.Errorf("readSecretFiles() error = %v, want it to name AUTH_SALT_FILE", err)
+	}
+}
+
+func TestValidateListsMissingValues(t *testing.T) {
+	var cfg Config
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() of an empty config succeeded")
+	}
+
+	for _, name := range []string{"server.port (SERVER_PORT)", "db.host (DB_HOST)", "auth.salt (AUTH_SALT or AUTH_SALT_FILE)"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Validate() error = %q, want it to list %s", err, name)
+		}
+	}
+
+	if strings.Contains(err.Error(), "db.password") {
+		t.Errorf("Validate() error = %q, the database password is optional", err)
+	}
+}
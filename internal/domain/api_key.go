@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ScopeMoviesRead  = "movies:read"
+	ScopeMoviesWrite = "movies:write"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("api key is invalid, expired or revoked")
+)
+
+// APIKey is a personal key used by scripts and integrations instead of a
+// session. Only a hash of the key is stored, Prefix helps users to tell their
+// keys apart.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key grants the scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" validate:"required,gte=1,lte=255"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=movies:read movies:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (i CreateAPIKeyInput) Validate() error {
+	if err := validate.Struct(i); err != nil {
+		return err
+	}
+
+	if i.ExpiresAt != nil && i.ExpiresAt.Before(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// CreatedAPIKey is returned once on creation, Key is never shown again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+// Audit actions that are not (yet) part of the crud_audit action set.
+const (
+	AuditActionLogout      = "LOGOUT"
+	AuditActionLoginFailed = "LOGIN_FAILED"
+	AuditActionDisable     = "DISABLE"
+	AuditActionVerify      = "VERIFY"
+
+	AuditActionPasswordResetRequest = "PASSWORD_RESET_REQUEST"
+	AuditActionPasswordReset        = "PASSWORD_RESET"
+	AuditActionPasswordChange       = "PASSWORD_CHANGE"
+
+	AuditActionMFAEnable  = "MFA_ENABLE"
+	AuditActionMFADisable = "MFA_DISABLE"
+
+	AuditActionIdentityLink = "IDENTITY_LINK"
+
+	AuditActionEmailChange = "EMAIL_CHANGE"
+
+	AuditActionExport = "EXPORT"
+	AuditActionErase  = "ERASE"
+)
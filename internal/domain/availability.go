@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Kinds of availability.
+const (
+	AvailabilitySubscription = "subscription"
+	AvailabilityRent         = "rent"
+	AvailabilityBuy          = "buy"
+)
+
+const availabilityDateLayout = "2006-01-02"
+
+var (
+	ErrAvailabilityNotFound = errors.New("availability not found")
+	ErrServiceNotInRegion   = errors.New("streaming service isn't available in the region")
+)
+
+// Availability tells that a movie can be watched on a service in a region,
+// optionally only between From and Until.
+type Availability struct {
+	ID        int64      `json:"id"`
+	MovieID   int64      `json:"movie_id"`
+	ServiceID int64      `json:"service_id"`
+	Service   string     `json:"service"`
+	Region    string     `json:"region"`
+	Kind      string     `json:"kind"`
+	Price     *float64   `json:"price,omitempty"`
+	Currency  *string    `json:"currency,omitempty"`
+	From      *time.Time `json:"from,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+// AvailableMovie is a movie with the offers matching a query.
+type AvailableMovie struct {
+	MovieID int64          `json:"movie_id"`
+	Title   string         `json:"title"`
+	Type    string         `json:"type"`
+	Release ReleaseDate    `json:"release"`
+	Runtime *int           `json:"runtime,omitempty"`
+	Offers  []Availability `json:"offers"`
+}
+
+// AvailabilityInput describes an availability, dates have the "2006-01-02"
+// format and are inclusive.
+type AvailabilityInput struct {
+	ServiceID int64    `json:"service_id" validate:"required"`
+	Region    string   `json:"region" validate:"iso3166_1_alpha2"`
+	Kind      string   `json:"kind" validate:"required,oneof=subscription rent buy"`
+	Price     *float64 `json:"price" validate:"omitempty,min=0,max=100000"`
+	Currency  *string  `json:"currency" validate:"required_with=Price,omitempty,iso4217"`
+	From      string   `json:"from" validate:"omitempty,datetime=2006-01-02"`
+	Until     string   `json:"until" validate:"omitempty,datetime=2006-01-02"`
+}
+
+func (i *AvailabilityInput) Validate() error {
+	i.Region = strings.ToUpper(i.Region)
+	if i.Currency != nil {
+		currency := strings.ToUpper(*i.Currency)
+		i.Currency = &currency
+	}
+
+	if err := validate.Struct(i); err != nil {
+		return err
+	}
+
+	if from, until := i.FromDate(), i.UntilDate(); from != nil && until != nil && until.Before(*from) {
+		return errors.New("until must not be before from")
+	}
+
+	return nil
+}
+
+func (i AvailabilityInput) FromDate() *time.Time {
+	return parseAvailabilityDate(i.From)
+}
+
+func (i AvailabilityInput) UntilDate() *time.Time {
+	return parseAvailabilityDate(i.Until)
+}
+
+func parseAvailabilityDate(value string) *time.Time {
+	date, err := time.Parse(availabilityDateLayout, value)
+	if err != nil {
+		return nil
+	}
+
+	return &date
+}
+
+// AvailabilityFilter selects offers available at a moment in a region.
+// Services are catalog names or ids; Status limits the movies to those with
+// this watch status of UserID, e.g. the watchlist.
+type AvailabilityFilter struct {
+	UserID   int64
+	Region   string   `validate:"iso3166_1_alpha2"`
+	Services []string `validate:"max=50"`
+	Kinds    []string `validate:"dive,oneof=subscription rent buy"`
+	Status   string   `validate:"omitempty,oneof=want_to_watch watching watched abandoned"`
+	At       time.Time
+
+	// ServiceIDs are resolved from Services.
+	ServiceIDs []int64
+}
+
+func (f *AvailabilityFilter) Validate() error {
+	f.Region = strings.ToUpper(f.Region)
+
+	return validate.Struct(f)
+}
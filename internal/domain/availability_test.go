@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestAvailabilityInputValidate(t *testing.T) {
+	price, currency := 3.99, "usd"
+
+	inp := AvailabilityInput{ServiceID: 1, Region: "us", Kind: AvailabilityRent, Price: &price, Currency: &currency, From: "2024-01-01", Until: "2024-01-01"}
+	if err := inp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if inp.Region != "US" || *inp.Currency != "USD" {
+		t.Errorf("Validate() left region %q and currency %q, want them uppercased", inp.Region, *inp.Currency)
+	}
+
+	for _, inp := range []AvailabilityInput{
+		{ServiceID: 1, Region: "US", Kind: AvailabilityRent, From: "2024-02-01", Until: "2024-01-31"},
+		{ServiceID: 1, Region: "US", Kind: AvailabilityRent, Price: &price},
+		{ServiceID: 1, Region: "US", Kind: "stream"},
+		{ServiceID: 1, Region: "XX", Kind: AvailabilityRent},
+	} {
+		inp := inp
+		if err := inp.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", inp)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DuplicateSimilarity is the title similarity from which movies of the same
+// release year are reported as likely duplicates.
+const DuplicateSimilarity = 0.8
+
+var (
+	ErrDuplicateMovie    = errors.New("movie may already exist, add force=true to save it anyway")
+	ErrMergeSameMovie    = errors.New("movie can't be merged into itself")
+	ErrMergeTypeMismatch = errors.New("movies of different types can't be merged")
+)
+
+// DuplicateCandidate is an existing movie similar to a new one.
+type DuplicateCandidate struct {
+	ID         int         `json:"id"`
+	Title      string      `json:"title"`
+	Type       string      `json:"type"`
+	Release    ReleaseDate `json:"release"`
+	Similarity float64     `json:"similarity"`
+}
+
+// MergeMovieInput moves everything linked to a movie to the Into movie and
+// deletes it.
+type MergeMovieInput struct {
+	Into int64 `json:"into" validate:"required"`
+}
+
+func (i MergeMovieInput) Validate() error {
+	return validate.Struct(i)
+}
+
+var titleArticles = map[string]bool{"the": true, "a": true, "an": true}
+
+// NormalizeTitle returns the title in lower case without punctuation, extra
+// spaces and a leading article, so "The Witcher!" becomes "witcher".
+func NormalizeTitle(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) > 1 && titleArticles[words[0]] {
+		words = words[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// TitleSimilarity compares normalized titles, 1 means equal titles and 0
+// nothing in common.
+func TitleSimilarity(a, b string) float64 {
+	x, y := []rune(NormalizeTitle(a)), []rune(NormalizeTitle(b))
+
+	longest := len(x)
+	if len(y) > longest {
+		longest = len(y)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(x, y))/float64(longest)
+}
+
+// DuplicateTitleLengths returns the range of normalized title lengths which
+// can reach DuplicateSimilarity with the title. Titles outside of it differ
+// by more characters than allowed.
+func DuplicateTitleLengths(title string) (minLen, maxLen int) {
+	n := float64(len([]rune(NormalizeTitle(title))))
+
+	return int(math.Ceil(n * DuplicateSimilarity)), int(math.Floor(n / DuplicateSimilarity))
+}
+
+// levenshtein returns the edit distance of the strings.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minOf(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minOf(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+
+	return result
+}
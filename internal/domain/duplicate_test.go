@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"The Witcher!", "witcher"},
+		{"  A   Quiet  Place ", "quiet place"},
+		{"An", "an"},
+		{"The", "the"},
+		{"Spider-Man: No Way Home", "spider man no way home"},
+		{"Амели", "амели"},
+		{"!!!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeTitle(tt.title); got != tt.want {
+			t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"The Witcher", "witcher", 1},
+		{"Amélie", "AMÉLIE!", 1},
+		{"", "", 1},
+		{"", "Up", 0},
+		{"Matrix", "Matrx", 1 - 1.0/6},
+		{"Alien", "Aliens", 1 - 1.0/6},
+		{"Up", "Cars", 0},
+		{"A", "An", 0.5},
+	}
+
+	for _, tt := range tests {
+		got := TitleSimilarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("TitleSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+
+		if reverse := TitleSimilarity(tt.b, tt.a); math.Abs(reverse-got) > 1e-9 {
+			t.Errorf("TitleSimilarity(%q, %q) = %v, not symmetric with %v", tt.b, tt.a, reverse, got)
+		}
+	}
+}
+
+func TestDuplicateTitleLengths(t *testing.T) {
+	tests := []struct {
+		title    string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"Up", 2, 2},
+		{"Alien", 4, 6},
+		{"The Matrix", 5, 7},
+		{"Interstellar", 10, 15},
+	}
+
+	for _, tt := range tests {
+		min, max := DuplicateTitleLengths(tt.title)
+		if min != tt.min || max != tt.max {
+			t.Errorf("DuplicateTitleLengths(%q) = %d, %d, want %d, %d", tt.title, min, max, tt.min, tt.max)
+		}
+	}
+}
@@ -1,9 +1,35 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrBookNotFound        = errors.New("movie not found")
 	ErrRefreshTokenExpired = errors.New("refresh token expired")
 	ErrUserAlreadyExists   = errors.New("user with this email already exists")
+	ErrTokenRevoked        = errors.New("token revoked")
+	ErrForbidden           = errors.New("not allowed")
+	ErrEmailNotVerified    = errors.New("email is not verified")
+	ErrInvalidUserToken    = errors.New("token is invalid or expired")
+	ErrWrongPassword       = errors.New("wrong password")
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidMFACode      = errors.New("invalid two-factor authentication code")
+	ErrUnknownProvider     = errors.New("unknown identity provider")
+	ErrInvalidOIDCState    = errors.New("sign in request is invalid or expired")
+	ErrIdentityNotVerified = errors.New("identity provider didn't verify the email")
+	ErrAccountNotVerified  = errors.New("account with this email isn't verified, confirm the email first")
 )
+
+// TooManyAttemptsError is returned while sign in is locked after repeated
+// failures.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
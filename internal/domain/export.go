@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// DataExport is a copy of all personal data kept about a user.
+type DataExport struct {
+	ExportedAt    time.Time         `json:"exported_at"`
+	Profile       Profile           `json:"profile"`
+	Movies        []Movie           `json:"movies"`
+	WatchStates   []WatchState      `json:"watch_states"`
+	Episodes      []WatchedEpisode  `json:"episode_watches"`
+	Subscriptions []Subscription    `json:"subscriptions"`
+	Sessions      []ExportedSession `json:"sessions"`
+	APIKeys       []APIKey          `json:"api_keys"`
+	Identities    []UserIdentity    `json:"identities"`
+	Libraries     []Library         `json:"libraries"`
+	Invitations   ExportedInvites   `json:"invitations"`
+	History       []HistoryEvent    `json:"history"`
+}
+
+// ExportedInvites are the pending library invitations sent by and to the
+// user.
+type ExportedInvites struct {
+	Sent     []ExportedInvitation `json:"sent"`
+	Received []ExportedInvitation `json:"received"`
+}
+
+// ExportedInvitation is a library invitation without its token.
+type ExportedInvitation struct {
+	ID        int64     `json:"id"`
+	LibraryID int64     `json:"library_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy int64     `json:"invited_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExportedSession is a refresh session without its token.
+type ExportedSession struct {
+	ID        int64     `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Kinds of account history events.
+const (
+	HistoryRegistered     = "registered"
+	HistoryEmailVerified  = "email_verified"
+	HistoryIdentityLinked = "identity_linked"
+	HistoryAPIKeyCreated  = "api_key_created"
+	HistoryAPIKeyUsed     = "api_key_used"
+	HistoryMovieSaved     = "movie_saved"
+	HistoryEpisodeWatched = "episode_watched"
+)
+
+type HistoryEvent struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Detail string    `json:"detail,omitempty"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// UserIdentity links an account of an external OpenID Connect provider to a
+// user.
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCLoginRequest starts a sign in through an identity provider. State is
+// kept by the client (in a cookie) and passed back with the callback.
+type OIDCLoginRequest struct {
+	URL   string
+	State string
+}
+
+type OIDCCallbackInput struct {
+	Provider string
+	Code     string `validate:"required"`
+	// State is the state parameter returned by the provider.
+	State string `validate:"required"`
+	// RequestState is OIDCLoginRequest.State.
+	RequestState string `validate:"required"`
+}
+
+func (i OIDCCallbackInput) Validate() error {
+	return validate.Struct(i)
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Library member roles, each role has all permissions of the previous one.
+const (
+	// RoleViewer can read movies of the library.
+	RoleViewer = "viewer"
+	// RoleEditor can also add, change and delete movies.
+	RoleEditor = "editor"
+	// RoleOwner can also manage the library and its members.
+	RoleOwner = "owner"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// RoleAtLeast reports whether role grants the permissions of min.
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] >= roleRanks[min] && roleRanks[role] > 0
+}
+
+var (
+	ErrLibraryNotFound      = errors.New("library not found")
+	ErrLastOwner            = errors.New("library must keep at least one owner")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrInvitationNotForUser = errors.New("invitation was sent to another email")
+)
+
+// Library is a movie collection shared by its members. Role is the role of
+// the user the library was loaded for.
+type Library struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Role      string    `json:"role,omitempty"`
+}
+
+type LibraryMember struct {
+	UserID   int64     `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type LibraryDetails struct {
+	Library
+	Members []LibraryMember `json:"members"`
+}
+
+// LibraryInvitation is sent to an email, only the hash of its token is
+// stored.
+type LibraryInvitation struct {
+	ID        int64
+	LibraryID int64
+	Email     string
+	Role      string
+	TokenHash string
+	InvitedBy int64
+	ExpiresAt time.Time
+}
+
+type CreateLibraryInput struct {
+	Name string `json:"name" validate:"required,gte=1,lte=255"`
+}
+
+func (i CreateLibraryInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type InviteMemberInput struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+func (i InviteMemberInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type AcceptInvitationInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (i AcceptInvitationInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type UpdateMemberInput struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+func (i UpdateMemberInput) Validate() error {
+	return validate.Struct(i)
+}
@@ -1,17 +1,86 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type Movie struct {
-	ID               int       `json:"id,omitempty"`
-	Title            string    `json:"title"`
-	Release          string    `json:"release"`
-	StreamingService string    `json:"streamingService"`
-	SavedAt          time.Time `json:"savedAt,omitempty"`
+	ID    int    `json:"id,omitempty"`
+	Title string `json:"title"`
+	// Type is TitleTypeMovie or TitleTypeSeries, movies are the default.
+	Type    string      `json:"type,omitempty"`
+	Release ReleaseDate `json:"release"`
+	// Releases are the release dates in the countries.
+	Releases []CountryRelease `json:"releases,omitempty"`
+	// Runtime is the length in minutes.
+	Runtime          *int   `json:"runtime,omitempty"`
+	StreamingService string `json:"streamingService"`
+	// StreamingServiceID is the catalog entry of StreamingService.
+	StreamingServiceID *int64    `json:"streamingServiceId,omitempty"`
+	SavedAt            time.Time `json:"savedAt,omitempty"`
+	// UserID is the user who saved the movie, it is cleared when the user
+	// is erased.
+	UserID int64 `json:"-"`
+	// LibraryID is set for movies of a shared library.
+	LibraryID *int64   `json:"libraryId,omitempty"`
+	Genres    []string `json:"genres,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	// Watch is the state of the movie for the user who listed movies.
+	Watch *WatchState `json:"watch,omitempty"`
+}
+
+// Validate checks the fields a client can't be trusted with, it is used for
+// both saved and library movies.
+func (m Movie) Validate() error {
+	if !ValidTitleType(m.Type) {
+		return ErrInvalidType
+	}
+
+	if !ValidRuntime(m.Runtime) {
+		return ErrInvalidRuntime
+	}
+
+	return nil
+}
+
+var ErrInvalidRuntime = errors.New("runtime must be between 1 and 1000 minutes")
+
+// ValidRuntime reports whether the runtime in minutes is plausible, nil
+// means unknown.
+func ValidRuntime(runtime *int) bool {
+	return runtime == nil || (*runtime > 0 && *runtime <= 1000)
 }
 
 type MovieMainInfo struct {
 	Title            string `json:"title"`
-	Release          string `json:"release"`
+	Type             string `json:"type"`
+	Release          string `json:"release" example:"2010-07-16"`
+	Runtime          int    `json:"runtime" example:"148"`
 	StreamingService string `json:"streamingService"`
 }
+
+// MovieFilter narrows GET /movies. Watch filters apply to the state of
+// UserID. Tags match movies having any of the tags, or all of them when
+// Match is "all". Director and Actor are a person id or name,
+// YearFrom and YearTo include the years.
+type MovieFilter struct {
+	UserID    int64
+	Type      string   `validate:"omitempty,oneof=movie series"`
+	Status    string   `validate:"omitempty,oneof=want_to_watch watching watched abandoned"`
+	MinRating int      `validate:"omitempty,min=1,max=10"`
+	MaxRating int      `validate:"omitempty,min=1,max=10"`
+	Tags      []string `validate:"max=20"`
+	Match     string   `validate:"omitempty,oneof=any all"`
+	Director  string   `validate:"max=255"`
+	Actor     string   `validate:"max=255"`
+	YearFrom  int      `validate:"omitempty,min=1800,max=3000"`
+	YearTo    int      `validate:"omitempty,min=1800,max=3000"`
+	// Sort is "title", "release" or "-release", by default movies are
+	// sorted by id.
+	Sort string `validate:"omitempty,oneof=title release -release"`
+}
+
+func (f MovieFilter) Validate() error {
+	return validate.Struct(f)
+}
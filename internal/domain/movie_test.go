@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMovieValidate(t *testing.T) {
+	runtime := func(minutes int) *int {
+		return &minutes
+	}
+
+	tests := []struct {
+		movie Movie
+		err   error
+	}{
+		{Movie{Title: "Heat"}, nil},
+		{Movie{Title: "Heat", Type: TitleTypeMovie, Runtime: runtime(170)}, nil},
+		{Movie{Title: "Dark", Type: TitleTypeSeries, Runtime: runtime(1000)}, nil},
+		{Movie{Title: "Dark", Type: "show"}, ErrInvalidType},
+		{Movie{Title: "Heat", Runtime: runtime(0)}, ErrInvalidRuntime},
+		{Movie{Title: "Heat", Runtime: runtime(1001)}, ErrInvalidRuntime},
+	}
+
+	for _, tt := range tests {
+		if err := tt.movie.Validate(); !errors.Is(err, tt.err) {
+			t.Errorf("Validate(%+v) error = %v, want %v", tt.movie, err, tt.err)
+		}
+	}
+}
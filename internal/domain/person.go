@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Credit roles.
+const (
+	CreditDirector = "director"
+	CreditCast     = "cast"
+	CreditCrew     = "crew"
+)
+
+var (
+	ErrPersonNotFound = errors.New("person not found")
+	ErrCreditNotFound = errors.New("credit not found")
+)
+
+type Person struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Roles     []string  `json:"roles"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Credit links a person to a movie. Character is set for the cast, Job for
+// the crew.
+type Credit struct {
+	ID        int64  `json:"id"`
+	MovieID   int64  `json:"movie_id"`
+	PersonID  int64  `json:"person_id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Character string `json:"character,omitempty"`
+	Job       string `json:"job,omitempty"`
+}
+
+// FilmographyEntry is a credit of a person with the credited movie.
+type FilmographyEntry struct {
+	MovieID   int64       `json:"movie_id"`
+	Title     string      `json:"title"`
+	Type      string      `json:"type"`
+	Release   ReleaseDate `json:"release"`
+	LibraryID *int64      `json:"library_id,omitempty"`
+	Role      string      `json:"role"`
+	Character string      `json:"character,omitempty"`
+	Job       string      `json:"job,omitempty"`
+}
+
+type PersonDetails struct {
+	Person
+	Filmography []FilmographyEntry `json:"filmography"`
+}
+
+type PersonInput struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+func (i PersonInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type CreditInput struct {
+	PersonID  int64  `json:"person_id" validate:"required"`
+	Role      string `json:"role" validate:"required,oneof=director cast crew"`
+	Character string `json:"character" validate:"max=255"`
+	Job       string `json:"job" validate:"required_if=Role crew,max=255"`
+}
+
+func (i CreditInput) Validate() error {
+	return validate.Struct(i)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "testing"
+
+func TestCreditInput(t *testing.T) {
+	tests := []struct {
+		inp CreditInput
+		ok  bool
+	}{
+		{CreditInput{PersonID: 1, Role: CreditDirector}, true},
+		{CreditInput{PersonID: 1, Role: CreditCast}, true},
+		{CreditInput{PersonID: 1, Role: CreditCrew, Job: "Editor"}, true},
+		{CreditInput{PersonID: 1, Role: CreditCrew}, false},
+		{CreditInput{PersonID: 1, Role: "producer"}, false},
+		{CreditInput{Role: CreditDirector}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.inp.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) error = %v, want ok %v", tt.inp, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// PosterOriginal is the size name of the uploaded image.
+const PosterOriginal = "original"
+
+// Poster limits, larger images are rejected before they are decoded. A
+// decoded image takes 4 bytes per pixel.
+const (
+	PosterMaxWidth  = 8000
+	PosterMaxHeight = 8000
+	PosterMaxPixels = 40_000_000
+)
+
+var (
+	ErrPosterNotFound = errors.New("poster not found")
+	ErrPosterTooLarge = errors.New("poster is too large")
+	ErrPosterType     = errors.New("poster must be a JPEG, PNG or GIF image")
+	ErrPosterSize     = errors.New("poster dimensions are out of range")
+)
+
+// Poster is the artwork of a movie. The original is kept as uploaded, Sizes
+// are the names of the JPEG thumbnails; all of them are stored under Key.
+type Poster struct {
+	MovieID     int64     `json:"movie_id"`
+	Key         string    `json:"-"`
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int       `json:"size"`
+	Sizes       []string  `json:"sizes"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// URLs maps the size names, including PosterOriginal, to their URLs.
+	URLs map[string]string `json:"urls,omitempty"`
+}
+
+// PosterImage is one size of a poster.
+type PosterImage struct {
+	ContentType string
+	ETag        string
+	UpdatedAt   time.Time
+	// MaxAge is how long clients may cache the image.
+	MaxAge time.Duration
+	Data   []byte
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Precisions of release dates.
+const (
+	ReleasePrecisionDay  = "day"
+	ReleasePrecisionYear = "year"
+)
+
+const (
+	releaseDayLayout  = "2006-01-02"
+	releaseYearLayout = "2006"
+)
+
+var errReleaseFormat = errors.New("release must be a year (2006) or a date (2006-01-02)")
+
+// ReleaseDate is a release date known to the day or only to the year. In
+// JSON it is "2006-01-02", "2006" or null when unknown.
+type ReleaseDate struct {
+	Date      time.Time
+	Precision string
+}
+
+// ParseReleaseDate parses "2006" and "2006-01-02", an empty string is an
+// unknown date.
+func ParseReleaseDate(value string) (ReleaseDate, error) {
+	value = strings.TrimSpace(value)
+
+	switch len(value) {
+	case 0:
+		return ReleaseDate{}, nil
+	case len(releaseYearLayout):
+		date, err := time.Parse(releaseYearLayout, value)
+		if err != nil {
+			return ReleaseDate{}, errReleaseFormat
+		}
+		return ReleaseDate{Date: date, Precision: ReleasePrecisionYear}, nil
+	default:
+		date, err := time.Parse(releaseDayLayout, value)
+		if err != nil {
+			return ReleaseDate{}, errReleaseFormat
+		}
+		return ReleaseDate{Date: date, Precision: ReleasePrecisionDay}, nil
+	}
+}
+
+func (d ReleaseDate) IsZero() bool {
+	return d.Date.IsZero()
+}
+
+func (d ReleaseDate) Year() int {
+	return d.Date.Year()
+}
+
+func (d ReleaseDate) String() string {
+	switch {
+	case d.IsZero():
+		return ""
+	case d.Precision == ReleasePrecisionYear:
+		return d.Date.Format(releaseYearLayout)
+	default:
+		return d.Date.Format(releaseDayLayout)
+	}
+}
+
+func (d ReleaseDate) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(d.String())
+}
+
+func (d *ReleaseDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = ReleaseDate{}
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errReleaseFormat
+	}
+
+	parsed, err := ParseReleaseDate(value)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+// CountryRelease is the release date in a country, Country is an ISO
+// 3166-1 alpha-2 code.
+type CountryRelease struct {
+	Country string      `json:"country" validate:"iso3166_1_alpha2"`
+	Date    ReleaseDate `json:"date"`
+}
+
+type CountryReleasesInput struct {
+	Releases []CountryRelease `json:"releases" validate:"max=250,dive"`
+}
+
+func (i CountryReleasesInput) Validate() error {
+	seen := make(map[string]bool, len(i.Releases))
+	for n, release := range i.Releases {
+		country := strings.ToUpper(release.Country)
+		if seen[country] {
+			return fmt.Errorf("country %s is listed twice", country)
+		}
+		if release.Date.IsZero() {
+			return fmt.Errorf("release date of %s is missing", country)
+		}
+		seen[country] = true
+		i.Releases[n].Country = country
+	}
+
+	return validate.Struct(i)
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		value     string
+		date      time.Time
+		precision string
+		err       bool
+	}{
+		{value: ""},
+		{value: "   "},
+		{value: "2010", date: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), precision: ReleasePrecisionYear},
+		{value: " 2010 ", date: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), precision: ReleasePrecisionYear},
+		{value: "2010-07-16", date: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC), precision: ReleasePrecisionDay},
+		{value: "2012-02-29", date: time.Date(2012, 2, 29, 0, 0, 0, 0, time.UTC), precision: ReleasePrecisionDay},
+		{value: "2010-02-30", err: true},
+		{value: "2010-13-01", err: true},
+		{value: "2010-7-16", err: true},
+		{value: "2010-07", err: true},
+		{value: "16.07.2010", err: true},
+		{value: "07/16/2010", err: true},
+		{value: "abcd", err: true},
+		{value: "201", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReleaseDate(tt.value)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseReleaseDate(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseReleaseDate(%q) error = %v", tt.value, err)
+			continue
+		}
+
+		if !got.Date.Equal(tt.date) || got.Precision != tt.precision {
+			t.Errorf("ParseReleaseDate(%q) = %s %s, want %s %s", tt.value, got.Date, got.Precision, tt.date, tt.precision)
+		}
+	}
+}
+
+func TestReleaseDateJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`null`, `null`},
+		{`""`, `null`},
+		{`"2010"`, `"2010"`},
+		{`"2010-07-16"`, `"2010-07-16"`},
+	}
+
+	for _, tt := range tests {
+		var d ReleaseDate
+		if err := d.UnmarshalJSON([]byte(tt.json)); err != nil {
+			t.Errorf("UnmarshalJSON(%s) error = %v", tt.json, err)
+			continue
+		}
+
+		got, err := d.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(got) != tt.want {
+			t.Errorf("round trip of %s = %s, want %s", tt.json, got, tt.want)
+		}
+	}
+
+	var d ReleaseDate
+	if err := d.UnmarshalJSON([]byte(`2010`)); err == nil {
+		t.Error("UnmarshalJSON accepted a number")
+	}
+}
+
+func TestCountryReleasesInput(t *testing.T) {
+	date, err := ParseReleaseDate("2010-07-16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inp := CountryReleasesInput{Releases: []CountryRelease{{Country: "us", Date: date}, {Country: "DE", Date: date}}}
+	if err := inp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if inp.Releases[0].Country != "US" {
+		t.Errorf("Country = %q, want it uppercased", inp.Releases[0].Country)
+	}
+
+	for _, inp := range []CountryReleasesInput{
+		{Releases: []CountryRelease{{Country: "US", Date: date}, {Country: "us", Date: date}}},
+		{Releases: []CountryRelease{{Country: "US"}}},
+		{Releases: []CountryRelease{{Country: "XX", Date: date}}},
+	} {
+		if err := inp.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", inp)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Title types of movies.
+const (
+	TitleTypeMovie  = "movie"
+	TitleTypeSeries = "series"
+)
+
+var (
+	ErrSeriesNotFound  = errors.New("series not found")
+	ErrSeasonNotFound  = errors.New("season not found")
+	ErrEpisodeNotFound = errors.New("episode not found")
+	ErrSeasonExists    = errors.New("season with this number already exists")
+	ErrEpisodeExists   = errors.New("episode with this number already exists")
+	ErrNoNextEpisode   = errors.New("all episodes are watched")
+	ErrInvalidType     = errors.New("type must be movie or series")
+)
+
+// ValidTitleType reports whether t is a title type, empty means the default
+// type of the operation.
+func ValidTitleType(t string) bool {
+	return t == "" || t == TitleTypeMovie || t == TitleTypeSeries
+}
+
+// Series is a movie of the series type with its seasons. Watched flags are
+// those of the user who requested the series.
+type Series struct {
+	Movie
+	Seasons []Season `json:"seasons"`
+}
+
+type Season struct {
+	ID       int64     `json:"id"`
+	Number   int       `json:"number"`
+	Title    string    `json:"title,omitempty"`
+	Episodes []Episode `json:"episodes"`
+}
+
+type Episode struct {
+	ID           int64      `json:"id"`
+	SeasonNumber int        `json:"season"`
+	Number       int        `json:"number"`
+	Title        string     `json:"title,omitempty"`
+	AirDate      *time.Time `json:"air_date,omitempty"`
+	WatchedAt    *time.Time `json:"watched_at,omitempty"`
+}
+
+// WatchedEpisode is an episode the user marked as watched.
+type WatchedEpisode struct {
+	MovieID      int64     `json:"movie_id"`
+	SeriesTitle  string    `json:"series_title"`
+	SeasonNumber int       `json:"season"`
+	Number       int       `json:"number"`
+	Title        string    `json:"title,omitempty"`
+	WatchedAt    time.Time `json:"watched_at"`
+}
+
+type SeasonInput struct {
+	Number int    `json:"number" validate:"min=0,max=1000"`
+	Title  string `json:"title" validate:"max=255"`
+}
+
+func (i SeasonInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// EpisodeInput describes an episode, AirDate has the "2006-01-02" format.
+type EpisodeInput struct {
+	Number  int    `json:"number" validate:"min=0,max=10000"`
+	Title   string `json:"title" validate:"max=255"`
+	AirDate string `json:"air_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+func (i EpisodeInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// ParsedAirDate returns the air date, nil if it isn't set.
+func (i EpisodeInput) ParsedAirDate() *time.Time {
+	if i.AirDate == "" {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", i.AirDate)
+	if err != nil {
+		return nil
+	}
+
+	return &date
+}
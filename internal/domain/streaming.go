@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrStreamingServiceNotFound = errors.New("streaming service not found")
+	ErrUnknownStreamingService  = errors.New("unknown streaming service, ask an admin to add it to the catalog")
+	ErrStreamingServiceExists   = errors.New("streaming service name or alias is already used")
+	ErrStreamingServiceInUse    = errors.New("streaming service is used by movies or availabilities")
+)
+
+// StreamingService is a catalog entry. Movies store the canonical Name, the
+// name and the aliases are matched by their keys, see StreamingServiceKey.
+type StreamingService struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases"`
+	Regions   []string  `json:"regions"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StreamingServiceKey returns the lookup key of a service name: lowercase
+// letters, digits and "+", so that "Disney+", "disney +" and "DISNEY+" match.
+func StreamingServiceKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// StreamingServiceInput describes a catalog entry, Regions are ISO 3166-1
+// alpha-2 country codes and empty means available everywhere.
+type StreamingServiceInput struct {
+	Name    string   `json:"name" validate:"required,max=255"`
+	Aliases []string `json:"aliases" validate:"max=50,dive,required,max=255"`
+	Regions []string `json:"regions" validate:"max=250,dive,iso3166_1_alpha2"`
+}
+
+func (i StreamingServiceInput) Validate() error {
+	for n, region := range i.Regions {
+		i.Regions[n] = strings.ToUpper(region)
+	}
+
+	if err := validate.Struct(i); err != nil {
+		return err
+	}
+
+	if StreamingServiceKey(i.Name) == "" {
+		return errors.New("name must contain letters or digits")
+	}
+
+	return nil
+}
+
+// Keys returns the distinct non-empty keys of the name and the aliases.
+func (i StreamingServiceInput) Keys() []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0, len(i.Aliases)+1)
+	for _, name := range append([]string{i.Name}, i.Aliases...) {
+		key := StreamingServiceKey(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStreamingServiceKey(t *testing.T) {
+	for _, name := range []string{"Disney+", "disney +", "DISNEY+", " Disney-+ "} {
+		if got := StreamingServiceKey(name); got != "disney+" {
+			t.Errorf("StreamingServiceKey(%q) = %q, want %q", name, got, "disney+")
+		}
+	}
+}
+
+func TestStreamingServiceInputKeys(t *testing.T) {
+	inp := StreamingServiceInput{Name: "Disney+", Aliases: []string{"disney plus", "DISNEY +", "--"}}
+
+	if got, want := inp.Keys(), []string{"disney+", "disneyplus"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %q, want %q", got, want)
+	}
+}
+
+func TestStreamingServiceInputValidate(t *testing.T) {
+	inp := StreamingServiceInput{Name: "Netflix", Regions: []string{"us", "De"}}
+	if err := inp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"US", "DE"}; !reflect.DeepEqual(inp.Regions, want) {
+		t.Errorf("Regions = %q, want %q", inp.Regions, want)
+	}
+
+	for _, inp := range []StreamingServiceInput{
+		{Name: "!!!"},
+		{Name: "Netflix", Regions: []string{"XX"}},
+	} {
+		if err := inp.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", inp)
+		}
+	}
+}
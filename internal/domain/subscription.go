@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Subscription is a streaming service a user pays for in a region.
+type Subscription struct {
+	ServiceID int64     `json:"service_id"`
+	Service   string    `json:"service"`
+	Region    string    `json:"region"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SubscriptionInput struct {
+	ServiceID int64  `json:"service_id" validate:"required"`
+	Region    string `json:"region" validate:"iso3166_1_alpha2"`
+}
+
+// SubscriptionsInput replaces all subscriptions of a user.
+type SubscriptionsInput struct {
+	Subscriptions []SubscriptionInput `json:"subscriptions" validate:"max=50,dive"`
+}
+
+func (i *SubscriptionsInput) Validate() error {
+	for j := range i.Subscriptions {
+		i.Subscriptions[j].Region = strings.ToUpper(i.Subscriptions[j].Region)
+	}
+
+	return validate.Struct(i)
+}
+
+// AvailableFilter narrows GET /movies/available, the unwatched movies on the
+// subscriptions of UserID. Region limits the subscriptions to one region,
+// MaxRuntime is in minutes.
+type AvailableFilter struct {
+	UserID     int64
+	Region     string `validate:"omitempty,iso3166_1_alpha2"`
+	MaxRuntime int    `validate:"omitempty,min=1,max=1000"`
+	Genre      string `validate:"max=64"`
+	At         time.Time
+}
+
+func (f *AvailableFilter) Validate() error {
+	f.Region = strings.ToUpper(f.Region)
+	f.Genre = NormalizeTag(f.Genre)
+
+	return validate.Struct(f)
+}
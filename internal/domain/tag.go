@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Values of MovieFilter.Match.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag with this name already exists, merge the tags instead")
+)
+
+// Tag is a free-form label shared by all users.
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Movies is the number of tagged movies.
+	Movies int `json:"movies"`
+}
+
+type Genre struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// NormalizeTag returns the stored form of a tag or genre name.
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// NormalizeTags normalizes the names and removes duplicates.
+func NormalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = NormalizeTag(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+
+	return result
+}
+
+type TagsInput struct {
+	Tags []string `json:"tags" validate:"required,min=1,max=50,dive,required,max=64,excludesall=0x2C"`
+}
+
+func (i TagsInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type GenresInput struct {
+	Genres []string `json:"genres" validate:"max=20,dive,required,max=64,excludesall=0x2C"`
+}
+
+func (i GenresInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type RenameTagInput struct {
+	Name string `json:"name" validate:"required,max=64,excludesall=0x2C"`
+}
+
+func (i RenameTagInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// MergeTagInput moves all movies of a tag to the Into tag and deletes it.
+type MergeTagInput struct {
+	Into int64 `json:"into" validate:"required"`
+}
+
+func (i MergeTagInput) Validate() error {
+	return validate.Struct(i)
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{"  Film   Noir ", "film noir", "", "   ", "Heist"})
+	want := []string{"film noir", "heist"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTags() = %q, want %q", got, want)
+	}
+}
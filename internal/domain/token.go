@@ -2,9 +2,49 @@ package domain
 
 import "time"
 
+// SignInResult holds either the token pair or, when the user has two-factor
+// authentication enabled, a short-lived MFA challenge token that must be
+// exchanged together with a code.
+type SignInResult struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
+
 type RefreshSession struct {
 	ID        int64
 	UserID    int64
 	Token     string
 	ExpiresAt time.Time
 }
+
+// RevokedToken is an access token that must be rejected until it expires.
+type RevokedToken struct {
+	ID        string
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+// UserRevocation rejects every access token of a user issued at or before
+// RevokedAt.
+type UserRevocation struct {
+	UserID    int64
+	RevokedAt time.Time
+}
+
+// Kinds of single-use tokens sent to users by email.
+const (
+	UserTokenVerifyEmail   = "verify_email"
+	UserTokenResetPassword = "reset_password"
+	UserTokenChangeEmail   = "change_email"
+)
+
+// UserToken is a single-use token sent to a user. Only the hash of the token
+// is stored.
+type UserToken struct {
+	ID        int64
+	UserID    int64
+	Kind      string
+	TokenHash string
+	ExpiresAt time.Time
+}
@@ -16,11 +16,61 @@ func init() {
 var ErrUserNotFound = errors.New("user with such credentials not found")
 
 type User struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Password     string    `json:"password"`
-	RegisteredAt time.Time `json:"registered_at"`
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Password     string     `json:"-"`
+	RegisteredAt time.Time  `json:"registered_at"`
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+	PendingEmail *string    `json:"-"`
+	TOTPSecret   string     `json:"-"`
+	TOTPEnabled  bool       `json:"totp_enabled"`
+	// Admin users disable other users and manage shared catalogs, the flag is
+	// set in the database.
+	Admin bool `json:"-"`
+}
+
+// Profile is the representation of a user returned by the API, it never
+// contains credentials.
+type Profile struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	PendingEmail *string    `json:"pending_email,omitempty"`
+	RegisteredAt time.Time  `json:"registered_at"`
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+	TOTPEnabled  bool       `json:"totp_enabled"`
+}
+
+func (u User) Profile() Profile {
+	return Profile{
+		ID:           u.ID,
+		Name:         u.Name,
+		Email:        u.Email,
+		PendingEmail: u.PendingEmail,
+		RegisteredAt: u.RegisteredAt,
+		VerifiedAt:   u.VerifiedAt,
+		TOTPEnabled:  u.TOTPEnabled,
+	}
+}
+
+// UpdateProfileInput changes only the fields that are set. A new email is
+// applied after it is confirmed by following the link sent to it.
+type UpdateProfileInput struct {
+	Name  *string `json:"name" validate:"omitempty,gte=2"`
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+func (i UpdateProfileInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"required"`
+}
+
+func (i DeleteAccountInput) Validate() error {
+	return validate.Struct(i)
 }
 
 type SignUpInput struct {
@@ -41,3 +91,69 @@ type SignInInput struct {
 func (i SignInInput) Validate() error {
 	return validate.Struct(i)
 }
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (i VerifyEmailInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (i ResendVerificationInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (i ForgotPasswordInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gte=6"`
+}
+
+func (i ResetPasswordInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,gte=6"`
+}
+
+func (i ChangePasswordInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// TOTPEnrollment is returned when a user starts enrolling an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFACodeInput struct {
+	// Code is either a TOTP code or a recovery code.
+	Code string `json:"code" validate:"required"`
+}
+
+func (i MFACodeInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type VerifyMFAInput struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+func (i VerifyMFAInput) Validate() error {
+	return validate.Struct(i)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Watch statuses of a movie for a user.
+const (
+	WatchStatusWant      = "want_to_watch"
+	WatchStatusWatching  = "watching"
+	WatchStatusWatched   = "watched"
+	WatchStatusAbandoned = "abandoned"
+)
+
+var ErrWatchEventNotFound = errors.New("watch date not found")
+
+// WatchState is the private state of a movie for a single user.
+type WatchState struct {
+	MovieID int64  `json:"movie_id"`
+	Status  string `json:"status"`
+	// Progress is the watched part of the movie in percent.
+	Progress  *int         `json:"progress,omitempty"`
+	Rating    *int         `json:"rating,omitempty"`
+	Notes     string       `json:"notes,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Watches   []WatchEvent `json:"watches,omitempty"`
+}
+
+// WatchEvent is a single (re)watch of a movie.
+type WatchEvent struct {
+	ID        int64     `json:"id"`
+	WatchedAt time.Time `json:"watched_at"`
+}
+
+type UpdateWatchStateInput struct {
+	Status   string `json:"status" validate:"required,oneof=want_to_watch watching watched abandoned"`
+	Progress *int   `json:"progress" validate:"omitempty,min=0,max=100"`
+	Rating   *int   `json:"rating" validate:"omitempty,min=1,max=10"`
+	Notes    string `json:"notes" validate:"lte=10000"`
+}
+
+func (i UpdateWatchStateInput) Validate() error {
+	return validate.Struct(i)
+}
+
+// MarkWatchedInput records a watch, WatchedAt defaults to now.
+type MarkWatchedInput struct {
+	WatchedAt *time.Time `json:"watched_at"`
+}
+
+func (i MarkWatchedInput) Validate() error {
+	if i.WatchedAt != nil && i.WatchedAt.After(time.Now()) {
+		return errors.New("watched_at can't be in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkWatchedInput(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	if err := (MarkWatchedInput{WatchedAt: &past}).Validate(); err != nil {
+		t.Errorf("Validate() of a past watch error = %v", err)
+	}
+
+	if err := (MarkWatchedInput{WatchedAt: &future}).Validate(); err == nil {
+		t.Error("Validate() of a future watch succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+type APIKeys struct {
+	db *sql.DB
+}
+
+func NewAPIKeys(db *sql.DB) *APIKeys {
+	return &APIKeys{db}
+}
+
+func (r *APIKeys) Create(ctx context.Context, key domain.APIKey) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, "INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at) values ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt, key.CreatedAt).
+		Scan(&id)
+
+	return id, err
+}
+
+func (r *APIKeys) List(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys WHERE user_id=$1 AND revoked_at IS NULL ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]domain.APIKey, 0)
+	for rows.Next() {
+		var k domain.APIKey
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetByHash returns a key that is not revoked and belongs to an active user.
+func (r *APIKeys) GetByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	var k domain.APIKey
+	err := r.db.QueryRowContext(ctx, `SELECT k.id, k.user_id, k.name, k.prefix, k.scopes, k.expires_at, k.last_used_at, k.created_at
+		FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash=$1 AND k.revoked_at IS NULL AND u.disabled_at IS NULL`, keyHash).
+		Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt)
+
+	return k, err
+}
+
+func (r *APIKeys) Touch(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at=now() WHERE id=$1", id)
+
+	return err
+}
+
+// Revoke revokes the key of the user and reports whether it existed.
+func (r *APIKeys) Revoke(ctx context.Context, userID, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at=now() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL", id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+type Availability struct {
+	db *sql.DB
+}
+
+func NewAvailability(db *sql.DB) *Availability {
+	return &Availability{db}
+}
+
+const availabilityColumns = "a.id, a.movie_id, a.service_id, s.name, a.region, a.kind, a.price, a.currency, a.available_from, a.available_until"
+
+// availableColumns are the offers with the movies.
+const availableColumns = availabilityColumns + ", m.title, m.type, m.release_date, m.release_precision, m.runtime "
+
+// ListByMovie returns the availability of the movie, in all regions if the
+// region is empty.
+func (r *Availability) ListByMovie(ctx context.Context, movieID int64, region string) ([]domain.Availability, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+availabilityColumns+" FROM availabilities a JOIN streaming_services s ON s.id = a.service_id "+
+		"WHERE a.movie_id=$1 AND ($2 = '' OR a.region = $2) ORDER BY a.region, s.name, a.kind", movieID, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	availabilities := make([]domain.Availability, 0)
+	for rows.Next() {
+		var a domain.Availability
+		if err := rows.Scan(&a.ID, &a.MovieID, &a.ServiceID, &a.Service, &a.Region, &a.Kind, &a.Price, &a.Currency, &a.From, &a.Until); err != nil {
+			return nil, err
+		}
+		availabilities = append(availabilities, a)
+	}
+
+	return availabilities, rows.Err()
+}
+
+func (r *Availability) Get(ctx context.Context, movieID, id int64) (domain.Availability, error) {
+	var a domain.Availability
+	err := r.db.QueryRowContext(ctx, "SELECT "+availabilityColumns+" FROM availabilities a JOIN streaming_services s ON s.id = a.service_id WHERE a.id=$1 AND a.movie_id=$2", id, movieID).
+		Scan(&a.ID, &a.MovieID, &a.ServiceID, &a.Service, &a.Region, &a.Kind, &a.Price, &a.Currency, &a.From, &a.Until)
+
+	return a, err
+}
+
+func (r *Availability) Create(ctx context.Context, movieID int64, inp domain.AvailabilityInput) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, "INSERT INTO availabilities (movie_id, service_id, region, kind, price, currency, available_from, available_until) "+
+		"values ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		movieID, inp.ServiceID, inp.Region, inp.Kind, inp.Price, inp.Currency, inp.FromDate(), inp.UntilDate()).Scan(&id)
+
+	return id, err
+}
+
+func (r *Availability) Update(ctx context.Context, id int64, inp domain.AvailabilityInput) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE availabilities SET service_id=$1, region=$2, kind=$3, price=$4, currency=$5, available_from=$6, available_until=$7 WHERE id=$8",
+		inp.ServiceID, inp.Region, inp.Kind, inp.Price, inp.Currency, inp.FromDate(), inp.UntilDate(), id)
+
+	return err
+}
+
+// Delete returns false if the movie has no such availability.
+func (r *Availability) Delete(ctx context.Context, movieID, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM availabilities WHERE id=$1 AND movie_id=$2", id, movieID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+// Search returns the movies of the common list with their offers matching
+// the filter, ordered by title.
+func (r *Availability) Search(ctx context.Context, filter domain.AvailabilityFilter) ([]domain.AvailableMovie, error) {
+	query := "SELECT " + availableColumns +
+		"FROM availabilities a JOIN streaming_services s ON s.id = a.service_id JOIN movies m ON m.id = a.movie_id AND m.library_id IS NULL"
+	args := []interface{}{filter.Region, filter.At}
+
+	if filter.Status != "" {
+		args = append(args, filter.UserID, filter.Status)
+		query += fmt.Sprintf(" JOIN watch_states w ON w.movie_id = m.id AND w.user_id = $%d AND w.status = $%d", len(args)-1, len(args))
+	}
+
+	query += " WHERE a.region = $1 AND (a.available_from IS NULL OR a.available_from <= $2::date) AND (a.available_until IS NULL OR a.available_until >= $2::date)"
+
+	if len(filter.ServiceIDs) > 0 {
+		args = append(args, pq.Array(filter.ServiceIDs))
+		query += fmt.Sprintf(" AND a.service_id = any($%d)", len(args))
+	}
+	if len(filter.Kinds) > 0 {
+		args = append(args, pq.Array(filter.Kinds))
+		query += fmt.Sprintf(" AND a.kind = any($%d)", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY lower(m.title), m.id, s.name, a.kind", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAvailableMovies(rows)
+}
+
+// Available returns the movies of the common list and of the user's
+// libraries which the user hasn't watched or abandoned and can watch on their
+// subscriptions, ordered by title.
+func (r *Availability) Available(ctx context.Context, filter domain.AvailableFilter) ([]domain.AvailableMovie, error) {
+	query := "SELECT " + availableColumns +
+		"FROM availabilities a JOIN streaming_services s ON s.id = a.service_id JOIN movies m ON m.id = a.movie_id " +
+		"AND (m.library_id IS NULL OR m.library_id IN (SELECT library_id FROM library_members WHERE user_id = $1)) " +
+		"JOIN user_subscriptions us ON us.service_id = a.service_id AND us.region = a.region AND us.user_id = $1 " +
+		"WHERE a.kind = 'subscription' AND (a.available_from IS NULL OR a.available_from <= $2::date) AND (a.available_until IS NULL OR a.available_until >= $2::date) " +
+		"AND NOT EXISTS (SELECT 1 FROM watch_states w WHERE w.movie_id = m.id AND w.user_id = $1 AND w.status IN ('watched', 'abandoned'))"
+	args := []interface{}{filter.UserID, filter.At}
+
+	if filter.Region != "" {
+		args = append(args, filter.Region)
+		query += fmt.Sprintf(" AND a.region = $%d", len(args))
+	}
+	if filter.MaxRuntime > 0 {
+		args = append(args, filter.MaxRuntime)
+		query += fmt.Sprintf(" AND m.runtime <= $%d", len(args))
+	}
+	if filter.Genre != "" {
+		args = append(args, filter.Genre)
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id WHERE mg.movie_id = m.id AND g.name = $%d)", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY lower(m.title), m.id, s.name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAvailableMovies(rows)
+}
+
+// scanAvailableMovies groups the offers of rows ordered by movie.
+func scanAvailableMovies(rows *sql.Rows) ([]domain.AvailableMovie, error) {
+	movies := make([]domain.AvailableMovie, 0)
+	for rows.Next() {
+		var (
+			a       domain.Availability
+			movie   domain.AvailableMovie
+			release releaseColumns
+		)
+		if err := rows.Scan(&a.ID, &a.MovieID, &a.ServiceID, &a.Service, &a.Region, &a.Kind, &a.Price, &a.Currency, &a.From, &a.Until,
+			&movie.Title, &movie.Type, &release.date, &release.precision, &movie.Runtime); err != nil {
+			return nil, err
+		}
+
+		if n := len(movies); n > 0 && movies[n-1].MovieID == a.MovieID {
+			movies[n-1].Offers = append(movies[n-1].Offers, a)
+			continue
+		}
+
+		movie.MovieID = a.MovieID
+		movie.Release = release.value()
+		movie.Offers = []domain.Availability{a}
+		movies = append(movies, movie)
+	}
+
+	return movies, rows.Err()
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+// ListForDuplicates returns up to limit movies of the common list of the type
+// which were released in the year or have no release date, and whose
+// normalized title length is within minLen and maxLen. Titles closest in
+// length come first. A zero year doesn't filter on the release date.
+func (m *Movies) ListForDuplicates(ctx context.Context, titleType string, year, minLen, maxLen, limit int) ([]domain.Movie, error) {
+	rows, err := m.db.QueryContext(ctx, "select id, title, type, release_date, release_precision from movies "+
+		"where library_id is null and type = $1 and ($2 = 0 or release_date is null or extract(year from release_date) = $2) "+
+		"and char_length(normalized_title) between $3 and $4 "+
+		"order by abs(char_length(normalized_title) - ($3 + $4) / 2), id limit $5", titleType, year, minLen, maxLen, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := make([]domain.Movie, 0)
+	for rows.Next() {
+		var (
+			m       domain.Movie
+			release releaseColumns
+		)
+		if err := rows.Scan(&m.ID, &m.Title, &m.Type, &release.date, &release.precision); err != nil {
+			return nil, err
+		}
+		m.Release = release.value()
+		movies = append(movies, m)
+	}
+
+	return movies, rows.Err()
+}
+
+// Merge moves genres, tags, watch states and history, credits, releases,
+// availability, seasons and the poster of the movie to the into movie and
+// deletes it. Details missing on the into movie are taken from the merged
+// one; for watch states the most recently updated status wins. If both movies
+// have a poster, the one of the movie is dropped and returned so that its
+// images can be deleted.
+func (m *Movies) Merge(ctx context.Context, id, into int64) (domain.Poster, error) {
+	var dropped domain.Poster
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dropped, err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"update movies t set release_date = coalesce(t.release_date, s.release_date), " +
+			"release_precision = case when t.release_date is null then s.release_precision else t.release_precision end, " +
+			"runtime = coalesce(t.runtime, s.runtime), " +
+			"streaming_service = case when t.streaming_service = '' then s.streaming_service else t.streaming_service end, " +
+			"streaming_service_id = case when t.streaming_service = '' then s.streaming_service_id else t.streaming_service_id end " +
+			"from movies s where s.id = $1 and t.id = $2",
+		"insert into movie_genres (movie_id, genre_id) select $2, genre_id from movie_genres where movie_id = $1 on conflict do nothing",
+		"insert into movie_tags (movie_id, tag_id) select $2, tag_id from movie_tags where movie_id = $1 on conflict do nothing",
+		"insert into watch_states (user_id, movie_id, status, progress, rating, notes, updated_at) " +
+			"select user_id, $2, status, progress, rating, notes, updated_at from watch_states where movie_id = $1 " +
+			"on conflict (user_id, movie_id) do update set " +
+			"status = case when excluded.updated_at > watch_states.updated_at then excluded.status else watch_states.status end, " +
+			"progress = case when excluded.updated_at > watch_states.updated_at then excluded.progress else watch_states.progress end, " +
+			"rating = coalesce(watch_states.rating, excluded.rating), " +
+			"notes = case when watch_states.notes = '' then excluded.notes else watch_states.notes end, " +
+			"updated_at = greatest(watch_states.updated_at, excluded.updated_at)",
+		"update watch_events set movie_id = $2 where movie_id = $1",
+		"update movie_credits c set movie_id = $2 where c.movie_id = $1 and not exists (select 1 from movie_credits x " +
+			"where x.movie_id = $2 and x.person_id = c.person_id and x.role = c.role and x.character = c.character and x.job = c.job)",
+		"insert into movie_releases (movie_id, country, release_date, release_precision) " +
+			"select $2, country, release_date, release_precision from movie_releases where movie_id = $1 on conflict do nothing",
+		"update availabilities a set movie_id = $2 where a.movie_id = $1 and not exists (select 1 from availabilities x " +
+			"where x.movie_id = $2 and x.service_id = a.service_id and x.region = a.region and x.kind = a.kind)",
+		// episodes missing in a season of the into series move there, watches
+		// of the other episodes go to the matching episodes
+		"update episodes e set season_id = t.id from seasons s, seasons t where e.season_id = s.id and s.movie_id = $1 " +
+			"and t.movie_id = $2 and t.number = s.number and not exists (select 1 from episodes x where x.season_id = t.id and x.number = e.number)",
+		"insert into episode_watches (user_id, episode_id, watched_at) select w.user_id, te.id, w.watched_at from episode_watches w " +
+			"join episodes e on e.id = w.episode_id join seasons s on s.id = e.season_id and s.movie_id = $1 " +
+			"join seasons t on t.movie_id = $2 and t.number = s.number join episodes te on te.season_id = t.id and te.number = e.number " +
+			"on conflict do nothing",
+		"update seasons set movie_id = $2 where movie_id = $1 and number not in (select number from seasons where movie_id = $2)",
+		"update movie_posters set movie_id = $2 where movie_id = $1 and not exists (select 1 from movie_posters where movie_id = $2)",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id, into); err != nil {
+			return dropped, err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, "delete from movie_posters where movie_id = $1 returning movie_id, key, sizes", id).
+		Scan(&dropped.MovieID, &dropped.Key, pq.Array(&dropped.Sizes))
+	if err != nil && err != sql.ErrNoRows {
+		return dropped, err
+	}
+
+	if err := deleteMovieLinks(ctx, tx, id); err != nil {
+		return dropped, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "delete from movies where id = $1", id); err != nil {
+		return dropped, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dropped, err
+	}
+
+	// missing cache entries are fine
+	m.cache.Delete(fmt.Sprint(id))
+	m.cache.Delete(fmt.Sprint(into))
+	return dropped, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type Identities struct {
+	db *sql.DB
+}
+
+func NewIdentities(db *sql.DB) *Identities {
+	return &Identities{db}
+}
+
+func (r *Identities) Create(ctx context.Context, identity domain.UserIdentity) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO user_identities (user_id, provider, subject, email, created_at) values ($1, $2, $3, $4, $5)",
+		identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt)
+
+	return err
+}
+
+func (r *Identities) Get(ctx context.Context, provider, subject string) (domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	err := r.db.QueryRowContext(ctx, "SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE provider=$1 AND subject=$2", provider, subject).
+		Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+
+	return identity, err
+}
+
+func (r *Identities) ListByUser(ctx context.Context, userID int64) ([]domain.UserIdentity, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE user_id=$1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := make([]domain.UserIdentity, 0)
+	for rows.Next() {
+		var identity domain.UserIdentity
+		if err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type Libraries struct {
+	db *sql.DB
+}
+
+func NewLibraries(db *sql.DB) *Libraries {
+	return &Libraries{db}
+}
+
+// Create stores the library with its first owner.
+func (r *Libraries) Create(ctx context.Context, name string, ownerID int64) (domain.Library, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Library{}, err
+	}
+	defer tx.Rollback()
+
+	library := domain.Library{Name: name, Role: domain.RoleOwner}
+	if err := tx.QueryRowContext(ctx, "INSERT INTO libraries (name) values ($1) RETURNING id, created_at", name).
+		Scan(&library.ID, &library.CreatedAt); err != nil {
+		return domain.Library{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO library_members (library_id, user_id, role) values ($1, $2, $3)",
+		library.ID, ownerID, domain.RoleOwner); err != nil {
+		return domain.Library{}, err
+	}
+
+	return library, tx.Commit()
+}
+
+// ListByUser returns the libraries the user is a member of.
+func (r *Libraries) ListByUser(ctx context.Context, userID int64) ([]domain.Library, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT l.id, l.name, l.created_at, m.role FROM libraries l JOIN library_members m ON m.library_id = l.id WHERE m.user_id=$1 ORDER BY l.name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	libraries := make([]domain.Library, 0)
+	for rows.Next() {
+		var l domain.Library
+		if err := rows.Scan(&l.ID, &l.Name, &l.CreatedAt, &l.Role); err != nil {
+			return nil, err
+		}
+		libraries = append(libraries, l)
+	}
+
+	return libraries, rows.Err()
+}
+
+// Get returns the library with the role of the user, sql.ErrNoRows is
+// returned if the user isn't a member.
+func (r *Libraries) Get(ctx context.Context, id, userID int64) (domain.Library, error) {
+	var l domain.Library
+	err := r.db.QueryRowContext(ctx, "SELECT l.id, l.name, l.created_at, m.role FROM libraries l JOIN library_members m ON m.library_id = l.id WHERE l.id=$1 AND m.user_id=$2", id, userID).
+		Scan(&l.ID, &l.Name, &l.CreatedAt, &l.Role)
+
+	return l, err
+}
+
+func (r *Libraries) Rename(ctx context.Context, id int64, name string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE libraries SET name=$1 WHERE id=$2", name, id)
+
+	return err
+}
+
+// Delete removes the library with its movies, members and invitations.
+func (r *Libraries) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := deleteMovies(ctx, tx, "SELECT id FROM movies WHERE library_id=$1", id); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM library_invitations WHERE library_id=$1",
+		"DELETE FROM library_members WHERE library_id=$1",
+		"DELETE FROM libraries WHERE id=$1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Libraries) ListMembers(ctx context.Context, id int64) ([]domain.LibraryMember, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT u.id, u.name, u.email, m.role, m.joined_at FROM library_members m JOIN users u ON u.id = m.user_id WHERE m.library_id=$1 ORDER BY m.joined_at", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]domain.LibraryMember, 0)
+	for rows.Next() {
+		var m domain.LibraryMember
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Email, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// UpdateMember changes the role of the member. sql.ErrNoRows is returned if
+// the user isn't a member and domain.ErrLastOwner if the last owner would be
+// demoted.
+func (r *Libraries) UpdateMember(ctx context.Context, id, userID int64, role string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockMember(ctx, tx, id, userID, role != domain.RoleOwner); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE library_members SET role=$1 WHERE library_id=$2 AND user_id=$3", role, id, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveMember returns sql.ErrNoRows if the user isn't a member and
+// domain.ErrLastOwner if the user is the last owner.
+func (r *Libraries) RemoveMember(ctx context.Context, id, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockMember(ctx, tx, id, userID, true); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM library_members WHERE library_id=$1 AND user_id=$2", id, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockMember locks the members of the library until the end of tx, so that
+// concurrent changes can't leave it without owners. If leavesOwners is set,
+// an owner is only allowed to go when another owner remains.
+func lockMember(ctx context.Context, tx *sql.Tx, id, userID int64, leavesOwners bool) error {
+	rows, err := tx.QueryContext(ctx, "SELECT user_id, role FROM library_members WHERE library_id=$1 FOR UPDATE", id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		found  bool
+		role   string
+		owners int
+	)
+	for rows.Next() {
+		var (
+			memberID   int64
+			memberRole string
+		)
+		if err := rows.Scan(&memberID, &memberRole); err != nil {
+			return err
+		}
+
+		if memberRole == domain.RoleOwner {
+			owners++
+		}
+
+		if memberID == userID {
+			found, role = true, memberRole
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !found {
+		return sql.ErrNoRows
+	}
+
+	if leavesOwners && role == domain.RoleOwner && owners < 2 {
+		return domain.ErrLastOwner
+	}
+
+	return nil
+}
+
+func (r *Libraries) CreateInvitation(ctx context.Context, inv domain.LibraryInvitation) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO library_invitations (library_id, email, role, token_hash, invited_by, expires_at) values ($1, $2, $3, $4, $5, $6)",
+		inv.LibraryID, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.ExpiresAt)
+
+	return err
+}
+
+// ListInvitationsSent returns the pending invitations sent by the user.
+func (r *Libraries) ListInvitationsSent(ctx context.Context, userID int64) ([]domain.LibraryInvitation, error) {
+	return r.listInvitations(ctx, "invited_by=$1", userID)
+}
+
+// ListInvitationsReceived returns the pending invitations sent to the email.
+func (r *Libraries) ListInvitationsReceived(ctx context.Context, email string) ([]domain.LibraryInvitation, error) {
+	return r.listInvitations(ctx, "lower(email)=lower($1)", email)
+}
+
+func (r *Libraries) listInvitations(ctx context.Context, where string, arg interface{}) ([]domain.LibraryInvitation, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, library_id, email, role, token_hash, invited_by, expires_at FROM library_invitations WHERE "+where+" ORDER BY id", arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := make([]domain.LibraryInvitation, 0)
+	for rows.Next() {
+		var inv domain.LibraryInvitation
+		if err := rows.Scan(&inv.ID, &inv.LibraryID, &inv.Email, &inv.Role, &inv.TokenHash, &inv.InvitedBy, &inv.ExpiresAt); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, rows.Err()
+}
+
+// GetInvitation returns the invitation with the token, sql.ErrNoRows is
+// returned if there is none.
+func (r *Libraries) GetInvitation(ctx context.Context, tokenHash string) (domain.LibraryInvitation, error) {
+	var inv domain.LibraryInvitation
+	err := r.db.QueryRowContext(ctx, "SELECT id, library_id, email, role, token_hash, invited_by, expires_at FROM library_invitations WHERE token_hash=$1", tokenHash).
+		Scan(&inv.ID, &inv.LibraryID, &inv.Email, &inv.Role, &inv.TokenHash, &inv.InvitedBy, &inv.ExpiresAt)
+
+	return inv, err
+}
+
+// AcceptInvitation deletes the invitation and adds the user to its library in
+// one transaction, so that it can be used only once. sql.ErrNoRows is
+// returned if the invitation was already used. Accepting never downgrades an
+// existing member.
+func (r *Libraries) AcceptInvitation(ctx context.Context, inv domain.LibraryInvitation, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM library_invitations WHERE id=$1", inv.ID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	var role string
+	err = tx.QueryRowContext(ctx, "SELECT role FROM library_members WHERE library_id=$1 AND user_id=$2 FOR UPDATE", inv.LibraryID, userID).
+		Scan(&role)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx, "INSERT INTO library_members (library_id, user_id, role) values ($1, $2, $3)", inv.LibraryID, userID, inv.Role)
+	case err == nil && !domain.RoleAtLeast(role, inv.Role):
+		_, err = tx.ExecContext(ctx, "UPDATE library_members SET role=$1 WHERE library_id=$2 AND user_id=$3", inv.Role, inv.LibraryID, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
 	cc "github.com/BalamutDiana/custom_cache"
+	"github.com/lib/pq"
 )
 
 type Movies struct {
@@ -22,8 +23,73 @@ func NewMovies(database *sql.DB, cache *cc.Cache) *Movies {
 	}
 }
 
-func (m *Movies) List(ctx context.Context) ([]domain.Movie, error) {
-	rows, err := m.db.QueryContext(ctx, "select * from movies")
+// List returns the movies outside of libraries. When the filter has a user,
+// every movie carries the watch state of that user and the watch filters are
+// applied.
+func (m *Movies) List(ctx context.Context, filter domain.MovieFilter) ([]domain.Movie, error) {
+	query := "select m.id, m.title, m.type, m.release_date, m.release_precision, m.runtime, m.streaming_service, m.streaming_service_id, m.saved_at, w.status, w.progress, w.rating, w.notes, w.updated_at " +
+		"from movies m left join watch_states w on w.movie_id = m.id and w.user_id = $1 where m.library_id is null"
+	args := []interface{}{filter.UserID}
+
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		query += fmt.Sprintf(" and m.type = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" and w.status = $%d", len(args))
+	}
+	if filter.MinRating > 0 {
+		args = append(args, filter.MinRating)
+		query += fmt.Sprintf(" and w.rating >= $%d", len(args))
+	}
+	if filter.MaxRating > 0 {
+		args = append(args, filter.MaxRating)
+		query += fmt.Sprintf(" and w.rating <= $%d", len(args))
+	}
+	if len(filter.Tags) > 0 {
+		args = append(args, pq.Array(filter.Tags))
+		tagged := fmt.Sprintf("select count(*) from movie_tags mt join tags t on t.id = mt.tag_id where mt.movie_id = m.id and t.name = any($%d)", len(args))
+		if filter.Match == domain.TagMatchAll {
+			query += fmt.Sprintf(" and (%s) = %d", tagged, len(filter.Tags))
+		} else {
+			query += fmt.Sprintf(" and (%s) > 0", tagged)
+		}
+	}
+
+	for role, person := range map[string]string{
+		domain.CreditDirector: filter.Director,
+		domain.CreditCast:     filter.Actor,
+	} {
+		if person == "" {
+			continue
+		}
+		args = append(args, person)
+		query += fmt.Sprintf(" and exists (select 1 from movie_credits c join people p on p.id = c.person_id "+
+			"where c.movie_id = m.id and c.role = '%s' and (p.id::text = $%d or lower(p.name) = lower($%d)))", role, len(args), len(args))
+	}
+
+	if filter.YearFrom > 0 {
+		args = append(args, filter.YearFrom)
+		query += fmt.Sprintf(" and extract(year from m.release_date) >= $%d", len(args))
+	}
+	if filter.YearTo > 0 {
+		args = append(args, filter.YearTo)
+		query += fmt.Sprintf(" and extract(year from m.release_date) <= $%d", len(args))
+	}
+
+	switch filter.Sort {
+	case "title":
+		query += " order by lower(m.title), m.id"
+	case "release":
+		query += " order by m.release_date nulls last, m.id"
+	case "-release":
+		query += " order by m.release_date desc nulls last, m.id"
+	default:
+		query += " order by m.id"
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,11 +97,27 @@ func (m *Movies) List(ctx context.Context) ([]domain.Movie, error) {
 
 	movies := make([]domain.Movie, 0)
 	for rows.Next() {
-		var m domain.Movie
-		err := rows.Scan(&m.ID, &m.Title, &m.Release, &m.StreamingService, &m.SavedAt)
+		var (
+			m         domain.Movie
+			state     domain.WatchState
+			status    sql.NullString
+			notes     sql.NullString
+			updatedAt sql.NullTime
+			release   releaseColumns
+		)
+		err := rows.Scan(&m.ID, &m.Title, &m.Type, &release.date, &release.precision, &m.Runtime, &m.StreamingService, &m.StreamingServiceID, &m.SavedAt,
+			&status, &state.Progress, &state.Rating, &notes, &updatedAt)
 		if err != nil {
 			return nil, err
 		}
+		m.Release = release.value()
+		if status.Valid {
+			state.MovieID = int64(m.ID)
+			state.Status = status.String
+			state.Notes = notes.String
+			state.UpdatedAt = updatedAt.Time
+			m.Watch = &state
+		}
 		movies = append(movies, m)
 	}
 	err = rows.Err()
@@ -45,29 +127,144 @@ func (m *Movies) List(ctx context.Context) ([]domain.Movie, error) {
 
 	for _, item := range movies {
 		if _, err := m.cache.Get(fmt.Sprint(item.ID)); err != nil {
+			// the cache is shared between users
+			item.Watch = nil
 			m.cache.Set(fmt.Sprint(item.ID), item, time.Minute*2)
 		}
 	}
 
-	return movies, nil
+	return movies, m.loadLabels(ctx, movies)
 }
 
+// GetMovieByID returns the movie with its genres and tags. Genres and tags
+// are never cached, they can be changed for many movies at once.
 func (m *Movies) GetMovieByID(ctx context.Context, id int64) (domain.Movie, error) {
-	if movie, err := m.cache.Get(fmt.Sprint(id)); err == nil {
-		return movie.(domain.Movie), err
+	var movie domain.Movie
+
+	if cached, err := m.cache.Get(fmt.Sprint(id)); err == nil {
+		movie = cached.(domain.Movie)
+	} else {
+		var release releaseColumns
+		err := m.db.QueryRowContext(ctx, "select id, title, type, release_date, release_precision, runtime, streaming_service, streaming_service_id, saved_at from movies where id = $1 and library_id is null", id).
+			Scan(&movie.ID, &movie.Title, &movie.Type, &release.date, &release.precision, &movie.Runtime, &movie.StreamingService, &movie.StreamingServiceID, &movie.SavedAt)
+		if err != nil {
+			return movie, err
+		}
+		movie.Release = release.value()
 	}
 
-	var movie domain.Movie
+	movies := []domain.Movie{movie}
+	if err := m.loadLabels(ctx, movies); err != nil {
+		return movie, err
+	}
+
+	return movies[0], nil
+}
 
-	err := m.db.QueryRowContext(ctx, "select * from movies where id = $1", id).
-		Scan(&movie.ID, &movie.Title, &movie.Release, &movie.StreamingService, &movie.SavedAt)
+// loadLabels fills genres, tags and country releases of the movies.
+func (m *Movies) loadLabels(ctx context.Context, movies []domain.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
 
-	return movie, err
+	ids := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		ids = append(ids, int64(movie.ID))
+	}
+
+	genres, err := m.listLabels(ctx, "select mg.movie_id, g.name from movie_genres mg join genres g on g.id = mg.genre_id where mg.movie_id = any($1) order by g.name", ids)
+	if err != nil {
+		return err
+	}
+
+	tags, err := m.listLabels(ctx, "select mt.movie_id, t.name from movie_tags mt join tags t on t.id = mt.tag_id where mt.movie_id = any($1) order by t.name", ids)
+	if err != nil {
+		return err
+	}
+
+	releases, err := m.listReleases(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range movies {
+		movies[i].Genres = genres[int64(movies[i].ID)]
+		movies[i].Tags = tags[int64(movies[i].ID)]
+		movies[i].Releases = releases[int64(movies[i].ID)]
+	}
+
+	return nil
+}
+
+func (m *Movies) listReleases(ctx context.Context, ids []int64) (map[int64][]domain.CountryRelease, error) {
+	rows, err := m.db.QueryContext(ctx, "select movie_id, country, release_date, release_precision from movie_releases where movie_id = any($1) order by release_date, country", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	releases := make(map[int64][]domain.CountryRelease)
+	for rows.Next() {
+		var (
+			movieID int64
+			r       domain.CountryRelease
+		)
+		if err := rows.Scan(&movieID, &r.Country, &r.Date.Date, &r.Date.Precision); err != nil {
+			return nil, err
+		}
+		releases[movieID] = append(releases[movieID], r)
+	}
+
+	return releases, rows.Err()
+}
+
+// SetReleases replaces the country releases of the movie.
+func (m *Movies) SetReleases(ctx context.Context, id int64, releases []domain.CountryRelease) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "delete from movie_releases where movie_id = $1", id); err != nil {
+		return err
+	}
+
+	for _, r := range releases {
+		if _, err := tx.ExecContext(ctx, "insert into movie_releases (movie_id, country, release_date, release_precision) values ($1, $2, $3, $4)",
+			id, r.Country, r.Date.Date, r.Date.Precision); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *Movies) listLabels(ctx context.Context, query string, ids []int64) (map[int64][]string, error) {
+	rows, err := m.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[int64][]string)
+	for rows.Next() {
+		var (
+			movieID int64
+			name    string
+		)
+		if err := rows.Scan(&movieID, &name); err != nil {
+			return nil, err
+		}
+		labels[movieID] = append(labels[movieID], name)
+	}
+
+	return labels, rows.Err()
 }
 
 func (m *Movies) Create(ctx context.Context, movie domain.Movie) error {
-	if _, err := m.db.ExecContext(ctx, "insert into movies (title, release, streaming_service) values ($1, $2, $3)",
-		movie.Title, movie.Release, movie.StreamingService); err != nil {
+	if _, err := m.db.ExecContext(ctx, "insert into movies (title, type, release_date, release_precision, runtime, streaming_service, streaming_service_id, user_id, library_id) values ($1, coalesce(nullif($2, ''), 'movie'), $3, $4, $5, $6, $7, $8, $9)",
+		movie.Title, movie.Type, releaseDate(movie.Release), releasePrecision(movie.Release), movie.Runtime, movie.StreamingService, movie.StreamingServiceID, movie.UserID, movie.LibraryID); err != nil {
 		return err
 	}
 
@@ -75,8 +272,53 @@ func (m *Movies) Create(ctx context.Context, movie domain.Movie) error {
 	return nil
 }
 
+// ListByUser returns the movies saved by the user.
+func (m *Movies) ListByUser(ctx context.Context, userID int64) ([]domain.Movie, error) {
+	rows, err := m.db.QueryContext(ctx, "select id, title, release_date, release_precision, streaming_service, saved_at, user_id from movies where user_id = $1 order by saved_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := make([]domain.Movie, 0)
+	for rows.Next() {
+		var (
+			m       domain.Movie
+			release releaseColumns
+		)
+		if err := rows.Scan(&m.ID, &m.Title, &release.date, &release.precision, &m.StreamingService, &m.SavedAt, &m.UserID); err != nil {
+			return nil, err
+		}
+		m.Release = release.value()
+		movies = append(movies, m)
+	}
+
+	return movies, rows.Err()
+}
+
+// DeleteMovie removes the movie with everything linked to it, including the
+// watch states of all users.
 func (m *Movies) DeleteMovie(ctx context.Context, id int64) error {
-	if _, err := m.db.ExecContext(ctx, "delete from movies where id = $1", id); err != nil {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "delete from movies where id = $1 and library_id is null", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	if err := deleteMovieLinks(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return err
 	}
 	if err := m.cache.Delete(fmt.Sprint(id)); err != nil {
@@ -85,11 +327,133 @@ func (m *Movies) DeleteMovie(ctx context.Context, id int64) error {
 	return nil
 }
 
+// deleteMovies removes the movies selected by the query together with the
+// rows linked to them and returns their ids.
+func deleteMovies(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, "delete from movies where id in ("+query+") returning id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := deleteMovieLinks(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// deleteMovieLinks deletes the rows linked to the movie.
+func deleteMovieLinks(ctx context.Context, tx *sql.Tx, id int64) error {
+	for _, query := range []string{
+		"delete from movie_genres where movie_id = $1",
+		"delete from movie_tags where movie_id = $1",
+		"delete from watch_events where movie_id = $1",
+		"delete from watch_states where movie_id = $1",
+		"delete from movie_credits where movie_id = $1",
+		"delete from movie_releases where movie_id = $1",
+		"delete from availabilities where movie_id = $1",
+		"delete from episode_watches where episode_id in (select e.id from episodes e join seasons s on s.id = e.season_id where s.movie_id = $1)",
+		"delete from episodes where season_id in (select id from seasons where movie_id = $1)",
+		"delete from seasons where movie_id = $1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateMovie keeps the type of the movie if newMovie has none.
 func (m *Movies) UpdateMovie(ctx context.Context, id int64, newMovie domain.Movie) error {
-	if _, err := m.db.ExecContext(ctx, "update movies set title=$1, release = $2, streaming_service = $3 where id = $4",
-		newMovie.Title, newMovie.Release, newMovie.StreamingService, id); err != nil {
+	if _, err := m.db.ExecContext(ctx, "update movies set title=$1, release_date = $2, release_precision = $3, streaming_service = $4, streaming_service_id = $5, type = coalesce(nullif($6, ''), type), runtime = $7 where id = $8 and library_id is null",
+		newMovie.Title, releaseDate(newMovie.Release), releasePrecision(newMovie.Release), newMovie.StreamingService, newMovie.StreamingServiceID, newMovie.Type, newMovie.Runtime, id); err != nil {
 		return err
 	}
-	m.cache.Set(fmt.Sprint(id), newMovie, time.Minute*2)
+	// the stored type may differ from newMovie, the movie is read again on
+	// the next request; a missing cache entry is fine
+	m.cache.Delete(fmt.Sprint(id))
 	return nil
 }
+
+func (m *Movies) ListByLibrary(ctx context.Context, libraryID int64) ([]domain.Movie, error) {
+	rows, err := m.db.QueryContext(ctx, "select id, title, type, release_date, release_precision, runtime, streaming_service, saved_at, library_id from movies where library_id = $1 order by saved_at", libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := make([]domain.Movie, 0)
+	for rows.Next() {
+		var (
+			m       domain.Movie
+			release releaseColumns
+		)
+		if err := rows.Scan(&m.ID, &m.Title, &m.Type, &release.date, &release.precision, &m.Runtime, &m.StreamingService, &m.SavedAt, &m.LibraryID); err != nil {
+			return nil, err
+		}
+		m.Release = release.value()
+		movies = append(movies, m)
+	}
+
+	return movies, rows.Err()
+}
+
+func (m *Movies) GetInLibrary(ctx context.Context, libraryID, id int64) (domain.Movie, error) {
+	var (
+		movie   domain.Movie
+		release releaseColumns
+	)
+	err := m.db.QueryRowContext(ctx, "select id, title, type, release_date, release_precision, runtime, streaming_service, saved_at, library_id from movies where id = $1 and library_id = $2", id, libraryID).
+		Scan(&movie.ID, &movie.Title, &movie.Type, &release.date, &release.precision, &movie.Runtime, &movie.StreamingService, &movie.SavedAt, &movie.LibraryID)
+	movie.Release = release.value()
+
+	return movie, err
+}
+
+// UpdateInLibrary returns false if the library has no such movie. The type
+// is kept if newMovie has none.
+func (m *Movies) UpdateInLibrary(ctx context.Context, libraryID, id int64, newMovie domain.Movie) (bool, error) {
+	res, err := m.db.ExecContext(ctx, "update movies set title=$1, release_date = $2, release_precision = $3, streaming_service = $4, streaming_service_id = $5, type = coalesce(nullif($6, ''), type), runtime = $7 where id = $8 and library_id = $9",
+		newMovie.Title, releaseDate(newMovie.Release), releasePrecision(newMovie.Release), newMovie.StreamingService, newMovie.StreamingServiceID, newMovie.Type, newMovie.Runtime, id, libraryID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+// DeleteInLibrary returns false if the library has no such movie.
+func (m *Movies) DeleteInLibrary(ctx context.Context, libraryID, id int64) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	ids, err := deleteMovies(ctx, tx, "select id from movies where id = $1 and library_id = $2", id, libraryID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(ids) > 0, tx.Commit()
+}
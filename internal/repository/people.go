@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+type People struct {
+	db *sql.DB
+}
+
+func NewPeople(db *sql.DB) *People {
+	return &People{db}
+}
+
+const personColumns = "p.id, p.name, p.created_at, array(select distinct c.role from movie_credits c where c.person_id = p.id order by c.role)"
+
+func (r *People) Create(ctx context.Context, name string) (domain.Person, error) {
+	person := domain.Person{Name: name, Roles: make([]string, 0)}
+	err := r.db.QueryRowContext(ctx, "INSERT INTO people (name) values ($1) RETURNING id, created_at", name).
+		Scan(&person.ID, &person.CreatedAt)
+
+	return person, err
+}
+
+func (r *People) GetByID(ctx context.Context, id int64) (domain.Person, error) {
+	var p domain.Person
+	err := r.db.QueryRowContext(ctx, "SELECT "+personColumns+" FROM people p WHERE p.id=$1", id).
+		Scan(&p.ID, &p.Name, &p.CreatedAt, pq.Array(&p.Roles))
+
+	return p, err
+}
+
+// Search returns the people whose name contains the query.
+func (r *People) Search(ctx context.Context, query string, limit int) ([]domain.Person, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+personColumns+" FROM people p WHERE strpos(lower(p.name), lower($1)) > 0 ORDER BY p.name LIMIT $2", query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	people := make([]domain.Person, 0)
+	for rows.Next() {
+		var p domain.Person
+		if err := rows.Scan(&p.ID, &p.Name, &p.CreatedAt, pq.Array(&p.Roles)); err != nil {
+			return nil, err
+		}
+		people = append(people, p)
+	}
+
+	return people, rows.Err()
+}
+
+func (r *People) Rename(ctx context.Context, id int64, name string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE people SET name=$1 WHERE id=$2", name, id)
+
+	return err
+}
+
+// Filmography returns the credits of the person on the movies the user can
+// see: the common list and the libraries the user is a member of.
+func (r *People) Filmography(ctx context.Context, id, userID int64) ([]domain.FilmographyEntry, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT m.id, m.title, m.type, m.release_date, m.release_precision, m.library_id, c.role, c.character, c.job FROM movie_credits c JOIN movies m ON m.id = c.movie_id "+
+		"WHERE c.person_id=$1 AND (m.library_id IS NULL OR m.library_id IN (SELECT library_id FROM library_members WHERE user_id=$2)) ORDER BY m.release_date, m.title, c.id", id, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]domain.FilmographyEntry, 0)
+	for rows.Next() {
+		var (
+			e       domain.FilmographyEntry
+			release releaseColumns
+		)
+		if err := rows.Scan(&e.MovieID, &e.Title, &e.Type, &release.date, &release.precision, &e.LibraryID, &e.Role, &e.Character, &e.Job); err != nil {
+			return nil, err
+		}
+		e.Release = release.value()
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// ListCredits returns the credits of the movie, directors first and the
+// cast in billing order.
+func (r *People) ListCredits(ctx context.Context, movieID int64) ([]domain.Credit, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT c.id, c.movie_id, c.person_id, p.name, c.role, c.character, c.job FROM movie_credits c JOIN people p ON p.id = c.person_id "+
+		"WHERE c.movie_id=$1 ORDER BY CASE c.role WHEN 'director' THEN 0 WHEN 'cast' THEN 1 ELSE 2 END, c.id", movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := make([]domain.Credit, 0)
+	for rows.Next() {
+		var c domain.Credit
+		if err := rows.Scan(&c.ID, &c.MovieID, &c.PersonID, &c.Name, &c.Role, &c.Character, &c.Job); err != nil {
+			return nil, err
+		}
+		credits = append(credits, c)
+	}
+
+	return credits, rows.Err()
+}
+
+func (r *People) AddCredit(ctx context.Context, movieID int64, inp domain.CreditInput) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, "INSERT INTO movie_credits (movie_id, person_id, role, character, job) values ($1, $2, $3, $4, $5) RETURNING id",
+		movieID, inp.PersonID, inp.Role, inp.Character, inp.Job).Scan(&id)
+
+	return id, err
+}
+
+// DeleteCredit returns false if the movie has no such credit.
+func (r *People) DeleteCredit(ctx context.Context, movieID, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM movie_credits WHERE id=$1 AND movie_id=$2", id, movieID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+type Posters struct {
+	db *sql.DB
+}
+
+func NewPosters(db *sql.DB) *Posters {
+	return &Posters{db}
+}
+
+func (r *Posters) Get(ctx context.Context, movieID int64) (domain.Poster, error) {
+	var p domain.Poster
+	err := r.db.QueryRowContext(ctx, "SELECT movie_id, key, content_type, width, height, size, sizes, updated_at FROM movie_posters WHERE movie_id=$1", movieID).
+		Scan(&p.MovieID, &p.Key, &p.ContentType, &p.Width, &p.Height, &p.Size, pq.Array(&p.Sizes), &p.UpdatedAt)
+
+	return p, err
+}
+
+// Set replaces the poster of the movie.
+func (r *Posters) Set(ctx context.Context, p domain.Poster) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO movie_posters (movie_id, key, content_type, width, height, size, sizes, updated_at) "+
+		"values ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (movie_id) DO UPDATE SET key=excluded.key, content_type=excluded.content_type, "+
+		"width=excluded.width, height=excluded.height, size=excluded.size, sizes=excluded.sizes, updated_at=excluded.updated_at",
+		p.MovieID, p.Key, p.ContentType, p.Width, p.Height, p.Size, pq.Array(p.Sizes), p.UpdatedAt)
+
+	return err
+}
+
+func (r *Posters) Delete(ctx context.Context, movieID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM movie_posters WHERE movie_id=$1", movieID)
+
+	return err
+}
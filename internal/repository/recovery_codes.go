@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type RecoveryCodes struct {
+	db *sql.DB
+}
+
+func NewRecoveryCodes(db *sql.DB) *RecoveryCodes {
+	return &RecoveryCodes{db}
+}
+
+// Replace deletes all recovery codes of the user and stores the new ones.
+func (r *RecoveryCodes) Replace(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) values ($1, $2)", userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Use deletes the recovery code and reports whether it existed.
+func (r *RecoveryCodes) Use(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1 AND code_hash=$2", userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+func (r *RecoveryCodes) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID)
+
+	return err
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+// releaseColumns scans the nullable release_date and release_precision
+// columns.
+type releaseColumns struct {
+	date      sql.NullTime
+	precision sql.NullString
+}
+
+func (c releaseColumns) value() domain.ReleaseDate {
+	if !c.date.Valid {
+		return domain.ReleaseDate{}
+	}
+
+	return domain.ReleaseDate{Date: c.date.Time, Precision: c.precision.String}
+}
+
+// releaseDate and releasePrecision return the column values of a release
+// date, NULL when it is unknown.
+func releaseDate(d domain.ReleaseDate) interface{} {
+	if d.IsZero() {
+		return nil
+	}
+
+	return d.Date
+}
+
+func releasePrecision(d domain.ReleaseDate) interface{} {
+	if d.IsZero() {
+		return nil
+	}
+
+	return d.Precision
+}
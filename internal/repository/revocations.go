@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type Revocations struct {
+	db *sql.DB
+}
+
+func NewRevocations(db *sql.DB) *Revocations {
+	return &Revocations{db}
+}
+
+func (r *Revocations) RevokeToken(ctx context.Context, token domain.RevokedToken) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, user_id, expires_at) values ($1, $2, $3) ON CONFLICT (jti) DO NOTHING",
+		token.ID, token.UserID, token.ExpiresAt)
+
+	return err
+}
+
+func (r *Revocations) RevokeUser(ctx context.Context, revocation domain.UserRevocation) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO user_revocations (user_id, revoked_at) values ($1, $2) ON CONFLICT (user_id) DO UPDATE SET revoked_at = EXCLUDED.revoked_at",
+		revocation.UserID, revocation.RevokedAt)
+
+	return err
+}
+
+// ListTokens returns revoked tokens that have not expired at the given time.
+func (r *Revocations) ListTokens(ctx context.Context, at time.Time) ([]domain.RevokedToken, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT jti, user_id, expires_at FROM revoked_tokens WHERE expires_at > $1", at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]domain.RevokedToken, 0)
+	for rows.Next() {
+		var t domain.RevokedToken
+		if err := rows.Scan(&t.ID, &t.UserID, &t.ExpiresAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+// ListUsers returns user revocations made after the given time.
+func (r *Revocations) ListUsers(ctx context.Context, after time.Time) ([]domain.UserRevocation, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT user_id, revoked_at FROM user_revocations WHERE revoked_at > $1", after)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revocations := make([]domain.UserRevocation, 0)
+	for rows.Next() {
+		var u domain.UserRevocation
+		if err := rows.Scan(&u.UserID, &u.RevokedAt); err != nil {
+			return nil, err
+		}
+		revocations = append(revocations, u)
+	}
+
+	return revocations, rows.Err()
+}
+
+// DeleteExpired removes token revocations expired before tokensBefore and
+// user revocations made before usersBefore.
+func (r *Revocations) DeleteExpired(ctx context.Context, tokensBefore, usersBefore time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1", tokensBefore); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_revocations WHERE revoked_at <= $1", usersBefore)
+
+	return err
+}
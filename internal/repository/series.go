@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type Series struct {
+	db *sql.DB
+}
+
+func NewSeries(db *sql.DB) *Series {
+	return &Series{db}
+}
+
+// ListSeasons returns the seasons of the series with their episodes and the
+// watch dates of the user.
+func (r *Series) ListSeasons(ctx context.Context, movieID, userID int64) ([]domain.Season, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, number, title FROM seasons WHERE movie_id=$1 ORDER BY number", movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := make([]domain.Season, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		s := domain.Season{Episodes: make([]domain.Episode, 0)}
+		if err := rows.Scan(&s.ID, &s.Number, &s.Title); err != nil {
+			return nil, err
+		}
+		index[s.Number] = len(seasons)
+		seasons = append(seasons, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	episodes, err := r.db.QueryContext(ctx, "SELECT e.id, s.number, e.number, e.title, e.air_date, w.watched_at FROM episodes e JOIN seasons s ON s.id = e.season_id "+
+		"LEFT JOIN episode_watches w ON w.episode_id = e.id AND w.user_id = $2 WHERE s.movie_id=$1 ORDER BY s.number, e.number", movieID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer episodes.Close()
+
+	for episodes.Next() {
+		var e domain.Episode
+		if err := episodes.Scan(&e.ID, &e.SeasonNumber, &e.Number, &e.Title, &e.AirDate, &e.WatchedAt); err != nil {
+			return nil, err
+		}
+		i := index[e.SeasonNumber]
+		seasons[i].Episodes = append(seasons[i].Episodes, e)
+	}
+
+	return seasons, episodes.Err()
+}
+
+func (r *Series) GetSeason(ctx context.Context, movieID int64, number int) (domain.Season, error) {
+	var s domain.Season
+	err := r.db.QueryRowContext(ctx, "SELECT id, number, title FROM seasons WHERE movie_id=$1 AND number=$2", movieID, number).
+		Scan(&s.ID, &s.Number, &s.Title)
+
+	return s, err
+}
+
+func (r *Series) CreateSeason(ctx context.Context, movieID int64, inp domain.SeasonInput) (domain.Season, error) {
+	s := domain.Season{Number: inp.Number, Title: inp.Title, Episodes: make([]domain.Episode, 0)}
+	err := r.db.QueryRowContext(ctx, "INSERT INTO seasons (movie_id, number, title) values ($1, $2, $3) RETURNING id", movieID, inp.Number, inp.Title).
+		Scan(&s.ID)
+
+	return s, err
+}
+
+func (r *Series) UpdateSeason(ctx context.Context, id int64, inp domain.SeasonInput) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE seasons SET number=$1, title=$2 WHERE id=$3", inp.Number, inp.Title, id)
+
+	return err
+}
+
+// DeleteSeason removes the season with its episodes and their watches.
+func (r *Series) DeleteSeason(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM episode_watches WHERE episode_id IN (SELECT id FROM episodes WHERE season_id=$1)",
+		"DELETE FROM episodes WHERE season_id=$1",
+		"DELETE FROM seasons WHERE id=$1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Series) GetEpisode(ctx context.Context, seasonID int64, number int) (domain.Episode, error) {
+	var e domain.Episode
+	err := r.db.QueryRowContext(ctx, "SELECT e.id, s.number, e.number, e.title, e.air_date FROM episodes e JOIN seasons s ON s.id = e.season_id WHERE e.season_id=$1 AND e.number=$2", seasonID, number).
+		Scan(&e.ID, &e.SeasonNumber, &e.Number, &e.Title, &e.AirDate)
+
+	return e, err
+}
+
+func (r *Series) CreateEpisode(ctx context.Context, season domain.Season, inp domain.EpisodeInput) (domain.Episode, error) {
+	e := domain.Episode{SeasonNumber: season.Number, Number: inp.Number, Title: inp.Title, AirDate: inp.ParsedAirDate()}
+	err := r.db.QueryRowContext(ctx, "INSERT INTO episodes (season_id, number, title, air_date) values ($1, $2, $3, $4) RETURNING id", season.ID, e.Number, e.Title, e.AirDate).
+		Scan(&e.ID)
+
+	return e, err
+}
+
+func (r *Series) UpdateEpisode(ctx context.Context, id int64, inp domain.EpisodeInput) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE episodes SET number=$1, title=$2, air_date=$3 WHERE id=$4", inp.Number, inp.Title, inp.ParsedAirDate(), id)
+
+	return err
+}
+
+func (r *Series) DeleteEpisode(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM episode_watches WHERE episode_id=$1",
+		"DELETE FROM episodes WHERE id=$1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Series) SetWatched(ctx context.Context, userID, episodeID int64, watchedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO episode_watches (user_id, episode_id, watched_at) values ($1, $2, $3) ON CONFLICT (user_id, episode_id) DO UPDATE SET watched_at=excluded.watched_at",
+		userID, episodeID, watchedAt)
+
+	return err
+}
+
+func (r *Series) UnsetWatched(ctx context.Context, userID, episodeID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM episode_watches WHERE user_id=$1 AND episode_id=$2", userID, episodeID)
+
+	return err
+}
+
+// ListWatchesByUser returns the episodes the user watched, oldest first.
+func (r *Series) ListWatchesByUser(ctx context.Context, userID int64) ([]domain.WatchedEpisode, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT m.id, m.title, s.number, e.number, e.title, w.watched_at FROM episode_watches w JOIN episodes e ON e.id = w.episode_id "+
+		"JOIN seasons s ON s.id = e.season_id JOIN movies m ON m.id = s.movie_id WHERE w.user_id=$1 ORDER BY w.watched_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watches := make([]domain.WatchedEpisode, 0)
+	for rows.Next() {
+		var w domain.WatchedEpisode
+		if err := rows.Scan(&w.MovieID, &w.SeriesTitle, &w.SeasonNumber, &w.Number, &w.Title, &w.WatchedAt); err != nil {
+			return nil, err
+		}
+		watches = append(watches, w)
+	}
+
+	return watches, rows.Err()
+}
+
+// NextEpisode returns the first episode after the last one the user watched,
+// sql.ErrNoRows is returned if there is none. Season 0 holds specials and is
+// skipped.
+func (r *Series) NextEpisode(ctx context.Context, movieID, userID int64) (domain.Episode, error) {
+	var lastSeason, lastEpisode int
+	err := r.db.QueryRowContext(ctx, "SELECT s.number, e.number FROM episode_watches w JOIN episodes e ON e.id = w.episode_id JOIN seasons s ON s.id = e.season_id "+
+		"WHERE s.movie_id=$1 AND w.user_id=$2 AND s.number > 0 ORDER BY s.number DESC, e.number DESC LIMIT 1", movieID, userID).
+		Scan(&lastSeason, &lastEpisode)
+	if err != nil && err != sql.ErrNoRows {
+		return domain.Episode{}, err
+	}
+
+	var e domain.Episode
+	err = r.db.QueryRowContext(ctx, "SELECT e.id, s.number, e.number, e.title, e.air_date FROM episodes e JOIN seasons s ON s.id = e.season_id "+
+		"WHERE s.movie_id=$1 AND s.number > 0 AND (s.number, e.number) > ($2, $3) ORDER BY s.number, e.number LIMIT 1", movieID, lastSeason, lastEpisode).
+		Scan(&e.ID, &e.SeasonNumber, &e.Number, &e.Title, &e.AirDate)
+
+	return e, err
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+type StreamingServices struct {
+	db *sql.DB
+}
+
+func NewStreamingServices(db *sql.DB) *StreamingServices {
+	return &StreamingServices{db}
+}
+
+const streamingServiceColumns = "s.id, s.name, array(select a.alias from streaming_service_aliases a where a.service_id = s.id order by a.alias), s.regions, s.created_at"
+
+func (r *StreamingServices) List(ctx context.Context) ([]domain.StreamingService, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+streamingServiceColumns+" FROM streaming_services s ORDER BY s.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := make([]domain.StreamingService, 0)
+	for rows.Next() {
+		var s domain.StreamingService
+		if err := rows.Scan(&s.ID, &s.Name, pq.Array(&s.Aliases), pq.Array(&s.Regions), &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		services = append(services, s)
+	}
+
+	return services, rows.Err()
+}
+
+func (r *StreamingServices) GetByID(ctx context.Context, id int64) (domain.StreamingService, error) {
+	var s domain.StreamingService
+	err := r.db.QueryRowContext(ctx, "SELECT "+streamingServiceColumns+" FROM streaming_services s WHERE s.id=$1", id).
+		Scan(&s.ID, &s.Name, pq.Array(&s.Aliases), pq.Array(&s.Regions), &s.CreatedAt)
+
+	return s, err
+}
+
+// FindByKey returns the service with the name or alias key.
+func (r *StreamingServices) FindByKey(ctx context.Context, key string) (domain.StreamingService, error) {
+	var s domain.StreamingService
+	err := r.db.QueryRowContext(ctx, "SELECT "+streamingServiceColumns+" FROM streaming_services s JOIN streaming_service_aliases k ON k.service_id = s.id WHERE k.alias=$1", key).
+		Scan(&s.ID, &s.Name, pq.Array(&s.Aliases), pq.Array(&s.Regions), &s.CreatedAt)
+
+	return s, err
+}
+
+// KeysUsed reports whether any of the keys belongs to a service other than
+// exceptID.
+func (r *StreamingServices) KeysUsed(ctx context.Context, keys []string, exceptID int64) (bool, error) {
+	var used bool
+	err := r.db.QueryRowContext(ctx, "SELECT exists(SELECT 1 FROM streaming_service_aliases WHERE alias = any($1) AND service_id <> $2)", pq.Array(keys), exceptID).
+		Scan(&used)
+
+	return used, err
+}
+
+func (r *StreamingServices) Create(ctx context.Context, inp domain.StreamingServiceInput, keys []string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, "INSERT INTO streaming_services (name, regions) values ($1, $2) RETURNING id", inp.Name, pq.Array(regions(inp.Regions))).
+		Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if err := setAliases(ctx, tx, id, keys); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Update replaces the service and renames it on the movies.
+func (r *StreamingServices) Update(ctx context.Context, id int64, inp domain.StreamingServiceInput, keys []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE streaming_services SET name=$1, regions=$2 WHERE id=$3", inp.Name, pq.Array(regions(inp.Regions)), id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM streaming_service_aliases WHERE service_id=$1", id); err != nil {
+		return err
+	}
+
+	if err := setAliases(ctx, tx, id, keys); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE movies SET streaming_service=$1 WHERE streaming_service_id=$2", inp.Name, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *StreamingServices) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM streaming_service_aliases WHERE service_id=$1",
+		"DELETE FROM streaming_services WHERE id=$1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CountUses returns the number of movies, availabilities and subscriptions
+// of the service.
+func (r *StreamingServices) CountUses(ctx context.Context, id int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, "SELECT (SELECT count(*) FROM movies WHERE streaming_service_id=$1) + (SELECT count(*) FROM availabilities WHERE service_id=$1) + "+
+		"(SELECT count(*) FROM user_subscriptions WHERE service_id=$1)", id).
+		Scan(&n)
+
+	return n, err
+}
+
+func setAliases(ctx context.Context, tx *sql.Tx, id int64, keys []string) error {
+	for _, key := range keys {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO streaming_service_aliases (service_id, alias) values ($1, $2)", id, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// regions never stores NULL for services available everywhere.
+func regions(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+
+	return codes
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/lib/pq"
+)
+
+type Subscriptions struct {
+	db *sql.DB
+}
+
+func NewSubscriptions(db *sql.DB) *Subscriptions {
+	return &Subscriptions{db}
+}
+
+func (r *Subscriptions) ListByUser(ctx context.Context, userID int64) ([]domain.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT us.service_id, s.name, us.region, us.created_at FROM user_subscriptions us "+
+		"JOIN streaming_services s ON s.id = us.service_id WHERE us.user_id=$1 ORDER BY s.name, us.region", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]domain.Subscription, 0)
+	for rows.Next() {
+		var s domain.Subscription
+		if err := rows.Scan(&s.ServiceID, &s.Service, &s.Region, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// Replace sets the subscriptions of the user, the subscriptions kept keep
+// their creation time.
+func (r *Subscriptions) Replace(ctx context.Context, userID int64, subscriptions []domain.SubscriptionInput) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	serviceIDs := make([]int64, 0, len(subscriptions))
+	regions := make([]string, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		serviceIDs = append(serviceIDs, s.ServiceID)
+		regions = append(regions, s.Region)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_subscriptions WHERE user_id=$1 AND (service_id, region) NOT IN "+
+		"(SELECT * FROM unnest($2::int[], $3::varchar[]))", userID, pq.Array(serviceIDs), pq.Array(regions)); err != nil {
+		return err
+	}
+
+	for _, s := range subscriptions {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO user_subscriptions (user_id, service_id, region) values ($1, $2, $3) ON CONFLICT DO NOTHING",
+			userID, s.ServiceID, s.Region); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type Tags struct {
+	db *sql.DB
+}
+
+func NewTags(db *sql.DB) *Tags {
+	return &Tags{db}
+}
+
+// Search returns the tags starting with the prefix, most used first.
+func (r *Tags) Search(ctx context.Context, prefix string, limit int) ([]domain.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT t.id, t.name, count(mt.movie_id) FROM tags t LEFT JOIN movie_tags mt ON mt.tag_id = t.id "+
+		"WHERE left(t.name, length($1)) = $1 GROUP BY t.id ORDER BY count(mt.movie_id) DESC, t.name LIMIT $2", prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]domain.Tag, 0)
+	for rows.Next() {
+		var t domain.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Movies); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *Tags) GetByID(ctx context.Context, id int64) (domain.Tag, error) {
+	var t domain.Tag
+	err := r.db.QueryRowContext(ctx, "SELECT t.id, t.name, (SELECT count(*) FROM movie_tags WHERE tag_id = t.id) FROM tags t WHERE t.id=$1", id).
+		Scan(&t.ID, &t.Name, &t.Movies)
+
+	return t, err
+}
+
+func (r *Tags) GetByName(ctx context.Context, name string) (domain.Tag, error) {
+	var t domain.Tag
+	err := r.db.QueryRowContext(ctx, "SELECT t.id, t.name, (SELECT count(*) FROM movie_tags WHERE tag_id = t.id) FROM tags t WHERE t.name=$1", name).
+		Scan(&t.ID, &t.Name, &t.Movies)
+
+	return t, err
+}
+
+// AddToMovie tags the movie, missing tags are created.
+func (r *Tags) AddToMovie(ctx context.Context, movieID int64, names []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := linkLabels(ctx, tx, "tags", "movie_tags", "tag_id", movieID, names); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveFromMovie returns false if the movie had no such tag.
+func (r *Tags) RemoveFromMovie(ctx context.Context, movieID int64, name string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM movie_tags mt USING tags t WHERE t.id = mt.tag_id AND mt.movie_id=$1 AND t.name=$2", movieID, name)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+func (r *Tags) Rename(ctx context.Context, id int64, name string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE tags SET name=$1 WHERE id=$2", name, id)
+
+	return err
+}
+
+// Merge moves the movies of the tag to the other tag and deletes the tag.
+func (r *Tags) Merge(ctx context.Context, id, into int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO movie_tags (movie_id, tag_id) SELECT movie_id, $2 FROM movie_tags WHERE tag_id=$1 ON CONFLICT DO NOTHING", id, into); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM movie_tags WHERE tag_id=$1",
+		"DELETE FROM tags WHERE id=$1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+type Genres struct {
+	db *sql.DB
+}
+
+func NewGenres(db *sql.DB) *Genres {
+	return &Genres{db}
+}
+
+func (r *Genres) List(ctx context.Context) ([]domain.Genre, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name FROM genres ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := make([]domain.Genre, 0)
+	for rows.Next() {
+		var g domain.Genre
+		if err := rows.Scan(&g.ID, &g.Name); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+
+	return genres, rows.Err()
+}
+
+// SetForMovie replaces the genres of the movie, missing genres are created.
+func (r *Genres) SetForMovie(ctx context.Context, movieID int64, names []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movie_genres WHERE movie_id=$1", movieID); err != nil {
+		return err
+	}
+
+	if err := linkLabels(ctx, tx, "genres", "movie_genres", "genre_id", movieID, names); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// linkLabels creates the missing rows of the label table and links them to
+// the movie.
+func linkLabels(ctx context.Context, tx *sql.Tx, table, links, column string, movieID int64, names []string) error {
+	for _, name := range names {
+		var id int64
+		if err := tx.QueryRowContext(ctx, "INSERT INTO "+table+" (name) values ($1) ON CONFLICT (name) DO UPDATE SET name=excluded.name RETURNING id", name).
+			Scan(&id); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO "+links+" (movie_id, "+column+") values ($1, $2) ON CONFLICT DO NOTHING", movieID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -34,3 +34,35 @@ func (r *Tokens) Get(ctx context.Context, token string) (domain.RefreshSession,
 
 	return t, err
 }
+
+func (r *Tokens) Delete(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id=$1", userID)
+
+	return err
+}
+
+// DeleteToken removes a single session, the token has to belong to the user.
+func (r *Tokens) DeleteToken(ctx context.Context, userID int64, token string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id=$1 AND token=$2", userID, token)
+
+	return err
+}
+
+func (r *Tokens) ListByUser(ctx context.Context, userID int64) ([]domain.RefreshSession, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, expires_at FROM refresh_tokens WHERE user_id=$1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]domain.RefreshSession, 0)
+	for rows.Next() {
+		var t domain.RefreshSession
+		if err := rows.Scan(&t.ID, &t.UserID, &t.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, t)
+	}
+
+	return sessions, rows.Err()
+}
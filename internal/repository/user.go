@@ -24,12 +24,93 @@ func (r *Users) Create(ctx context.Context, user domain.User) error {
 
 func (r *Users) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, registered_at FROM users WHERE email=$1 AND password=$2", email, password).
-		Scan(&user.ID, &user.Name, &user.Email, &user.RegisteredAt)
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, registered_at, verified_at, totp_enabled FROM users WHERE email=$1 AND password=$2 AND disabled_at IS NULL", email, password).
+		Scan(&user.ID, &user.Name, &user.Email, &user.RegisteredAt, &user.VerifiedAt, &user.TOTPEnabled)
 
 	return user, err
 }
 
+func (r *Users) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	var user domain.User
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, registered_at, verified_at FROM users WHERE email=$1 AND disabled_at IS NULL", email).
+		Scan(&user.ID, &user.Name, &user.Email, &user.RegisteredAt, &user.VerifiedAt)
+
+	return user, err
+}
+
+func (r *Users) GetByID(ctx context.Context, id int64) (domain.User, error) {
+	var user domain.User
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, password, registered_at, verified_at, pending_email, totp_secret, totp_enabled, admin FROM users WHERE id=$1 AND disabled_at IS NULL", id).
+		Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.RegisteredAt, &user.VerifiedAt, &user.PendingEmail, &user.TOTPSecret, &user.TOTPEnabled, &user.Admin)
+
+	return user, err
+}
+
+func (r *Users) UpdatePassword(ctx context.Context, id int64, password string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET password=$1 WHERE id=$2", password, id)
+
+	return err
+}
+
+// SetTOTPSecret stores a pending secret, two-factor authentication is
+// enabled only after EnableTOTP.
+func (r *Users) SetTOTPSecret(ctx context.Context, id int64, secret string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET totp_secret=$1, totp_enabled=false, totp_last_step=0 WHERE id=$2", secret, id)
+
+	return err
+}
+
+func (r *Users) EnableTOTP(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET totp_enabled=true WHERE id=$1", id)
+
+	return err
+}
+
+func (r *Users) DisableTOTP(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET totp_secret='', totp_enabled=false, totp_last_step=0 WHERE id=$1", id)
+
+	return err
+}
+
+// UseTOTPStep records the time step of an accepted code. It returns false if
+// a code of the same or a later step was already used.
+func (r *Users) UseTOTPStep(ctx context.Context, id int64, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET totp_last_step=$1 WHERE id=$2 AND totp_last_step < $1", step, id)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+func (r *Users) MarkVerified(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET verified_at=now() WHERE id=$1 AND verified_at IS NULL", id)
+
+	return err
+}
+
+func (r *Users) UpdateName(ctx context.Context, id int64, name string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET name=$1 WHERE id=$2", name, id)
+
+	return err
+}
+
+// SetPendingEmail stores an email that replaces the current one once
+// confirmed with ConfirmPendingEmail.
+func (r *Users) SetPendingEmail(ctx context.Context, id int64, email string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET pending_email=$1 WHERE id=$2", email, id)
+
+	return err
+}
+
+func (r *Users) ConfirmPendingEmail(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET email=pending_email, pending_email=NULL, verified_at=now() WHERE id=$1 AND pending_email IS NOT NULL", id)
+
+	return err
+}
+
 func (r *Users) CheckUserExist(ctx context.Context, email string) (bool, error) {
 	var user domain.User
 	
@@ -45,3 +126,61 @@ func (r *Users) CheckUserExist(ctx context.Context, email string) (bool, error)
 	}
 	return true, nil
 }
+
+func (r *Users) Disable(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET disabled_at=now() WHERE id=$1", id)
+
+	return err
+}
+
+// soleMemberLibraries selects the libraries the user $1 is the only member of.
+const soleMemberLibraries = "SELECT library_id FROM library_members GROUP BY library_id HAVING bool_and(user_id=$1)"
+
+// Delete removes the user together with everything that belongs to them.
+// Shared data the user created, like movies, is kept but no longer linked
+// to the user. Shared libraries are handed over to another member.
+func (r *Users) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// libraries the user is the only member of go away with them
+	if _, err := deleteMovies(ctx, tx, "SELECT id FROM movies WHERE library_id IN ("+soleMemberLibraries+")", id); err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM refresh_tokens WHERE user_id=$1",
+		"DELETE FROM user_tokens WHERE user_id=$1",
+		"DELETE FROM recovery_codes WHERE user_id=$1",
+		"DELETE FROM api_keys WHERE user_id=$1",
+		"DELETE FROM user_identities WHERE user_id=$1",
+		"UPDATE movies SET user_id=NULL WHERE user_id=$1",
+		"DELETE FROM watch_events WHERE user_id=$1",
+		"DELETE FROM watch_states WHERE user_id=$1",
+		"DELETE FROM episode_watches WHERE user_id=$1",
+		"DELETE FROM user_subscriptions WHERE user_id=$1",
+		"DELETE FROM library_invitations WHERE library_id IN (" + soleMemberLibraries + ")",
+		"DELETE FROM libraries WHERE id IN (" + soleMemberLibraries + ")",
+		// other libraries the user is the last owner of get a new owner, the
+		// oldest editor or else the oldest member
+		`UPDATE library_members m SET role='owner' FROM (
+			SELECT DISTINCT ON (c.library_id) c.library_id, c.user_id FROM library_members c
+			WHERE c.user_id<>$1 AND c.library_id IN (SELECT library_id FROM library_members WHERE user_id=$1 AND role='owner')
+			AND NOT EXISTS (SELECT 1 FROM library_members o WHERE o.library_id=c.library_id AND o.user_id<>$1 AND o.role='owner')
+			ORDER BY c.library_id, c.role='editor' DESC, c.joined_at
+		) p WHERE m.library_id=p.library_id AND m.user_id=p.user_id`,
+		"DELETE FROM library_members WHERE user_id=$1",
+		"DELETE FROM library_invitations WHERE invited_by=$1",
+		"DELETE FROM library_invitations WHERE lower(email)=(SELECT lower(email) FROM users WHERE id=$1)",
+		"DELETE FROM users WHERE id=$1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
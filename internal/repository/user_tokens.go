@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type UserTokens struct {
+	db *sql.DB
+}
+
+func NewUserTokens(db *sql.DB) *UserTokens {
+	return &UserTokens{db}
+}
+
+func (r *UserTokens) Create(ctx context.Context, token domain.UserToken) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO user_tokens (user_id, kind, token_hash, expires_at) values ($1, $2, $3, $4)",
+		token.UserID, token.Kind, token.TokenHash, token.ExpiresAt)
+
+	return err
+}
+
+// Consume deletes the token and returns it, so that it can be used only once.
+func (r *UserTokens) Consume(ctx context.Context, kind, tokenHash string) (domain.UserToken, error) {
+	var t domain.UserToken
+	err := r.db.QueryRowContext(ctx, "DELETE FROM user_tokens WHERE kind=$1 AND token_hash=$2 RETURNING id, user_id, kind, token_hash, expires_at",
+		kind, tokenHash).
+		Scan(&t.ID, &t.UserID, &t.Kind, &t.TokenHash, &t.ExpiresAt)
+
+	return t, err
+}
+
+func (r *UserTokens) DeleteByUser(ctx context.Context, userID int64, kind string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_tokens WHERE user_id=$1 AND kind=$2", userID, kind)
+
+	return err
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type WatchStates struct {
+	db *sql.DB
+}
+
+func NewWatchStates(db *sql.DB) *WatchStates {
+	return &WatchStates{db}
+}
+
+// Get returns the state of the movie with its watch dates, sql.ErrNoRows is
+// returned if the user has no state for the movie.
+func (r *WatchStates) Get(ctx context.Context, userID, movieID int64) (domain.WatchState, error) {
+	state := domain.WatchState{MovieID: movieID}
+	if err := r.db.QueryRowContext(ctx, "SELECT status, progress, rating, notes, updated_at FROM watch_states WHERE user_id=$1 AND movie_id=$2", userID, movieID).
+		Scan(&state.Status, &state.Progress, &state.Rating, &state.Notes, &state.UpdatedAt); err != nil {
+		return state, err
+	}
+
+	watches, err := r.listEvents(ctx, "SELECT id, movie_id, watched_at FROM watch_events WHERE user_id=$1 AND movie_id=$2 ORDER BY watched_at", userID, movieID)
+	if err != nil {
+		return state, err
+	}
+
+	state.Watches = watches[movieID]
+
+	return state, nil
+}
+
+// ListByUser returns all watch states of the user.
+func (r *WatchStates) ListByUser(ctx context.Context, userID int64) ([]domain.WatchState, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT movie_id, status, progress, rating, notes, updated_at FROM watch_states WHERE user_id=$1 ORDER BY updated_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make([]domain.WatchState, 0)
+	for rows.Next() {
+		var s domain.WatchState
+		if err := rows.Scan(&s.MovieID, &s.Status, &s.Progress, &s.Rating, &s.Notes, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		states = append(states, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	watches, err := r.listEvents(ctx, "SELECT id, movie_id, watched_at FROM watch_events WHERE user_id=$1 ORDER BY watched_at", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range states {
+		states[i].Watches = watches[states[i].MovieID]
+	}
+
+	return states, nil
+}
+
+func (r *WatchStates) Set(ctx context.Context, userID, movieID int64, inp domain.UpdateWatchStateInput) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO watch_states (user_id, movie_id, status, progress, rating, notes, updated_at) values ($1, $2, $3, $4, $5, $6, now()) "+
+		"ON CONFLICT (user_id, movie_id) DO UPDATE SET status=excluded.status, progress=excluded.progress, rating=excluded.rating, notes=excluded.notes, updated_at=excluded.updated_at",
+		userID, movieID, inp.Status, inp.Progress, inp.Rating, inp.Notes)
+
+	return err
+}
+
+// Delete removes the state of the movie together with its watch dates.
+func (r *WatchStates) Delete(ctx context.Context, userID, movieID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM watch_events WHERE user_id=$1 AND movie_id=$2",
+		"DELETE FROM watch_states WHERE user_id=$1 AND movie_id=$2",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID, movieID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddWatch records a watch and marks the movie as watched. Progress is reset,
+// the rating and notes are kept.
+func (r *WatchStates) AddWatch(ctx context.Context, userID, movieID int64, watchedAt time.Time) (domain.WatchEvent, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.WatchEvent{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO watch_states (user_id, movie_id, status, updated_at) values ($1, $2, $3, now()) "+
+		"ON CONFLICT (user_id, movie_id) DO UPDATE SET status=excluded.status, progress=NULL, updated_at=excluded.updated_at",
+		userID, movieID, domain.WatchStatusWatched); err != nil {
+		return domain.WatchEvent{}, err
+	}
+
+	event := domain.WatchEvent{WatchedAt: watchedAt}
+	if err := tx.QueryRowContext(ctx, "INSERT INTO watch_events (user_id, movie_id, watched_at) values ($1, $2, $3) RETURNING id",
+		userID, movieID, watchedAt).Scan(&event.ID); err != nil {
+		return domain.WatchEvent{}, err
+	}
+
+	return event, tx.Commit()
+}
+
+// DeleteWatch returns false if the movie has no such watch of the user.
+func (r *WatchStates) DeleteWatch(ctx context.Context, userID, movieID, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM watch_events WHERE id=$1 AND user_id=$2 AND movie_id=$3", id, userID, movieID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n > 0, err
+}
+
+// listEvents groups the watch events returned by the query by movie.
+func (r *WatchStates) listEvents(ctx context.Context, query string, args ...interface{}) (map[int64][]domain.WatchEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make(map[int64][]domain.WatchEvent)
+	for rows.Next() {
+		var (
+			e       domain.WatchEvent
+			movieID int64
+		)
+		if err := rows.Scan(&e.ID, &movieID, &e.WatchedAt); err != nil {
+			return nil, err
+		}
+		events[movieID] = append(events[movieID], e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	crand "crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const apiKeyPrefix = "mm_"
+
+type APIKeysRepository interface {
+	Create(ctx context.Context, key domain.APIKey) (int64, error)
+	List(ctx context.Context, userID int64) ([]domain.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (domain.APIKey, error)
+	Touch(ctx context.Context, id int64) error
+	Revoke(ctx context.Context, userID, id int64) (bool, error)
+}
+
+type APIKeys struct {
+	repo APIKeysRepository
+}
+
+func NewAPIKeys(repo APIKeysRepository) *APIKeys {
+	return &APIKeys{
+		repo: repo,
+	}
+}
+
+// Create issues a new key. The plain key is returned only here.
+func (s *APIKeys) Create(ctx context.Context, userID int64, inp domain.CreateAPIKeyInput) (domain.CreatedAPIKey, error) {
+	b := make([]byte, 24)
+	if _, err := crand.Read(b); err != nil {
+		return domain.CreatedAPIKey{}, err
+	}
+
+	secret := fmt.Sprintf("%x", b)
+	plain := apiKeyPrefix + secret
+
+	key := domain.APIKey{
+		UserID:    userID,
+		Name:      inp.Name,
+		Prefix:    plain[:len(apiKeyPrefix)+8],
+		KeyHash:   hashUserToken(plain),
+		Scopes:    inp.Scopes,
+		ExpiresAt: inp.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	id, err := s.repo.Create(ctx, key)
+	if err != nil {
+		return domain.CreatedAPIKey{}, err
+	}
+	key.ID = id
+
+	return domain.CreatedAPIKey{APIKey: key, Key: plain}, nil
+}
+
+func (s *APIKeys) List(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	return s.repo.List(ctx, userID)
+}
+
+func (s *APIKeys) Revoke(ctx context.Context, userID, id int64) error {
+	ok, err := s.repo.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Authenticate resolves a plain key to an active key.
+func (s *APIKeys) Authenticate(ctx context.Context, plain string) (domain.APIKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return domain.APIKey{}, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, hashUserToken(plain))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.APIKey{}, domain.ErrInvalidAPIKey
+		}
+
+		return domain.APIKey{}, err
+	}
+
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+		return domain.APIKey{}, domain.ErrInvalidAPIKey
+	}
+
+	if err := s.repo.Touch(ctx, key.ID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "APIKeys.Authenticate",
+		}).Error("failed to update last usage:", err)
+	}
+
+	return key, nil
+}
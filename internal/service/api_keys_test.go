@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryAPIKeys struct {
+	mu      sync.Mutex
+	keys    []domain.APIKey
+	revoked map[int64]bool
+}
+
+func (r *memoryAPIKeys) Create(ctx context.Context, key domain.APIKey) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = int64(len(r.keys) + 1)
+	r.keys = append(r.keys, key)
+
+	return key.ID, nil
+}
+
+func (r *memoryAPIKeys) List(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]domain.APIKey, 0)
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (r *memoryAPIKeys) GetByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash && !r.revoked[key.ID] {
+			return key, nil
+		}
+	}
+
+	return domain.APIKey{}, sql.ErrNoRows
+}
+
+func (r *memoryAPIKeys) Touch(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *memoryAPIKeys) Revoke(ctx context.Context, userID, id int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.ID == id && key.UserID == userID && !r.revoked[id] {
+			r.revoked[id] = true
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestAPIKeysAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	keys := NewAPIKeys(&memoryAPIKeys{revoked: make(map[int64]bool)})
+
+	created, err := keys.Create(ctx, 1, domain.CreateAPIKeyInput{Name: "script", Scopes: []string{domain.ScopeMoviesRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("key %q doesn't start with its prefix %q", created.Key, created.Prefix)
+	}
+
+	key, err := keys.Authenticate(ctx, created.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.UserID != 1 || !key.HasScope(domain.ScopeMoviesRead) || key.HasScope(domain.ScopeMoviesWrite) {
+		t.Errorf("Authenticate() = %+v, want a read only key of user 1", key)
+	}
+
+	for _, plain := range []string{"", created.Key[len(apiKeyPrefix):], created.Key + "0", "mm_unknown"} {
+		if _, err := keys.Authenticate(ctx, plain); !errors.Is(err, domain.ErrInvalidAPIKey) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", plain, err, domain.ErrInvalidAPIKey)
+		}
+	}
+}
+
+func TestAPIKeysExpired(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryAPIKeys{revoked: make(map[int64]bool)}
+	keys := NewAPIKeys(repo)
+
+	created, err := keys.Create(ctx, 1, domain.CreateAPIKeyInput{Name: "script", Scopes: []string{domain.ScopeMoviesRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	repo.keys[0].ExpiresAt = &expired
+
+	if _, err := keys.Authenticate(ctx, created.Key); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() of an expired key error = %v, want %v", err, domain.ErrInvalidAPIKey)
+	}
+}
+
+func TestAPIKeysRevoke(t *testing.T) {
+	ctx := context.Background()
+	keys := NewAPIKeys(&memoryAPIKeys{revoked: make(map[int64]bool)})
+
+	created, err := keys.Create(ctx, 1, domain.CreateAPIKeyInput{Name: "script", Scopes: []string{domain.ScopeMoviesWrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keys.Revoke(ctx, 2, created.ID); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("Revoke() of another user's key error = %v, want %v", err, domain.ErrAPIKeyNotFound)
+	}
+
+	if err := keys.Revoke(ctx, 1, created.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keys.Authenticate(ctx, created.Key); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() of a revoked key error = %v, want %v", err, domain.ErrInvalidAPIKey)
+	}
+}
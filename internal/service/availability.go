@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type AvailabilityRepository interface {
+	ListByMovie(ctx context.Context, movieID int64, region string) ([]domain.Availability, error)
+	Get(ctx context.Context, movieID, id int64) (domain.Availability, error)
+	Create(ctx context.Context, movieID int64, inp domain.AvailabilityInput) (int64, error)
+	Update(ctx context.Context, id int64, inp domain.AvailabilityInput) error
+	Delete(ctx context.Context, movieID, id int64) (bool, error)
+	Search(ctx context.Context, filter domain.AvailabilityFilter) ([]domain.AvailableMovie, error)
+	Available(ctx context.Context, filter domain.AvailableFilter) ([]domain.AvailableMovie, error)
+}
+
+// Availability tracks where movies can be watched in which regions.
+type Availability struct {
+	repo     AvailabilityRepository
+	movies   MoviesGetter
+	services StreamingServicesRepository
+}
+
+func NewAvailability(repo AvailabilityRepository, movies MoviesGetter, services StreamingServicesRepository) *Availability {
+	return &Availability{
+		repo:     repo,
+		movies:   movies,
+		services: services,
+	}
+}
+
+func (s *Availability) List(ctx context.Context, movieID int64, region string) ([]domain.Availability, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListByMovie(ctx, movieID, region)
+}
+
+func (s *Availability) Create(ctx context.Context, movieID int64, inp domain.AvailabilityInput) (domain.Availability, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.Availability{}, err
+	}
+
+	if err := checkServiceRegion(ctx, s.services, inp.ServiceID, inp.Region); err != nil {
+		return domain.Availability{}, err
+	}
+
+	id, err := s.repo.Create(ctx, movieID, inp)
+	if err != nil {
+		return domain.Availability{}, err
+	}
+
+	return s.repo.Get(ctx, movieID, id)
+}
+
+func (s *Availability) Update(ctx context.Context, movieID, id int64, inp domain.AvailabilityInput) (domain.Availability, error) {
+	if _, err := s.get(ctx, movieID, id); err != nil {
+		return domain.Availability{}, err
+	}
+
+	if err := checkServiceRegion(ctx, s.services, inp.ServiceID, inp.Region); err != nil {
+		return domain.Availability{}, err
+	}
+
+	if err := s.repo.Update(ctx, id, inp); err != nil {
+		return domain.Availability{}, err
+	}
+
+	return s.repo.Get(ctx, movieID, id)
+}
+
+func (s *Availability) Delete(ctx context.Context, movieID, id int64) error {
+	ok, err := s.repo.Delete(ctx, movieID, id)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrAvailabilityNotFound
+	}
+
+	return nil
+}
+
+// Search returns the movies available today on the services in the region.
+func (s *Availability) Search(ctx context.Context, filter domain.AvailabilityFilter) ([]domain.AvailableMovie, error) {
+	ids, err := s.resolveServices(ctx, filter.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.ServiceIDs = ids
+	if filter.At.IsZero() {
+		filter.At = time.Now().UTC()
+	}
+
+	return s.repo.Search(ctx, filter)
+}
+
+// Available returns the unwatched movies the user can watch today on their
+// subscriptions.
+func (s *Availability) Available(ctx context.Context, filter domain.AvailableFilter) ([]domain.AvailableMovie, error) {
+	if filter.At.IsZero() {
+		filter.At = time.Now().UTC()
+	}
+
+	return s.repo.Available(ctx, filter)
+}
+
+// resolveServices maps catalog ids and names to ids.
+func (s *Availability) resolveServices(ctx context.Context, services []string) ([]int64, error) {
+	ids := make([]int64, 0, len(services))
+	for _, value := range services {
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			ids = append(ids, id)
+			continue
+		}
+
+		service, err := s.services.FindByKey(ctx, domain.StreamingServiceKey(value))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, domain.ErrUnknownStreamingService
+			}
+
+			return nil, err
+		}
+
+		ids = append(ids, service.ID)
+	}
+
+	return ids, nil
+}
+
+// checkServiceRegion makes sure the service exists and operates in the
+// region.
+func checkServiceRegion(ctx context.Context, services StreamingServicesRepository, serviceID int64, region string) error {
+	service, err := services.GetByID(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrUnknownStreamingService
+		}
+
+		return err
+	}
+
+	if len(service.Regions) == 0 {
+		return nil
+	}
+
+	for _, r := range service.Regions {
+		if r == region {
+			return nil
+		}
+	}
+
+	return domain.ErrServiceNotInRegion
+}
+
+func (s *Availability) get(ctx context.Context, movieID, id int64) (domain.Availability, error) {
+	availability, err := s.repo.Get(ctx, movieID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return availability, domain.ErrAvailabilityNotFound
+	}
+
+	return availability, err
+}
+
+func (s *Availability) checkMovie(ctx context.Context, movieID int64) error {
+	_, err := s.movies.GetMovieByID(ctx, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrBookNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryAvailability struct {
+	AvailabilityRepository
+
+	mu      sync.Mutex
+	created []domain.AvailabilityInput
+	search  domain.AvailabilityFilter
+	// available is the filter of the last Available call.
+	available domain.AvailableFilter
+}
+
+func (r *memoryAvailability) Create(ctx context.Context, movieID int64, inp domain.AvailabilityInput) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.created = append(r.created, inp)
+
+	return int64(len(r.created)), nil
+}
+
+func (r *memoryAvailability) Get(ctx context.Context, movieID, id int64) (domain.Availability, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > int64(len(r.created)) {
+		return domain.Availability{}, sql.ErrNoRows
+	}
+
+	inp := r.created[id-1]
+
+	return domain.Availability{ID: id, MovieID: movieID, ServiceID: inp.ServiceID, Region: inp.Region, Kind: inp.Kind}, nil
+}
+
+func (r *memoryAvailability) Search(ctx context.Context, filter domain.AvailabilityFilter) ([]domain.AvailableMovie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.search = filter
+
+	return nil, nil
+}
+
+// newAvailabilityEnv returns a service with the movie 1 and the streaming
+// services of newStreamingEnv, Netflix (2) only operates in the US.
+func newAvailabilityEnv(t *testing.T) (*Availability, *memoryAvailability) {
+	t.Helper()
+
+	_, services := newStreamingEnv(t)
+	services.services[1].Regions = []string{"US"}
+
+	repo := &memoryAvailability{}
+
+	return NewAvailability(repo, memoryMovies{1: {ID: 1, Title: "Heat"}}, services), repo
+}
+
+func TestCreateAvailability(t *testing.T) {
+	ctx := context.Background()
+	availability, repo := newAvailabilityEnv(t)
+
+	tests := []struct {
+		name    string
+		movieID int64
+		inp     domain.AvailabilityInput
+		err     error
+	}{
+		{"unknown movie", 2, domain.AvailabilityInput{ServiceID: 1, Region: "DE", Kind: domain.AvailabilityRent}, domain.ErrBookNotFound},
+		{"unknown service", 1, domain.AvailabilityInput{ServiceID: 3, Region: "DE", Kind: domain.AvailabilityRent}, domain.ErrUnknownStreamingService},
+		{"service outside its regions", 1, domain.AvailabilityInput{ServiceID: 2, Region: "DE", Kind: domain.AvailabilitySubscription}, domain.ErrServiceNotInRegion},
+		{"service in its region", 1, domain.AvailabilityInput{ServiceID: 2, Region: "US", Kind: domain.AvailabilitySubscription}, nil},
+		{"service available everywhere", 1, domain.AvailabilityInput{ServiceID: 1, Region: "DE", Kind: domain.AvailabilityBuy}, nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := availability.Create(ctx, tt.movieID, tt.inp); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Create() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if len(repo.created) != 2 {
+		t.Errorf("%d availabilities were stored, want 2", len(repo.created))
+	}
+
+	if _, err := availability.Update(ctx, 1, 3, domain.AvailabilityInput{ServiceID: 1, Region: "DE", Kind: domain.AvailabilityBuy}); !errors.Is(err, domain.ErrAvailabilityNotFound) {
+		t.Errorf("Update() of an unknown availability error = %v, want %v", err, domain.ErrAvailabilityNotFound)
+	}
+}
+
+func TestSearchAvailabilityServices(t *testing.T) {
+	ctx := context.Background()
+	availability, repo := newAvailabilityEnv(t)
+
+	before := time.Now().UTC()
+
+	if _, err := availability.Search(ctx, domain.AvailabilityFilter{Region: "US", Services: []string{"disney plus", "2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int64{1, 2}; !reflect.DeepEqual(repo.search.ServiceIDs, want) {
+		t.Errorf("searched services %v, want %v", repo.search.ServiceIDs, want)
+	}
+
+	if repo.search.At.Before(before) {
+		t.Errorf("searched at %s, want the current time", repo.search.At)
+	}
+
+	if _, err := availability.Search(ctx, domain.AvailabilityFilter{Region: "US", Services: []string{"Hulu"}}); !errors.Is(err, domain.ErrUnknownStreamingService) {
+		t.Errorf("Search() of an unknown service error = %v, want %v", err, domain.ErrUnknownStreamingService)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+// maxDuplicateCandidates bounds the movies compared with a new one.
+const maxDuplicateCandidates = 500
+
+type DuplicatesRepository interface {
+	GetMovieByID(ctx context.Context, id int64) (domain.Movie, error)
+	ListForDuplicates(ctx context.Context, titleType string, year, minLen, maxLen, limit int) ([]domain.Movie, error)
+	Merge(ctx context.Context, id, into int64) (domain.Poster, error)
+}
+
+type PosterImagesDeleter interface {
+	DeleteImages(ctx context.Context, poster domain.Poster)
+}
+
+// Duplicates finds likely duplicates of new movies and merges duplicates.
+type Duplicates struct {
+	repo    DuplicatesRepository
+	posters PosterImagesDeleter
+}
+
+func NewDuplicates(repo DuplicatesRepository, posters PosterImagesDeleter) *Duplicates {
+	return &Duplicates{
+		repo:    repo,
+		posters: posters,
+	}
+}
+
+// Find returns the movies of the same type and release year with a similar
+// title, most similar first. Movies without a release date are compared with
+// every movie.
+func (s *Duplicates) Find(ctx context.Context, movie domain.Movie) ([]domain.DuplicateCandidate, error) {
+	titleType := movie.Type
+	if titleType == "" {
+		titleType = domain.TitleTypeMovie
+	}
+
+	year := 0
+	if !movie.Release.IsZero() {
+		year = movie.Release.Year()
+	}
+
+	minLen, maxLen := domain.DuplicateTitleLengths(movie.Title)
+
+	movies, err := s.repo.ListForDuplicates(ctx, titleType, year, minLen, maxLen, maxDuplicateCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]domain.DuplicateCandidate, 0)
+	for _, m := range movies {
+		similarity := domain.TitleSimilarity(movie.Title, m.Title)
+		if similarity < domain.DuplicateSimilarity {
+			continue
+		}
+
+		candidates = append(candidates, domain.DuplicateCandidate{
+			ID:         m.ID,
+			Title:      m.Title,
+			Type:       m.Type,
+			Release:    m.Release,
+			Similarity: similarity,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	return candidates, nil
+}
+
+// Merge merges the movie into inp.Into and returns the remaining movie.
+func (s *Duplicates) Merge(ctx context.Context, id int64, inp domain.MergeMovieInput) (domain.Movie, error) {
+	if id == inp.Into {
+		return domain.Movie{}, domain.ErrMergeSameMovie
+	}
+
+	movie, err := s.get(ctx, id)
+	if err != nil {
+		return movie, err
+	}
+
+	into, err := s.get(ctx, inp.Into)
+	if err != nil {
+		return into, err
+	}
+
+	if movie.Type != into.Type {
+		return into, domain.ErrMergeTypeMismatch
+	}
+
+	dropped, err := s.repo.Merge(ctx, id, inp.Into)
+	if err != nil {
+		return into, err
+	}
+
+	// the poster row is gone with the merge, its images are deleted best-effort
+	if dropped.Key != "" {
+		s.posters.DeleteImages(ctx, dropped)
+	}
+
+	return s.get(ctx, inp.Into)
+}
+
+func (s *Duplicates) get(ctx context.Context, id int64) (domain.Movie, error) {
+	movie, err := s.repo.GetMovieByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return movie, domain.ErrBookNotFound
+	}
+
+	return movie, err
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryDuplicates struct {
+	memoryMovies
+
+	titleType string
+	year      int
+	merged    [][2]int64
+	// dropped is the poster of the merged movie.
+	dropped domain.Poster
+}
+
+func (r *memoryDuplicates) ListForDuplicates(ctx context.Context, titleType string, year, minLen, maxLen, limit int) ([]domain.Movie, error) {
+	r.titleType, r.year = titleType, year
+
+	var movies []domain.Movie
+	for _, movie := range r.memoryMovies {
+		if movie.Type == titleType {
+			movies = append(movies, movie)
+		}
+	}
+
+	return movies, nil
+}
+
+func (r *memoryDuplicates) Merge(ctx context.Context, id, into int64) (domain.Poster, error) {
+	r.merged = append(r.merged, [2]int64{id, into})
+
+	return r.dropped, nil
+}
+
+func newDuplicatesRepo() *memoryDuplicates {
+	return &memoryDuplicates{memoryMovies: memoryMovies{
+		1: {ID: 1, Title: "The Witcher", Type: domain.TitleTypeSeries},
+		2: {ID: 2, Title: "Witcher", Type: domain.TitleTypeSeries},
+		3: {ID: 3, Title: "The Witch", Type: domain.TitleTypeMovie},
+		4: {ID: 4, Title: "Witches", Type: domain.TitleTypeMovie},
+		5: {ID: 5, Title: "Heat", Type: domain.TitleTypeMovie},
+	}}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	repo := newDuplicatesRepo()
+	duplicates := &Duplicates{repo: repo}
+
+	release, err := domain.ParseReleaseDate("2015")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err := duplicates.Find(context.Background(), domain.Movie{Title: "Witch", Release: release})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.titleType != domain.TitleTypeMovie || repo.year != 2015 {
+		t.Errorf("compared %s movies of %d, want the movie type by default and the release year", repo.titleType, repo.year)
+	}
+
+	if len(candidates) != 1 || candidates[0].ID != 3 {
+		t.Fatalf("Find() = %+v, want the movie 3", candidates)
+	}
+
+	candidates, err = duplicates.Find(context.Background(), domain.Movie{Title: "The Witcher!", Type: domain.TitleTypeSeries})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.year != 0 {
+		t.Errorf("compared movies of %d, want all years without a release date", repo.year)
+	}
+
+	if len(candidates) != 2 {
+		t.Fatalf("Find() = %+v, want both series", candidates)
+	}
+
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Similarity > candidates[i-1].Similarity {
+			t.Errorf("Find() = %+v, want the most similar first", candidates)
+		}
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	ctx := context.Background()
+	repo := newDuplicatesRepo()
+	duplicates := &Duplicates{repo: repo}
+
+	tests := []struct {
+		name     string
+		id, into int64
+		err      error
+	}{
+		{"itself", 1, 1, domain.ErrMergeSameMovie},
+		{"unknown movie", 6, 1, domain.ErrBookNotFound},
+		{"into an unknown movie", 1, 6, domain.ErrBookNotFound},
+		{"other type", 3, 1, domain.ErrMergeTypeMismatch},
+	}
+
+	for _, tt := range tests {
+		if _, err := duplicates.Merge(ctx, tt.id, domain.MergeMovieInput{Into: tt.into}); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Merge() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if len(repo.merged) != 0 {
+		t.Fatalf("movies %v were merged by rejected requests", repo.merged)
+	}
+
+	movie, err := duplicates.Merge(ctx, 2, domain.MergeMovieInput{Into: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if movie.ID != 1 || len(repo.merged) != 1 || repo.merged[0] != [2]int64{2, 1} {
+		t.Errorf("Merge() = %+v, merged %v, want the movie 2 merged into 1", movie, repo.merged)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type UserMoviesRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.Movie, error)
+}
+
+type UserWatchStatesRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.WatchState, error)
+}
+
+type UserEpisodeWatchesRepository interface {
+	ListWatchesByUser(ctx context.Context, userID int64) ([]domain.WatchedEpisode, error)
+}
+
+type UserSubscriptionsRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.Subscription, error)
+}
+
+type UserSessionsRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.RefreshSession, error)
+}
+
+type UserIdentitiesRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.UserIdentity, error)
+}
+
+type UserLibrariesRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.Library, error)
+	ListInvitationsSent(ctx context.Context, userID int64) ([]domain.LibraryInvitation, error)
+	ListInvitationsReceived(ctx context.Context, email string) ([]domain.LibraryInvitation, error)
+}
+
+// Exports collects the personal data of users.
+type Exports struct {
+	users         UsersRepository
+	movies        UserMoviesRepository
+	watchStates   UserWatchStatesRepository
+	episodes      UserEpisodeWatchesRepository
+	subscriptions UserSubscriptionsRepository
+	sessions      UserSessionsRepository
+	apiKeys       APIKeysRepository
+	identities    UserIdentitiesRepository
+	libraries     UserLibrariesRepository
+	auditClient   AuditClient
+}
+
+func NewExports(users UsersRepository, movies UserMoviesRepository, watchStates UserWatchStatesRepository, episodes UserEpisodeWatchesRepository,
+	subscriptions UserSubscriptionsRepository, sessions UserSessionsRepository, apiKeys APIKeysRepository, identities UserIdentitiesRepository,
+	libraries UserLibrariesRepository, auditClient AuditClient) *Exports {
+	return &Exports{
+		users:         users,
+		movies:        movies,
+		watchStates:   watchStates,
+		episodes:      episodes,
+		subscriptions: subscriptions,
+		sessions:      sessions,
+		apiKeys:       apiKeys,
+		identities:    identities,
+		libraries:     libraries,
+		auditClient:   auditClient,
+	}
+}
+
+// Export returns everything stored about the user. The audit log is kept by
+// the audit service and is not part of the export.
+func (s *Exports) Export(ctx context.Context, userID int64) (domain.DataExport, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.DataExport{}, domain.ErrUserNotFound
+		}
+
+		return domain.DataExport{}, err
+	}
+
+	movies, err := s.movies.ListByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	watchStates, err := s.watchStates.ListByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	episodes, err := s.episodes.ListWatchesByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	subscriptions, err := s.subscriptions.ListByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	sessions, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	apiKeys, err := s.apiKeys.List(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	identities, err := s.identities.ListByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	libraries, err := s.libraries.ListByUser(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	sent, err := s.libraries.ListInvitationsSent(ctx, userID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	received, err := s.libraries.ListInvitationsReceived(ctx, user.Email)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+
+	export := domain.DataExport{
+		ExportedAt:    time.Now(),
+		Profile:       user.Profile(),
+		Movies:        movies,
+		WatchStates:   watchStates,
+		Episodes:      episodes,
+		Subscriptions: subscriptions,
+		Sessions:      make([]domain.ExportedSession, 0, len(sessions)),
+		APIKeys:       apiKeys,
+		Identities:    identities,
+		Libraries:     libraries,
+		Invitations: domain.ExportedInvites{
+			Sent:     exportInvitations(sent),
+			Received: exportInvitations(received),
+		},
+	}
+
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, domain.ExportedSession{
+			ID:        session.ID,
+			ExpiresAt: session.ExpiresAt,
+		})
+	}
+
+	export.History = history(user, movies, episodes, apiKeys, identities)
+
+	sendAuditEvent(ctx, s.auditClient, "Exports.Export", domain.AuditActionExport, userID)
+
+	return export, nil
+}
+
+func exportInvitations(invitations []domain.LibraryInvitation) []domain.ExportedInvitation {
+	exported := make([]domain.ExportedInvitation, 0, len(invitations))
+	for _, inv := range invitations {
+		exported = append(exported, domain.ExportedInvitation{
+			ID:        inv.ID,
+			LibraryID: inv.LibraryID,
+			Email:     inv.Email,
+			Role:      inv.Role,
+			InvitedBy: inv.InvitedBy,
+			ExpiresAt: inv.ExpiresAt,
+		})
+	}
+
+	return exported
+}
+
+// history rebuilds the account timeline from the stored timestamps.
+func history(user domain.User, movies []domain.Movie, episodes []domain.WatchedEpisode, apiKeys []domain.APIKey, identities []domain.UserIdentity) []domain.HistoryEvent {
+	events := []domain.HistoryEvent{{Time: user.RegisteredAt, Event: domain.HistoryRegistered}}
+
+	if user.VerifiedAt != nil {
+		events = append(events, domain.HistoryEvent{Time: *user.VerifiedAt, Event: domain.HistoryEmailVerified})
+	}
+
+	for _, identity := range identities {
+		events = append(events, domain.HistoryEvent{Time: identity.CreatedAt, Event: domain.HistoryIdentityLinked, Detail: identity.Provider})
+	}
+
+	for _, key := range apiKeys {
+		events = append(events, domain.HistoryEvent{Time: key.CreatedAt, Event: domain.HistoryAPIKeyCreated, Detail: key.Name})
+
+		if key.LastUsedAt != nil {
+			events = append(events, domain.HistoryEvent{Time: *key.LastUsedAt, Event: domain.HistoryAPIKeyUsed, Detail: key.Name})
+		}
+	}
+
+	for _, movie := range movies {
+		events = append(events, domain.HistoryEvent{Time: movie.SavedAt, Event: domain.HistoryMovieSaved, Detail: movie.Title})
+	}
+
+	for _, episode := range episodes {
+		events = append(events, domain.HistoryEvent{Time: episode.WatchedAt, Event: domain.HistoryEpisodeWatched,
+			Detail: fmt.Sprintf("%s S%02dE%02d", episode.SeriesTitle, episode.SeasonNumber, episode.Number)})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events
+}
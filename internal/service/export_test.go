@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (r *memoryUsers) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, user := range r.users {
+		if user.ID == id {
+			r.users = append(r.users[:i], r.users[i+1:]...)
+		}
+	}
+
+	return nil
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	session := env.signedUpUser(t)
+
+	if err := env.users.DeleteAccount(ctx, 1, domain.DeleteAccountInput{Password: "wrong"}); !errors.Is(err, domain.ErrWrongPassword) {
+		t.Fatalf("DeleteAccount() with a wrong password error = %v, want %v", err, domain.ErrWrongPassword)
+	}
+
+	if _, err := env.repo.GetByID(ctx, 1); err != nil {
+		t.Fatalf("user was erased after a wrong password: %v", err)
+	}
+
+	if err := env.users.DeleteAccount(ctx, 1, domain.DeleteAccountInput{Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.repo.GetByID(ctx, 1); err == nil {
+		t.Error("user wasn't erased")
+	}
+
+	if _, err := env.users.ParseToken(ctx, session.AccessToken); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("ParseToken() of an erased user error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	at := func(minutes int) time.Time {
+		return time.Date(2024, 1, 1, 0, minutes, 0, 0, time.UTC)
+	}
+
+	verified, used := at(1), at(5)
+	user := domain.User{RegisteredAt: at(0), VerifiedAt: &verified}
+	movies := []domain.Movie{{Title: "Heat", SavedAt: at(4)}}
+	episodes := []domain.WatchedEpisode{{SeriesTitle: "Dark", SeasonNumber: 1, Number: 2, WatchedAt: at(6)}}
+	apiKeys := []domain.APIKey{{Name: "cli", CreatedAt: at(3), LastUsedAt: &used}}
+	identities := []domain.UserIdentity{{Provider: "google", CreatedAt: at(2)}}
+
+	want := []domain.HistoryEvent{
+		{Time: at(0), Event: domain.HistoryRegistered},
+		{Time: at(1), Event: domain.HistoryEmailVerified},
+		{Time: at(2), Event: domain.HistoryIdentityLinked, Detail: "google"},
+		{Time: at(3), Event: domain.HistoryAPIKeyCreated, Detail: "cli"},
+		{Time: at(4), Event: domain.HistoryMovieSaved, Detail: "Heat"},
+		{Time: at(5), Event: domain.HistoryAPIKeyUsed, Detail: "cli"},
+		{Time: at(6), Event: domain.HistoryEpisodeWatched, Detail: "Dark S01E02"},
+	}
+
+	got := history(user, movies, episodes, apiKeys, identities)
+	if len(got) != len(want) {
+		t.Fatalf("history() = %+v, want %+v", got, want)
+	}
+
+	for i := range want {
+		if !got[i].Time.Equal(want[i].Time) || got[i].Event != want[i].Event || got[i].Detail != want[i].Detail {
+			t.Errorf("history()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
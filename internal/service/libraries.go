@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	crand "crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/mail"
+)
+
+type LibrariesRepository interface {
+	Create(ctx context.Context, name string, ownerID int64) (domain.Library, error)
+	ListByUser(ctx context.Context, userID int64) ([]domain.Library, error)
+	Get(ctx context.Context, id, userID int64) (domain.Library, error)
+	Rename(ctx context.Context, id int64, name string) error
+	Delete(ctx context.Context, id int64) error
+	ListMembers(ctx context.Context, id int64) ([]domain.LibraryMember, error)
+	UpdateMember(ctx context.Context, id, userID int64, role string) error
+	RemoveMember(ctx context.Context, id, userID int64) error
+	CreateInvitation(ctx context.Context, inv domain.LibraryInvitation) error
+	GetInvitation(ctx context.Context, tokenHash string) (domain.LibraryInvitation, error)
+	AcceptInvitation(ctx context.Context, inv domain.LibraryInvitation, userID int64) error
+}
+
+type LibraryMoviesRepository interface {
+	Create(ctx context.Context, movie domain.Movie) error
+	ListByLibrary(ctx context.Context, libraryID int64) ([]domain.Movie, error)
+	GetInLibrary(ctx context.Context, libraryID, id int64) (domain.Movie, error)
+	UpdateInLibrary(ctx context.Context, libraryID, id int64, movie domain.Movie) (bool, error)
+	DeleteInLibrary(ctx context.Context, libraryID, id int64) (bool, error)
+}
+
+// MovieNormalizer maps the user input of a movie to catalog entries.
+type MovieNormalizer interface {
+	NormalizeMovie(ctx context.Context, movie *domain.Movie) error
+}
+
+// InvitationsConfig describes the link sent to invited members. The token is
+// appended to the URL as the "token" query parameter.
+type InvitationsConfig struct {
+	URL string
+	TTL time.Duration
+}
+
+// Libraries manages shared movie libraries. Every operation checks the role
+// of the user in the library; libraries the user isn't a member of are
+// reported as not found.
+type Libraries struct {
+	repo        LibrariesRepository
+	movies      LibraryMoviesRepository
+	normalizer  MovieNormalizer
+	users       UsersRepository
+	mailer      Mailer
+	invitations InvitationsConfig
+}
+
+func NewLibraries(repo LibrariesRepository, movies LibraryMoviesRepository, normalizer MovieNormalizer, users UsersRepository, mailer Mailer,
+	invitations InvitationsConfig) *Libraries {
+	return &Libraries{
+		repo:        repo,
+		movies:      movies,
+		normalizer:  normalizer,
+		users:       users,
+		mailer:      mailer,
+		invitations: invitations,
+	}
+}
+
+func (s *Libraries) Create(ctx context.Context, userID int64, inp domain.CreateLibraryInput) (domain.Library, error) {
+	return s.repo.Create(ctx, inp.Name, userID)
+}
+
+func (s *Libraries) List(ctx context.Context, userID int64) ([]domain.Library, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *Libraries) Get(ctx context.Context, userID, id int64) (domain.LibraryDetails, error) {
+	library, err := s.authorize(ctx, userID, id, domain.RoleViewer)
+	if err != nil {
+		return domain.LibraryDetails{}, err
+	}
+
+	members, err := s.repo.ListMembers(ctx, id)
+	if err != nil {
+		return domain.LibraryDetails{}, err
+	}
+
+	return domain.LibraryDetails{Library: library, Members: members}, nil
+}
+
+func (s *Libraries) Rename(ctx context.Context, userID, id int64, inp domain.CreateLibraryInput) error {
+	if _, err := s.authorize(ctx, userID, id, domain.RoleOwner); err != nil {
+		return err
+	}
+
+	return s.repo.Rename(ctx, id, inp.Name)
+}
+
+// Delete removes the library together with its movies.
+func (s *Libraries) Delete(ctx context.Context, userID, id int64) error {
+	if _, err := s.authorize(ctx, userID, id, domain.RoleOwner); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+// Invite emails a single-use invitation link. The invitation can only be
+// accepted by a user with the invited email.
+func (s *Libraries) Invite(ctx context.Context, userID, id int64, inp domain.InviteMemberInput) error {
+	library, err := s.authorize(ctx, userID, id, domain.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	inviter, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		return err
+	}
+
+	token := fmt.Sprintf("%x", b)
+
+	if err := s.repo.CreateInvitation(ctx, domain.LibraryInvitation{
+		LibraryID: id,
+		Email:     inp.Email,
+		Role:      inp.Role,
+		TokenHash: hashUserToken(token),
+		InvitedBy: userID,
+		ExpiresAt: time.Now().Add(s.invitations.TTL),
+	}); err != nil {
+		return err
+	}
+
+	link, err := withToken(s.invitations.URL, token)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      inp.Email,
+		Subject: fmt.Sprintf("%s invited you to %q", inviter.Name, library.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to the movie library %q as %s. "+
+			"Sign in or create an account with this email and follow the link below to join:\n%s\n\nThe link expires in %s.\n",
+			inviter.Name, library.Name, inp.Role, link, s.invitations.TTL),
+	})
+}
+
+// AcceptInvitation adds the user to the library with the invited role. The
+// invitation is only used up once it's accepted by the invited email.
+func (s *Libraries) AcceptInvitation(ctx context.Context, userID int64, inp domain.AcceptInvitationInput) (domain.Library, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return domain.Library{}, err
+	}
+
+	inv, err := s.repo.GetInvitation(ctx, hashUserToken(inp.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Library{}, domain.ErrInvalidUserToken
+		}
+
+		return domain.Library{}, err
+	}
+
+	if inv.ExpiresAt.Before(time.Now()) {
+		return domain.Library{}, domain.ErrInvalidUserToken
+	}
+
+	if !strings.EqualFold(inv.Email, user.Email) {
+		return domain.Library{}, domain.ErrInvitationNotForUser
+	}
+
+	if err := s.repo.AcceptInvitation(ctx, inv, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Library{}, domain.ErrInvalidUserToken
+		}
+
+		return domain.Library{}, err
+	}
+
+	return s.repo.Get(ctx, inv.LibraryID, userID)
+}
+
+func (s *Libraries) UpdateMember(ctx context.Context, userID, id, memberID int64, inp domain.UpdateMemberInput) error {
+	if _, err := s.authorize(ctx, userID, id, domain.RoleOwner); err != nil {
+		return err
+	}
+
+	err := s.repo.UpdateMember(ctx, id, memberID, inp.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrMemberNotFound
+	}
+
+	return err
+}
+
+// RemoveMember is allowed to owners and to members leaving the library.
+func (s *Libraries) RemoveMember(ctx context.Context, userID, id, memberID int64) error {
+	minRole := domain.RoleOwner
+	if memberID == userID {
+		minRole = domain.RoleViewer
+	}
+
+	if _, err := s.authorize(ctx, userID, id, minRole); err != nil {
+		return err
+	}
+
+	err := s.repo.RemoveMember(ctx, id, memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrMemberNotFound
+	}
+
+	return err
+}
+
+func (s *Libraries) ListMovies(ctx context.Context, userID, id int64) ([]domain.Movie, error) {
+	if _, err := s.authorize(ctx, userID, id, domain.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.movies.ListByLibrary(ctx, id)
+}
+
+func (s *Libraries) GetMovie(ctx context.Context, userID, id, movieID int64) (domain.Movie, error) {
+	if _, err := s.authorize(ctx, userID, id, domain.RoleViewer); err != nil {
+		return domain.Movie{}, err
+	}
+
+	movie, err := s.movies.GetInLibrary(ctx, id, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return movie, domain.ErrBookNotFound
+	}
+
+	return movie, err
+}
+
+func (s *Libraries) AddMovie(ctx context.Context, userID, id int64, movie domain.Movie) error {
+	if err := movie.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.authorize(ctx, userID, id, domain.RoleEditor); err != nil {
+		return err
+	}
+
+	if err := s.normalizer.NormalizeMovie(ctx, &movie); err != nil {
+		return err
+	}
+
+	movie.UserID = userID
+	movie.LibraryID = &id
+
+	return s.movies.Create(ctx, movie)
+}
+
+func (s *Libraries) UpdateMovie(ctx context.Context, userID, id, movieID int64, movie domain.Movie) error {
+	if err := movie.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.authorize(ctx, userID, id, domain.RoleEditor); err != nil {
+		return err
+	}
+
+	if err := s.normalizer.NormalizeMovie(ctx, &movie); err != nil {
+		return err
+	}
+
+	ok, err := s.movies.UpdateInLibrary(ctx, id, movieID, movie)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrBookNotFound
+	}
+
+	return nil
+}
+
+func (s *Libraries) DeleteMovie(ctx context.Context, userID, id, movieID int64) error {
+	if _, err := s.authorize(ctx, userID, id, domain.RoleEditor); err != nil {
+		return err
+	}
+
+	ok, err := s.movies.DeleteInLibrary(ctx, id, movieID)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrBookNotFound
+	}
+
+	return nil
+}
+
+// authorize returns the library if the user has at least minRole in it.
+func (s *Libraries) authorize(ctx context.Context, userID, id int64, minRole string) (domain.Library, error) {
+	library, err := s.repo.Get(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return library, domain.ErrLibraryNotFound
+		}
+
+		return library, err
+	}
+
+	if !domain.RoleAtLeast(library.Role, minRole) {
+		return library, domain.ErrForbidden
+	}
+
+	return library, nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+// memoryLibraries keeps libraries as a map of member roles.
+type memoryLibraries struct {
+	LibrariesRepository
+
+	mu          sync.Mutex
+	libraries   []domain.Library
+	roles       map[int64]map[int64]string
+	invitations []domain.LibraryInvitation
+}
+
+func (r *memoryLibraries) Create(ctx context.Context, name string, ownerID int64) (domain.Library, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	library := domain.Library{ID: int64(len(r.libraries) + 1), Name: name}
+	r.libraries = append(r.libraries, library)
+
+	if r.roles == nil {
+		r.roles = make(map[int64]map[int64]string)
+	}
+	r.roles[library.ID] = map[int64]string{ownerID: domain.RoleOwner}
+
+	library.Role = domain.RoleOwner
+
+	return library, nil
+}
+
+func (r *memoryLibraries) Get(ctx context.Context, id, userID int64) (domain.Library, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.roles[id][userID]
+	if !ok {
+		return domain.Library{}, sql.ErrNoRows
+	}
+
+	library := r.libraries[id-1]
+	library.Role = role
+
+	return library, nil
+}
+
+func (r *memoryLibraries) ListMembers(ctx context.Context, id int64) ([]domain.LibraryMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var members []domain.LibraryMember
+	for userID, role := range r.roles[id] {
+		members = append(members, domain.LibraryMember{UserID: userID, Role: role})
+	}
+
+	return members, nil
+}
+
+func (r *memoryLibraries) Rename(ctx context.Context, id int64, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.libraries[id-1].Name = name
+
+	return nil
+}
+
+func (r *memoryLibraries) RemoveMember(ctx context.Context, id, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[id][userID]; !ok {
+		return sql.ErrNoRows
+	}
+
+	delete(r.roles[id], userID)
+
+	return nil
+}
+
+func (r *memoryLibraries) CreateInvitation(ctx context.Context, inv domain.LibraryInvitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.invitations = append(r.invitations, inv)
+
+	return nil
+}
+
+func (r *memoryLibraries) GetInvitation(ctx context.Context, tokenHash string) (domain.LibraryInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, inv := range r.invitations {
+		if inv.TokenHash == tokenHash {
+			return inv, nil
+		}
+	}
+
+	return domain.LibraryInvitation{}, sql.ErrNoRows
+}
+
+func (r *memoryLibraries) AcceptInvitation(ctx context.Context, inv domain.LibraryInvitation, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.invitations {
+		if r.invitations[i].TokenHash == inv.TokenHash {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			r.roles[inv.LibraryID][userID] = inv.Role
+
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
+type librariesEnv struct {
+	libraries *Libraries
+	repo      *memoryLibraries
+	mailer    *memoryMailer
+}
+
+// newLibrariesEnv returns a service with the users ann (1), bob (2) and
+// carol (3) and a library owned by ann.
+func newLibrariesEnv(t *testing.T) *librariesEnv {
+	t.Helper()
+
+	ctx := context.Background()
+	users := &memoryUsers{}
+
+	for _, name := range []string{"Ann", "Bob", "Carol"} {
+		if err := users.Create(ctx, domain.User{Name: name, Email: strings.ToLower(name) + "@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := &librariesEnv{repo: &memoryLibraries{}, mailer: &memoryMailer{}}
+	env.libraries = &Libraries{
+		repo:        env.repo,
+		users:       users,
+		mailer:      env.mailer,
+		invitations: InvitationsConfig{URL: "http://movies.test/invitations", TTL: time.Hour},
+	}
+
+	if _, err := env.libraries.Create(ctx, 1, domain.CreateLibraryInput{Name: "Family"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return env
+}
+
+// join invites the user with the role and accepts the invitation.
+func (e *librariesEnv) join(t *testing.T, userID int64, email, role string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if err := e.libraries.Invite(ctx, 1, 1, domain.InviteMemberInput{Email: email, Role: role}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.libraries.AcceptInvitation(ctx, userID, domain.AcceptInvitationInput{Token: e.mailer.lastToken(t, email)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	ctx := context.Background()
+	env := newLibrariesEnv(t)
+
+	if err := env.libraries.Invite(ctx, 1, 1, domain.InviteMemberInput{Email: "bob@example.com", Role: domain.RoleEditor}); err != nil {
+		t.Fatal(err)
+	}
+
+	token := env.mailer.lastToken(t, "bob@example.com")
+
+	if _, err := env.libraries.AcceptInvitation(ctx, 3, domain.AcceptInvitationInput{Token: token}); !errors.Is(err, domain.ErrInvitationNotForUser) {
+		t.Fatalf("AcceptInvitation() by another user error = %v, want %v", err, domain.ErrInvitationNotForUser)
+	}
+
+	library, err := env.libraries.AcceptInvitation(ctx, 2, domain.AcceptInvitationInput{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if library.ID != 1 || library.Role != domain.RoleEditor {
+		t.Errorf("AcceptInvitation() = %+v, want library 1 with the editor role", library)
+	}
+
+	if _, err := env.libraries.AcceptInvitation(ctx, 2, domain.AcceptInvitationInput{Token: token}); !errors.Is(err, domain.ErrInvalidUserToken) {
+		t.Errorf("AcceptInvitation() with a used token error = %v, want %v", err, domain.ErrInvalidUserToken)
+	}
+}
+
+func TestAcceptInvitationExpired(t *testing.T) {
+	ctx := context.Background()
+	env := newLibrariesEnv(t)
+	env.libraries.invitations.TTL = -time.Minute
+
+	if err := env.libraries.Invite(ctx, 1, 1, domain.InviteMemberInput{Email: "bob@example.com", Role: domain.RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+
+	token := env.mailer.lastToken(t, "bob@example.com")
+
+	if _, err := env.libraries.AcceptInvitation(ctx, 2, domain.AcceptInvitationInput{Token: token}); !errors.Is(err, domain.ErrInvalidUserToken) {
+		t.Errorf("AcceptInvitation() with an expired token error = %v, want %v", err, domain.ErrInvalidUserToken)
+	}
+}
+
+func TestLibraryRoles(t *testing.T) {
+	ctx := context.Background()
+	env := newLibrariesEnv(t)
+	env.join(t, 2, "bob@example.com", domain.RoleEditor)
+	env.join(t, 3, "carol@example.com", domain.RoleViewer)
+
+	rename := domain.CreateLibraryInput{Name: "Films"}
+
+	tests := []struct {
+		name string
+		call func() error
+		err  error
+	}{
+		{"viewer reads", func() error { _, err := env.libraries.Get(ctx, 3, 1); return err }, nil},
+		{"stranger reads", func() error { _, err := env.libraries.Get(ctx, 4, 1); return err }, domain.ErrLibraryNotFound},
+		{"editor renames", func() error { return env.libraries.Rename(ctx, 2, 1, rename) }, domain.ErrForbidden},
+		{"editor invites", func() error {
+			return env.libraries.Invite(ctx, 2, 1, domain.InviteMemberInput{Email: "dan@example.com", Role: domain.RoleViewer})
+		}, domain.ErrForbidden},
+		{"editor removes a member", func() error { return env.libraries.RemoveMember(ctx, 2, 1, 3) }, domain.ErrForbidden},
+		{"viewer deletes", func() error { return env.libraries.Delete(ctx, 3, 1) }, domain.ErrForbidden},
+		{"owner renames", func() error { return env.libraries.Rename(ctx, 1, 1, rename) }, nil},
+		{"viewer leaves", func() error { return env.libraries.RemoveMember(ctx, 3, 1, 3) }, nil},
+		{"owner removes a stranger", func() error { return env.libraries.RemoveMember(ctx, 1, 1, 4) }, domain.ErrMemberNotFound},
+	}
+
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if _, err := env.libraries.Get(ctx, 3, 1); !errors.Is(err, domain.ErrLibraryNotFound) {
+		t.Errorf("Get() after leaving error = %v, want %v", err, domain.ErrLibraryNotFound)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockoutConfig controls how failed sign in attempts are throttled.
+type LockoutConfig struct {
+	// EmailThreshold and IPThreshold are the numbers of failures after which
+	// an email or a client IP gets locked.
+	EmailThreshold int
+	IPThreshold    int
+	// BaseDelay is the first lockout duration, it doubles with every further
+	// failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long failures are remembered after the last one.
+	Window time.Duration
+}
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Lockout tracks failed sign in attempts in memory.
+type Lockout struct {
+	mu     sync.Mutex
+	cfg    LockoutConfig
+	counts map[string]*attempts
+}
+
+func NewLockout(cfg LockoutConfig) *Lockout {
+	return &Lockout{
+		cfg:    cfg,
+		counts: make(map[string]*attempts),
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func emailKey(email string) string {
+	return "email:" + normalizeEmail(email)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func mfaKey(userID int64) string {
+	return fmt.Sprintf("mfa:%d", userID)
+}
+
+// RetryAfter returns how long the longest locked of the keys stays locked.
+func (l *Lockout) RetryAfter(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	var wait time.Duration
+	for _, key := range keys {
+		a, ok := l.counts[key]
+		if !ok {
+			continue
+		}
+
+		if d := a.lockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// Fail records a failed attempt for each key.
+func (l *Lockout) Fail(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	for _, key := range keys {
+		a, ok := l.counts[key]
+		if !ok || now.Sub(a.lastFailure) > l.cfg.Window {
+			a = &attempts{}
+			l.counts[key] = a
+		}
+
+		a.failures++
+		a.lastFailure = now
+
+		if over := a.failures - l.threshold(key); over >= 0 {
+			a.lockedUntil = now.Add(l.delay(over))
+		}
+	}
+}
+
+// Reset forgets failures of the keys.
+func (l *Lockout) Reset(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		delete(l.counts, key)
+	}
+}
+
+// Run periodically forgets stale entries until ctx is done.
+func (l *Lockout) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.cfg.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			for key, a := range l.counts {
+				if now.Sub(a.lastFailure) > l.cfg.Window && now.After(a.lockedUntil) {
+					delete(l.counts, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *Lockout) threshold(key string) int {
+	if strings.HasPrefix(key, "ip:") {
+		return l.cfg.IPThreshold
+	}
+
+	return l.cfg.EmailThreshold
+}
+
+func (l *Lockout) delay(over int) time.Duration {
+	d := l.cfg.BaseDelay
+	for i := 0; i < over && d < l.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if d > l.cfg.MaxDelay {
+		d = l.cfg.MaxDelay
+	}
+
+	return d
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/totp"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	l := NewLockout(LockoutConfig{EmailThreshold: 3, IPThreshold: 10, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute, Window: time.Hour})
+	key := emailKey(" Ann@Example.com")
+
+	for i, want := range []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		l.Fail(key)
+
+		// RetryAfter runs a bit later than Fail
+		if got := l.RetryAfter(emailKey("ann@example.com")); got > want || got < want-time.Second {
+			t.Errorf("after %d failures RetryAfter() = %s, want %s", i+1, got, want)
+		}
+	}
+
+	l.Reset(key)
+
+	if got := l.RetryAfter(key); got != 0 {
+		t.Errorf("RetryAfter() after Reset() = %s, want 0", got)
+	}
+}
+
+func TestLockoutThresholds(t *testing.T) {
+	l := NewLockout(LockoutConfig{EmailThreshold: 1, IPThreshold: 3, BaseDelay: time.Minute, MaxDelay: time.Minute, Window: time.Hour})
+
+	for _, email := range []string{"ann@example.com", "bob@example.com"} {
+		l.Fail(emailKey(email), ipKey("10.0.0.1"))
+	}
+
+	if l.RetryAfter(emailKey("ann@example.com")) == 0 {
+		t.Error("email isn't locked after reaching its threshold")
+	}
+
+	if l.RetryAfter(ipKey("10.0.0.1")) != 0 {
+		t.Error("ip is locked before reaching its threshold")
+	}
+
+	if l.RetryAfter(emailKey("carol@example.com"), ipKey("10.0.0.2")) != 0 {
+		t.Error("other email and ip are locked")
+	}
+}
+
+func TestSignInLockout(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.users.lockout = NewLockout(LockoutConfig{EmailThreshold: 3, IPThreshold: 20, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour})
+	env.signedUpUser(t)
+
+	wrong := domain.SignInInput{Email: "ann@example.com", Password: "wrong password"}
+	right := domain.SignInInput{Email: "ann@example.com", Password: "secret"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := env.users.SignIn(ctx, wrong, "10.0.0.1"); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Fatalf("SignIn() with a wrong password error = %v, want %v", err, domain.ErrUserNotFound)
+		}
+	}
+
+	// a success before the threshold forgets the failures
+	if _, err := env.users.SignIn(ctx, right, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := env.users.SignIn(ctx, wrong, "10.0.0.1"); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Fatalf("SignIn() with a wrong password error = %v, want %v", err, domain.ErrUserNotFound)
+		}
+	}
+
+	// the right password from another ip doesn't help
+	var tooMany *domain.TooManyAttemptsError
+	if _, err := env.users.SignIn(ctx, right, "10.0.0.2"); !errors.As(err, &tooMany) {
+		t.Fatalf("SignIn() of a locked email error = %v, want %T", err, tooMany)
+	}
+
+	if tooMany.RetryAfter <= 0 || tooMany.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s, want up to a minute", tooMany.RetryAfter)
+	}
+}
+
+func TestVerifyMFALockout(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.users.tokens.MFAChallengeTTL = time.Minute
+	env.users.lockout = NewLockout(LockoutConfig{EmailThreshold: 3, IPThreshold: 20, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour})
+	env.signedUpUser(t)
+	secret, _ := env.enableTOTP(t, 1)
+
+	inp := domain.SignInInput{Email: "ann@example.com", Password: "secret"}
+
+	for i := 0; i < 3; i++ {
+		res, err := env.users.SignIn(ctx, inp, "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := env.users.VerifyMFA(ctx, domain.VerifyMFAInput{MFAToken: res.MFAToken, Code: "000000"}, "10.0.0.1"); !errors.Is(err, domain.ErrInvalidMFACode) {
+			t.Fatalf("VerifyMFA() with a wrong code error = %v, want %v", err, domain.ErrInvalidMFACode)
+		}
+	}
+
+	// a new challenge doesn't reset the failures
+	res, err := env.users.SignIn(ctx, inp, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tooMany *domain.TooManyAttemptsError
+	if _, _, err := env.users.VerifyMFA(ctx, domain.VerifyMFAInput{MFAToken: res.MFAToken, Code: code}, "10.0.0.1"); !errors.As(err, &tooMany) {
+		t.Errorf("VerifyMFA() after repeated wrong codes error = %v, want %T", err, tooMany)
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	crand "crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	audit "github.com/BalamutDiana/crud_audit/pkg/domain"
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/totp"
+)
+
+const (
+	recoveryCodesCount = 10
+	// totpSkew is the number of 30 second steps accepted around the current
+	// time to tolerate clock drift of the user's device.
+	totpSkew = 1
+)
+
+type RecoveryCodesRepository interface {
+	Replace(ctx context.Context, userID int64, codeHashes []string) error
+	Use(ctx context.Context, userID int64, codeHash string) (bool, error)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+// EnrollTOTP generates a new secret for the user. Two-factor authentication
+// is enabled only after the secret is confirmed with ConfirmTOTP.
+func (s *Users) EnrollTOTP(ctx context.Context, userID int64) (domain.TOTPEnrollment, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	if user.TOTPEnabled {
+		return domain.TOTPEnrollment{}, domain.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	if err := s.repo.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	return domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.tokens.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication if the code matches the
+// enrolled secret and returns a fresh set of recovery codes.
+func (s *Users) ConfirmTOTP(ctx context.Context, userID int64, inp domain.MFACodeInput) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, domain.ErrTOTPAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrTOTPNotEnrolled
+	}
+
+	if err := s.checkTOTP(ctx, user, inp.Code); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableTOTP(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.newRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sendAuditEvent(ctx, "Users.ConfirmTOTP", domain.AuditActionMFAEnable, userID)
+
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (s *Users) RegenerateRecoveryCodes(ctx context.Context, userID int64, inp domain.MFACodeInput) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, domain.ErrTOTPNotEnrolled
+	}
+
+	if err := s.checkMFACode(ctx, user, inp.Code); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+// DisableTOTP turns two-factor authentication off.
+func (s *Users) DisableTOTP(ctx context.Context, userID int64, inp domain.MFACodeInput) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return domain.ErrTOTPNotEnrolled
+	}
+
+	if err := s.checkMFACode(ctx, user, inp.Code); err != nil {
+		return err
+	}
+
+	if err := s.repo.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.recoveryCodes.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.DisableTOTP", domain.AuditActionMFADisable, userID)
+
+	return nil
+}
+
+// VerifyMFA exchanges an MFA challenge token and a TOTP or recovery code for
+// an access and refresh token pair. Each challenge token can be used once.
+// Wrong codes are counted per user and per client IP, a new challenge token
+// doesn't reset them.
+func (s *Users) VerifyMFA(ctx context.Context, inp domain.VerifyMFAInput, clientIP string) (string, string, error) {
+	claims, err := s.parseClaims(inp.MFAToken, s.mfaAudience())
+	if err != nil {
+		return "", "", err
+	}
+
+	if s.revocations.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt) {
+		return "", "", domain.ErrTokenRevoked
+	}
+
+	user, err := s.getUser(ctx, claims.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !user.TOTPEnabled {
+		return "", "", domain.ErrTOTPNotEnrolled
+	}
+
+	keys := []string{mfaKey(user.ID), ipKey(clientIP)}
+	if wait := s.lockout.RetryAfter(keys...); wait > 0 {
+		return "", "", &domain.TooManyAttemptsError{RetryAfter: wait}
+	}
+
+	if err := s.checkMFACode(ctx, user, inp.Code); err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			s.signInFailed(ctx, user.Email, keys...)
+		}
+
+		return "", "", err
+	}
+
+	s.lockout.Reset(mfaKey(user.ID), emailKey(user.Email))
+
+	if err := s.revocations.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt); err != nil {
+		return "", "", err
+	}
+
+	s.sendAuditEvent(ctx, "Users.VerifyMFA", audit.ACTION_LOGIN, user.ID)
+
+	return s.generateTokens(ctx, user.ID)
+}
+
+func (s *Users) getUser(ctx context.Context, userID int64) (domain.User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, domain.ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// mfaAudience is used for challenge tokens so they are never accepted as
+// access tokens.
+func (s *Users) mfaAudience() string {
+	return s.tokens.Audience + ":mfa"
+}
+
+func (s *Users) newMFAToken(userID int64) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	return s.signer.Sign(s.userClaims(jti, userID, s.mfaAudience(), now, s.tokens.MFAChallengeTTL))
+}
+
+// checkMFACode accepts either a TOTP code or an unused recovery code.
+func (s *Users) checkMFACode(ctx context.Context, user domain.User, code string) error {
+	err := s.checkTOTP(ctx, user, code)
+	if !errors.Is(err, domain.ErrInvalidMFACode) {
+		return err
+	}
+
+	ok, err := s.recoveryCodes.Use(ctx, user.ID, hashUserToken(code))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *Users) checkTOTP(ctx context.Context, user domain.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return domain.ErrInvalidMFACode
+	}
+
+	// a code can't be used twice
+	fresh, err := s.repo.UseTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return domain.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *Users) newRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := crand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := fmt.Sprintf("%x", b)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashUserToken(codes[i])
+	}
+
+	if err := s.recoveryCodes.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
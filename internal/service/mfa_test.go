@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/totp"
+)
+
+func (r *memoryUsers) SetTOTPSecret(ctx context.Context, id int64, secret string) error {
+	return r.update(id, func(u *domain.User) { u.TOTPSecret = secret })
+}
+
+func (r *memoryUsers) EnableTOTP(ctx context.Context, id int64) error {
+	return r.update(id, func(u *domain.User) { u.TOTPEnabled = true })
+}
+
+func (r *memoryUsers) DisableTOTP(ctx context.Context, id int64) error {
+	return r.update(id, func(u *domain.User) {
+		u.TOTPSecret = ""
+		u.TOTPEnabled = false
+	})
+}
+
+// totpUsers remembers the last used time step of the users.
+type totpUsers struct {
+	*memoryUsers
+
+	steps map[int64]int64
+}
+
+// UseTOTPStep accepts steps after the last used one, the same as the
+// database does.
+func (r *totpUsers) UseTOTPStep(ctx context.Context, id, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if step <= r.steps[id] {
+		return false, nil
+	}
+	r.steps[id] = step
+
+	return true, nil
+}
+
+func (r *memoryUsers) update(id int64, fn func(u *domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].ID == id {
+			fn(&r.users[i])
+		}
+	}
+
+	return nil
+}
+
+type memoryRecoveryCodes struct {
+	mu    sync.Mutex
+	codes map[int64][]string
+}
+
+func (r *memoryRecoveryCodes) Replace(ctx context.Context, userID int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.codes[userID] = codeHashes
+
+	return nil
+}
+
+func (r *memoryRecoveryCodes) Use(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, hash := range r.codes[userID] {
+		if hash == codeHash {
+			r.codes[userID] = append(r.codes[userID][:i], r.codes[userID][i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *memoryRecoveryCodes) DeleteByUser(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codes, userID)
+
+	return nil
+}
+
+// enableTOTP turns 2FA on for the user and returns the secret and the
+// recovery codes.
+func (e *mailEnv) enableTOTP(t *testing.T, userID int64) (string, []string) {
+	t.Helper()
+
+	ctx := context.Background()
+	e.users.repo = &totpUsers{memoryUsers: e.repo, steps: make(map[int64]int64)}
+	e.users.recoveryCodes = &memoryRecoveryCodes{codes: make(map[int64][]string)}
+
+	enrollment, err := e.users.EnrollTOTP(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous step, the current one is left for the sign in
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now())-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := e.users.ConfirmTOTP(ctx, userID, domain.MFACodeInput{Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return enrollment.Secret, codes
+}
+
+func TestSignInWithTOTP(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.users.tokens.MFAChallengeTTL = time.Minute
+	env.signedUpUser(t)
+
+	secret, _ := env.enableTOTP(t, 1)
+	inp := domain.SignInInput{Email: "ann@example.com", Password: "secret"}
+
+	res, err := env.users.SignIn(ctx, inp, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.MFAToken == "" || res.AccessToken != "" {
+		t.Fatalf("SignIn() = %+v, want only an MFA challenge", res)
+	}
+
+	if _, err := env.users.ParseToken(ctx, res.MFAToken); err == nil {
+		t.Error("MFA challenge token was accepted as an access token")
+	}
+
+	if _, _, err := env.users.VerifyMFA(ctx, domain.VerifyMFAInput{MFAToken: res.MFAToken, Code: "000000"}, "127.0.0.1"); !errors.Is(err, domain.ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA() with a wrong code error = %v, want %v", err, domain.ErrInvalidMFACode)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access, _, err := env.users.VerifyMFA(ctx, domain.VerifyMFAInput{MFAToken: res.MFAToken, Code: code}, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, err := env.users.ParseToken(ctx, access); err != nil || id != 1 {
+		t.Errorf("ParseToken() = %d, %v, want 1", id, err)
+	}
+
+	// the challenge is used up
+	if _, _, err := env.users.VerifyMFA(ctx, domain.VerifyMFAInput{MFAToken: res.MFAToken, Code: code}, "127.0.0.1"); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("VerifyMFA() with a used challenge error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.users.tokens.MFAChallengeTTL = time.Minute
+	env.signedUpUser(t)
+
+	_, codes := env.enableTOTP(t, 1)
+	if len(codes) != recoveryCodesCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodesCount)
+	}
+
+	inp := domain.SignInInput{Email: "ann@example.com", Password: "secret"}
+
+	for i, want := range []error{nil, domain.ErrInvalidMFACode} {
+		res, err := env.users.SignIn(ctx, inp, "127.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := env.users.VerifyMFA(ctx, domain.VerifyMFAInput{MFAToken: res.MFAToken, Code: codes[0]}, "127.0.0.1"); !errors.Is(err, want) {
+			t.Errorf("use %d of a recovery code: VerifyMFA() error = %v, want %v", i+1, err, want)
+		}
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.signedUpUser(t)
+
+	_, codes := env.enableTOTP(t, 1)
+
+	if err := env.users.DisableTOTP(ctx, 1, domain.MFACodeInput{Code: "000000"}); !errors.Is(err, domain.ErrInvalidMFACode) {
+		t.Errorf("DisableTOTP() with a wrong code error = %v, want %v", err, domain.ErrInvalidMFACode)
+	}
+
+	if err := env.users.DisableTOTP(ctx, 1, domain.MFACodeInput{Code: codes[1]}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := env.users.SignIn(ctx, domain.SignInInput{Email: "ann@example.com", Password: "secret"}, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.AccessToken == "" {
+		t.Errorf("SignIn() = %+v, want tokens without a challenge", res)
+	}
+}
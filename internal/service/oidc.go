@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	audit "github.com/BalamutDiana/crud_audit/pkg/domain"
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/oidc"
+	"github.com/golang-jwt/jwt"
+)
+
+// IdentityProvider is an external OpenID Connect provider.
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (oidc.Claims, error)
+}
+
+type IdentitiesRepository interface {
+	Create(ctx context.Context, identity domain.UserIdentity) error
+	Get(ctx context.Context, provider, subject string) (domain.UserIdentity, error)
+}
+
+type oidcState struct {
+	ID       string
+	Provider string
+	Nonce    string
+	Verifier string
+	Expires  time.Time
+}
+
+// StartOIDC returns the provider URL the user has to be redirected to. The
+// returned state must be passed back unchanged with the callback.
+func (s *Users) StartOIDC(ctx context.Context, provider string) (domain.OIDCLoginRequest, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return domain.OIDCLoginRequest{}, domain.ErrUnknownProvider
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return domain.OIDCLoginRequest{}, err
+	}
+
+	nonce, err := newTokenID()
+	if err != nil {
+		return domain.OIDCLoginRequest{}, err
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return domain.OIDCLoginRequest{}, err
+	}
+
+	url, err := p.AuthCodeURL(ctx, jti, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		return domain.OIDCLoginRequest{}, err
+	}
+
+	now := time.Now()
+
+	state, err := s.signer.Sign(jwt.MapClaims{
+		"jti":      jti,
+		"iss":      s.tokens.Issuer,
+		"aud":      s.oidcAudience(),
+		"iat":      now.Unix(),
+		"exp":      now.Add(s.tokens.OIDCStateTTL).Unix(),
+		"provider": provider,
+		"nonce":    nonce,
+		"verifier": verifier,
+	})
+	if err != nil {
+		return domain.OIDCLoginRequest{}, err
+	}
+
+	return domain.OIDCLoginRequest{URL: url, State: state}, nil
+}
+
+// FinishOIDC exchanges the authorization code and signs in the user linked
+// to the external identity. Unknown identities are linked to the verified
+// user with the same email, or a new user is created, if the provider
+// verified the email.
+func (s *Users) FinishOIDC(ctx context.Context, inp domain.OIDCCallbackInput) (domain.SignInResult, error) {
+	p, ok := s.providers[inp.Provider]
+	if !ok {
+		return domain.SignInResult{}, domain.ErrUnknownProvider
+	}
+
+	state, err := s.parseOIDCState(inp.RequestState)
+	if err != nil || state.Provider != inp.Provider || state.ID != inp.State {
+		return domain.SignInResult{}, domain.ErrInvalidOIDCState
+	}
+
+	if s.revocations.IsRevoked(state.ID, 0, time.Time{}) {
+		return domain.SignInResult{}, domain.ErrInvalidOIDCState
+	}
+
+	// a login request can be finished once
+	if err := s.revocations.RevokeToken(ctx, state.ID, 0, state.Expires); err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	claims, err := p.Exchange(ctx, inp.Code, state.Verifier, state.Nonce)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	user, err := s.identityUser(ctx, inp.Provider, claims)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.newMFAToken(user.ID)
+		if err != nil {
+			return domain.SignInResult{}, err
+		}
+
+		return domain.SignInResult{MFAToken: mfaToken}, nil
+	}
+
+	s.sendAuditEvent(ctx, "Users.FinishOIDC", audit.ACTION_LOGIN, user.ID)
+
+	accessToken, refreshToken, err := s.generateTokens(ctx, user.ID)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	return domain.SignInResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// identityUser returns the user linked to the external identity, linking it
+// first if needed.
+func (s *Users) identityUser(ctx context.Context, provider string, claims oidc.Claims) (domain.User, error) {
+	identity, err := s.identities.Get(ctx, provider, claims.Subject)
+	if err == nil {
+		return s.getUser(ctx, identity.UserID)
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, err
+	}
+
+	// linking by an unverified email would let anyone take over accounts
+	if claims.Email == "" || !claims.EmailVerified {
+		return domain.User{}, domain.ErrIdentityNotVerified
+	}
+
+	user, err := s.repo.GetByEmail(ctx, claims.Email)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if user, err = s.createIdentityUser(ctx, claims); err != nil {
+			return domain.User{}, err
+		}
+
+		if err := s.repo.MarkVerified(ctx, user.ID); err != nil {
+			return domain.User{}, err
+		}
+	case err != nil:
+		return domain.User{}, err
+	case user.VerifiedAt == nil:
+		// anyone can sign up with an email they don't own, linking would
+		// hand the account with its password to them
+		return domain.User{}, domain.ErrAccountNotVerified
+	}
+
+	if err := s.identities.Create(ctx, domain.UserIdentity{
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return domain.User{}, err
+	}
+
+	s.sendAuditEvent(ctx, "Users.FinishOIDC", domain.AuditActionIdentityLink, user.ID)
+
+	return s.getUser(ctx, user.ID)
+}
+
+// createIdentityUser registers a user without a password, one can be set
+// later with the forgot password flow.
+func (s *Users) createIdentityUser(ctx context.Context, claims oidc.Claims) (domain.User, error) {
+	// the email may belong to a disabled user
+	exist, err := s.repo.CheckUserExist(ctx, claims.Email)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if exist {
+		return domain.User{}, domain.ErrUserAlreadyExists
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	if err := s.repo.Create(ctx, domain.User{
+		Name:         name,
+		Email:        claims.Email,
+		RegisteredAt: time.Now(),
+	}); err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := s.repo.GetByEmail(ctx, claims.Email)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	s.sendAuditEvent(ctx, "Users.FinishOIDC", audit.ACTION_REGISTER, user.ID)
+
+	return user, nil
+}
+
+// oidcAudience is used for login request state so it is never accepted as
+// an access token.
+func (s *Users) oidcAudience() string {
+	return s.tokens.Audience + ":oidc"
+}
+
+func (s *Users) parseOIDCState(token string) (oidcState, error) {
+	parser := jwt.Parser{SkipClaimsValidation: true}
+
+	t, err := parser.Parse(token, s.signer.Keyfunc)
+	if err != nil {
+		return oidcState{}, err
+	}
+
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok || !t.Valid {
+		return oidcState{}, errors.New("invalid state")
+	}
+
+	if err := s.verifyClaims(claims, s.oidcAudience()); err != nil {
+		return oidcState{}, err
+	}
+
+	var state oidcState
+	state.ID, _ = claims["jti"].(string)
+	state.Provider, _ = claims["provider"].(string)
+	state.Nonce, _ = claims["nonce"].(string)
+	state.Verifier, _ = claims["verifier"].(string)
+
+	if state.ID == "" || state.Nonce == "" || state.Verifier == "" {
+		return oidcState{}, errors.New("invalid state")
+	}
+
+	exp, _ := claims["exp"].(float64)
+	state.Expires = time.Unix(int64(exp), 0)
+
+	return state, nil
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/keyring"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/oidc"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/oidc/oidctest"
+)
+
+const (
+	testProvider    = "test"
+	testClientID    = "movies"
+	testRedirectURL = "http://movies.test/auth/oidc/test/callback"
+)
+
+type memoryIdentities struct {
+	mu         sync.Mutex
+	identities []domain.UserIdentity
+}
+
+func (r *memoryIdentities) Create(ctx context.Context, identity domain.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.identities = append(r.identities, identity)
+
+	return nil
+}
+
+func (r *memoryIdentities) Get(ctx context.Context, provider, subject string) (domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+
+	return domain.UserIdentity{}, sql.ErrNoRows
+}
+
+type memoryRevocations struct {
+	mu     sync.Mutex
+	tokens map[string]bool
+}
+
+func (r *memoryRevocations) RevokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[jti] = true
+
+	return nil
+}
+
+func (r *memoryRevocations) RevokeUser(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func (r *memoryRevocations) IsRevoked(jti string, userID int64, issuedAt time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.tokens[jti]
+}
+
+type oidcEnv struct {
+	users      *Users
+	repo       *memoryUsers
+	identities *memoryIdentities
+	client     *http.Client
+}
+
+func newOIDCEnv(t *testing.T, user oidctest.User) *oidcEnv {
+	t.Helper()
+
+	var provider *oidctest.Server
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	provider, err := oidctest.New(oidctest.Options{
+		Issuer:       srv.URL,
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		User:         user,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := keyring.New(keyring.Options{Algorithm: "EdDSA", RotateEvery: time.Hour, Retain: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &oidcEnv{
+		repo:       &memoryUsers{},
+		identities: &memoryIdentities{},
+		client: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	providers := map[string]IdentityProvider{
+		testProvider: oidc.New(oidc.Config{
+			Issuer:       srv.URL,
+			ClientID:     testClientID,
+			ClientSecret: "secret",
+			RedirectURL:  testRedirectURL,
+		}, srv.Client()),
+	}
+
+	env.users = NewUsers(env.repo, &memorySessions{}, &memoryRevocations{tokens: make(map[string]bool)}, nil, nil, env.identities, providers,
+		discardAudit{}, nil, nil, nil, keys, TokenConfig{
+			AccessTTL:    time.Minute,
+			RefreshTTL:   time.Hour,
+			Issuer:       "movies",
+			Audience:     "movies",
+			Leeway:       time.Second,
+			OIDCStateTTL: time.Minute,
+		}, LinksConfig{})
+
+	return env
+}
+
+// login starts a sign in and follows it through the provider's consent page,
+// the returned input is what the client sends to the callback.
+func (e *oidcEnv) login(t *testing.T) domain.OIDCCallbackInput {
+	t.Helper()
+
+	req, err := e.users.StartOIDC(context.Background(), testProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := e.client.Get(req.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return domain.OIDCCallbackInput{
+		Provider:     testProvider,
+		Code:         callback.Query().Get("code"),
+		State:        callback.Query().Get("state"),
+		RequestState: req.State,
+	}
+}
+
+func TestFinishOIDC(t *testing.T) {
+	env := newOIDCEnv(t, oidctest.User{Subject: "42", Email: "ann@example.com", EmailVerified: true, Name: "Ann"})
+
+	res, err := env.users.FinishOIDC(context.Background(), env.login(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.AccessToken == "" || res.RefreshToken == "" {
+		t.Errorf("FinishOIDC() = %+v, want access and refresh tokens", res)
+	}
+
+	user, err := env.repo.GetByEmail(context.Background(), "ann@example.com")
+	if err != nil {
+		t.Fatalf("user wasn't created: %v", err)
+	}
+
+	if user.Name != "Ann" || user.VerifiedAt == nil {
+		t.Errorf("created user = %+v, want verified user named Ann", user)
+	}
+
+	identity, err := env.identities.Get(context.Background(), testProvider, "42")
+	if err != nil {
+		t.Fatalf("identity wasn't linked: %v", err)
+	}
+
+	if identity.UserID != user.ID {
+		t.Errorf("identity linked to user %d, want %d", identity.UserID, user.ID)
+	}
+}
+
+func TestFinishOIDCPKCEMismatch(t *testing.T) {
+	env := newOIDCEnv(t, oidctest.User{Subject: "42", Email: "ann@example.com", EmailVerified: true})
+
+	first, second := env.login(t), env.login(t)
+
+	// the code of the first login is redeemed with the verifier of the second
+	second.Code = first.Code
+
+	if _, err := env.users.FinishOIDC(context.Background(), second); err == nil {
+		t.Fatal("FinishOIDC() succeeded with a code issued for another code challenge")
+	}
+
+	if _, err := env.repo.GetByEmail(context.Background(), "ann@example.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("user was created after a failed exchange: %v", err)
+	}
+}
+
+func TestFinishOIDCStateReplay(t *testing.T) {
+	env := newOIDCEnv(t, oidctest.User{Subject: "42", Email: "ann@example.com", EmailVerified: true})
+
+	inp := env.login(t)
+
+	if _, err := env.users.FinishOIDC(context.Background(), inp); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.users.FinishOIDC(context.Background(), inp); !errors.Is(err, domain.ErrInvalidOIDCState) {
+		t.Errorf("replayed FinishOIDC() error = %v, want %v", err, domain.ErrInvalidOIDCState)
+	}
+
+	// a fresh code doesn't help either, the state is used up
+	fresh := env.login(t)
+	inp.Code = fresh.Code
+
+	if _, err := env.users.FinishOIDC(context.Background(), inp); !errors.Is(err, domain.ErrInvalidOIDCState) {
+		t.Errorf("FinishOIDC() with a used state error = %v, want %v", err, domain.ErrInvalidOIDCState)
+	}
+}
+
+func TestFinishOIDCUnverifiedEmail(t *testing.T) {
+	env := newOIDCEnv(t, oidctest.User{Subject: "42", Email: "ann@example.com", EmailVerified: false})
+
+	if err := env.repo.Create(context.Background(), domain.User{Name: "Ann", Email: "ann@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.users.FinishOIDC(context.Background(), env.login(t)); !errors.Is(err, domain.ErrIdentityNotVerified) {
+		t.Errorf("FinishOIDC() error = %v, want %v", err, domain.ErrIdentityNotVerified)
+	}
+
+	if _, err := env.identities.Get(context.Background(), testProvider, "42"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unverified identity was linked: %v", err)
+	}
+}
+
+func TestFinishOIDCLinking(t *testing.T) {
+	verified := time.Now()
+
+	tests := []struct {
+		name     string
+		existing domain.User
+		err      error
+	}{
+		{"verified account", domain.User{Name: "Ann", Email: "ann@example.com", Password: "hash", VerifiedAt: &verified}, nil},
+		// someone else may have signed up with the email and a password of
+		// their choice
+		{"unverified account", domain.User{Name: "Ann", Email: "ann@example.com", Password: "attacker"}, domain.ErrAccountNotVerified},
+	}
+
+	for _, tt := range tests {
+		env := newOIDCEnv(t, oidctest.User{Subject: "42", Email: "ann@example.com", EmailVerified: true})
+
+		if err := env.repo.Create(context.Background(), tt.existing); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := env.users.FinishOIDC(context.Background(), env.login(t))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: FinishOIDC() error = %v, want %v", tt.name, err, tt.err)
+		}
+
+		identity, getErr := env.identities.Get(context.Background(), testProvider, "42")
+		switch {
+		case tt.err == nil && (getErr != nil || identity.UserID != 1):
+			t.Errorf("%s: identity wasn't linked to the account: %v", tt.name, getErr)
+		case tt.err != nil && getErr == nil:
+			t.Errorf("%s: identity was linked to the account", tt.name)
+		}
+
+		if user, _ := env.repo.GetByID(context.Background(), 1); tt.err != nil && user.VerifiedAt != nil {
+			t.Errorf("%s: account was marked verified", tt.name)
+		}
+	}
+}
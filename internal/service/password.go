@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/mail"
+)
+
+// ForgotPassword emails a password reset link. Unknown emails are silently
+// ignored so the endpoint can't be used to find registered users.
+func (s *Users) ForgotPassword(ctx context.Context, inp domain.ForgotPasswordInput) error {
+	user, err := s.repo.GetByEmail(ctx, inp.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	if err := s.userTokens.DeleteByUser(ctx, user.ID, domain.UserTokenResetPassword); err != nil {
+		return err
+	}
+
+	token, err := s.issueUserToken(ctx, user.ID, domain.UserTokenResetPassword, s.links.ResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link, err := withToken(s.links.ResetURL, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nsomeone requested a password reset for your account. "+
+			"If it was you, follow the link below to set a new password:\n%s\n\n"+
+			"The link expires in %s. If you didn't request it, just ignore this email.\n",
+			user.Name, link, s.links.ResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.ForgotPassword", domain.AuditActionPasswordResetRequest, user.ID)
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out everywhere.
+func (s *Users) ResetPassword(ctx context.Context, inp domain.ResetPasswordInput) error {
+	token, err := s.consumeUserToken(ctx, domain.UserTokenResetPassword, inp.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, token.UserID, inp.Password); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.ResetPassword", domain.AuditActionPasswordReset, token.UserID)
+
+	return nil
+}
+
+// ChangePassword sets a new password after checking the current one and
+// signs the user out everywhere.
+func (s *Users) ChangePassword(ctx context.Context, userID int64, inp domain.ChangePasswordInput) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.hasher.Hash(inp.CurrentPassword)
+	if err != nil {
+		return err
+	}
+
+	if current != user.Password {
+		return domain.ErrWrongPassword
+	}
+
+	if err := s.setPassword(ctx, userID, inp.NewPassword); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.ChangePassword", domain.AuditActionPasswordChange, userID)
+
+	return nil
+}
+
+// setPassword stores the new password and revokes all sessions and access
+// tokens of the user.
+func (s *Users) setPassword(ctx context.Context, userID int64, password string) error {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	if err := s.userTokens.DeleteByUser(ctx, userID, domain.UserTokenResetPassword); err != nil {
+		return err
+	}
+
+	if err := s.sessionsRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.revocations.RevokeUser(ctx, userID)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (r *memoryUsers) UpdatePassword(ctx context.Context, id int64, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].ID == id {
+			r.users[i].Password = password
+		}
+	}
+
+	return nil
+}
+
+// signedUpUser signs up and verifies ann@example.com with the password
+// "secret", the returned tokens are those of a sign in.
+func (e *mailEnv) signedUpUser(t *testing.T) domain.SignInResult {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if err := e.users.SignUp(ctx, domain.SignUpInput{Name: "Ann", Email: "ann@example.com", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: e.mailer.lastToken(t, "ann@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := e.users.SignIn(ctx, domain.SignInInput{Email: "ann@example.com", Password: "secret"}, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+// checkSignedOut fails unless the tokens of the sign in were revoked.
+func (e *mailEnv) checkSignedOut(t *testing.T, res domain.SignInResult) {
+	t.Helper()
+
+	if _, err := e.users.ParseToken(context.Background(), res.AccessToken); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("ParseToken() after the password change error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+
+	if _, err := e.sessions.Get(context.Background(), res.RefreshToken); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("refresh session wasn't deleted: %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	session := env.signedUpUser(t)
+
+	if err := env.users.ForgotPassword(ctx, domain.ForgotPasswordInput{Email: "ann@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	token := env.mailer.lastToken(t, "ann@example.com")
+
+	if err := env.users.ResetPassword(ctx, domain.ResetPasswordInput{Token: token, Password: "new secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, _ := env.repo.GetByEmail(ctx, "ann@example.com"); user.Password != "hashed:new secret" {
+		t.Errorf("password = %q, want the new one", user.Password)
+	}
+
+	env.checkSignedOut(t, session)
+
+	if err := env.users.ResetPassword(ctx, domain.ResetPasswordInput{Token: token, Password: "another"}); !errors.Is(err, domain.ErrInvalidUserToken) {
+		t.Errorf("ResetPassword() with a used token error = %v, want %v", err, domain.ErrInvalidUserToken)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	env := newMailEnv(t)
+
+	if err := env.users.ForgotPassword(context.Background(), domain.ForgotPasswordInput{Email: "bob@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(env.mailer.messages) != 0 {
+		t.Errorf("%d emails were sent for an unknown user", len(env.mailer.messages))
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	session := env.signedUpUser(t)
+
+	user, err := env.repo.GetByEmail(ctx, "ann@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = env.users.ChangePassword(ctx, user.ID, domain.ChangePasswordInput{CurrentPassword: "wrong", NewPassword: "new secret"})
+	if !errors.Is(err, domain.ErrWrongPassword) {
+		t.Errorf("ChangePassword() with a wrong password error = %v, want %v", err, domain.ErrWrongPassword)
+	}
+
+	if err := env.users.ChangePassword(ctx, user.ID, domain.ChangePasswordInput{CurrentPassword: "secret", NewPassword: "new secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, _ := env.repo.GetByID(ctx, user.ID); user.Password != "hashed:new secret" {
+		t.Errorf("password = %q, want the new one", user.Password)
+	}
+
+	env.checkSignedOut(t, session)
+}
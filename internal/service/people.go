@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+const (
+	defaultPeopleLimit = 20
+	maxPeopleLimit     = 100
+)
+
+type PeopleRepository interface {
+	Create(ctx context.Context, name string) (domain.Person, error)
+	GetByID(ctx context.Context, id int64) (domain.Person, error)
+	Search(ctx context.Context, query string, limit int) ([]domain.Person, error)
+	Rename(ctx context.Context, id int64, name string) error
+	Filmography(ctx context.Context, id, userID int64) ([]domain.FilmographyEntry, error)
+	ListCredits(ctx context.Context, movieID int64) ([]domain.Credit, error)
+	AddCredit(ctx context.Context, movieID int64, inp domain.CreditInput) (int64, error)
+	DeleteCredit(ctx context.Context, movieID, id int64) (bool, error)
+}
+
+// People manages directors, cast and crew and their credits on the movies.
+type People struct {
+	repo   PeopleRepository
+	movies MoviesGetter
+}
+
+func NewPeople(repo PeopleRepository, movies MoviesGetter) *People {
+	return &People{
+		repo:   repo,
+		movies: movies,
+	}
+}
+
+func (s *People) Create(ctx context.Context, inp domain.PersonInput) (domain.Person, error) {
+	return s.repo.Create(ctx, inp.Name)
+}
+
+func (s *People) Search(ctx context.Context, query string, limit int) ([]domain.Person, error) {
+	if limit <= 0 {
+		limit = defaultPeopleLimit
+	}
+	if limit > maxPeopleLimit {
+		limit = maxPeopleLimit
+	}
+
+	return s.repo.Search(ctx, query, limit)
+}
+
+// Get returns the person with the filmography limited to the movies the
+// user can see.
+func (s *People) Get(ctx context.Context, userID, id int64) (domain.PersonDetails, error) {
+	person, err := s.get(ctx, id)
+	if err != nil {
+		return domain.PersonDetails{}, err
+	}
+
+	filmography, err := s.repo.Filmography(ctx, id, userID)
+	if err != nil {
+		return domain.PersonDetails{}, err
+	}
+
+	return domain.PersonDetails{Person: person, Filmography: filmography}, nil
+}
+
+func (s *People) Rename(ctx context.Context, id int64, inp domain.PersonInput) (domain.Person, error) {
+	if _, err := s.get(ctx, id); err != nil {
+		return domain.Person{}, err
+	}
+
+	if err := s.repo.Rename(ctx, id, inp.Name); err != nil {
+		return domain.Person{}, err
+	}
+
+	return s.get(ctx, id)
+}
+
+func (s *People) ListCredits(ctx context.Context, movieID int64) ([]domain.Credit, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListCredits(ctx, movieID)
+}
+
+func (s *People) AddCredit(ctx context.Context, movieID int64, inp domain.CreditInput) (domain.Credit, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.Credit{}, err
+	}
+
+	person, err := s.get(ctx, inp.PersonID)
+	if err != nil {
+		return domain.Credit{}, err
+	}
+
+	if inp.Role != domain.CreditCast {
+		inp.Character = ""
+	}
+	if inp.Role != domain.CreditCrew {
+		inp.Job = ""
+	}
+
+	id, err := s.repo.AddCredit(ctx, movieID, inp)
+	if err != nil {
+		return domain.Credit{}, err
+	}
+
+	return domain.Credit{
+		ID:        id,
+		MovieID:   movieID,
+		PersonID:  person.ID,
+		Name:      person.Name,
+		Role:      inp.Role,
+		Character: inp.Character,
+		Job:       inp.Job,
+	}, nil
+}
+
+func (s *People) DeleteCredit(ctx context.Context, movieID, id int64) error {
+	ok, err := s.repo.DeleteCredit(ctx, movieID, id)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrCreditNotFound
+	}
+
+	return nil
+}
+
+func (s *People) get(ctx context.Context, id int64) (domain.Person, error) {
+	person, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return person, domain.ErrPersonNotFound
+	}
+
+	return person, err
+}
+
+func (s *People) checkMovie(ctx context.Context, movieID int64) error {
+	_, err := s.movies.GetMovieByID(ctx, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrBookNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryPeople struct {
+	PeopleRepository
+
+	mu      sync.Mutex
+	people  []domain.Person
+	credits []domain.CreditInput
+}
+
+func (r *memoryPeople) GetByID(ctx context.Context, id int64) (domain.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, person := range r.people {
+		if person.ID == id {
+			return person, nil
+		}
+	}
+
+	return domain.Person{}, sql.ErrNoRows
+}
+
+func (r *memoryPeople) AddCredit(ctx context.Context, movieID int64, inp domain.CreditInput) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.credits = append(r.credits, inp)
+
+	return int64(len(r.credits)), nil
+}
+
+func (r *memoryPeople) DeleteCredit(ctx context.Context, movieID, id int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return id >= 1 && id <= int64(len(r.credits)), nil
+}
+
+func TestAddCredit(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryPeople{people: []domain.Person{{ID: 1, Name: "Michael Mann"}}}
+	people := NewPeople(repo, memoryMovies{1: {ID: 1, Title: "Heat"}})
+
+	if _, err := people.AddCredit(ctx, 2, domain.CreditInput{PersonID: 1, Role: domain.CreditDirector}); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("AddCredit() to an unknown movie error = %v, want %v", err, domain.ErrBookNotFound)
+	}
+
+	if _, err := people.AddCredit(ctx, 1, domain.CreditInput{PersonID: 2, Role: domain.CreditDirector}); !errors.Is(err, domain.ErrPersonNotFound) {
+		t.Errorf("AddCredit() of an unknown person error = %v, want %v", err, domain.ErrPersonNotFound)
+	}
+
+	tests := []struct {
+		inp            domain.CreditInput
+		character, job string
+	}{
+		{domain.CreditInput{PersonID: 1, Role: domain.CreditDirector, Character: "Neil", Job: "Writer"}, "", ""},
+		{domain.CreditInput{PersonID: 1, Role: domain.CreditCast, Character: "Neil", Job: "Writer"}, "Neil", ""},
+		{domain.CreditInput{PersonID: 1, Role: domain.CreditCrew, Character: "Neil", Job: "Writer"}, "", "Writer"},
+	}
+
+	for _, tt := range tests {
+		credit, err := people.AddCredit(ctx, 1, tt.inp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored := repo.credits[len(repo.credits)-1]
+		if credit.Name != "Michael Mann" || credit.Character != tt.character || credit.Job != tt.job ||
+			stored.Character != tt.character || stored.Job != tt.job {
+			t.Errorf("AddCredit() as %s = %+v, stored %+v, want character %q and job %q", tt.inp.Role, credit, stored, tt.character, tt.job)
+		}
+	}
+
+	if err := people.DeleteCredit(ctx, 1, 10); !errors.Is(err, domain.ErrCreditNotFound) {
+		t.Errorf("DeleteCredit() of an unknown credit error = %v, want %v", err, domain.ErrCreditNotFound)
+	}
+}
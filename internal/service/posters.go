@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/blob"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/thumbnail"
+	"github.com/sirupsen/logrus"
+)
+
+type PostersRepository interface {
+	Get(ctx context.Context, movieID int64) (domain.Poster, error)
+	Set(ctx context.Context, poster domain.Poster) error
+	Delete(ctx context.Context, movieID int64) error
+}
+
+// BlobStorage keeps poster images, see pkg/blob.
+type BlobStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type PostersConfig struct {
+	// MaxSize is the largest accepted upload in bytes.
+	MaxSize int64
+	// Sizes maps thumbnail names to their widths in pixels.
+	Sizes map[string]int
+	// MaxAge is how long clients may cache the images.
+	MaxAge time.Duration
+	// MaxUploads is the number of images decoded at the same time, further
+	// uploads wait.
+	MaxUploads int
+}
+
+const thumbnailQuality = 85
+
+// Posters stores movie posters with their thumbnails.
+type Posters struct {
+	repo    PostersRepository
+	movies  MoviesGetter
+	storage BlobStorage
+	cfg     PostersConfig
+	uploads chan struct{}
+}
+
+func NewPosters(repo PostersRepository, movies MoviesGetter, storage BlobStorage, cfg PostersConfig) *Posters {
+	return &Posters{
+		repo:    repo,
+		movies:  movies,
+		storage: storage,
+		cfg:     cfg,
+		uploads: make(chan struct{}, cfg.MaxUploads),
+	}
+}
+
+// Upload replaces the poster of the movie with a JPEG, PNG or GIF image and
+// generates its thumbnails.
+func (s *Posters) Upload(ctx context.Context, movieID int64, r io.Reader) (domain.Poster, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.Poster{}, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxSize+1))
+	if err != nil {
+		return domain.Poster{}, err
+	}
+
+	if int64(len(data)) > s.cfg.MaxSize {
+		return domain.Poster{}, domain.ErrPosterTooLarge
+	}
+
+	// the header is checked first, decoding a huge image would exhaust memory
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return domain.Poster{}, domain.ErrPosterType
+	}
+
+	if config.Width < 1 || config.Height < 1 || config.Width > domain.PosterMaxWidth || config.Height > domain.PosterMaxHeight ||
+		config.Width*config.Height > domain.PosterMaxPixels {
+		return domain.Poster{}, domain.ErrPosterSize
+	}
+
+	select {
+	case s.uploads <- struct{}{}:
+		defer func() { <-s.uploads }()
+	case <-ctx.Done():
+		return domain.Poster{}, ctx.Err()
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return domain.Poster{}, domain.ErrPosterType
+	}
+
+	hash := sha256.Sum256(data)
+	poster := domain.Poster{
+		MovieID:     movieID,
+		Key:         fmt.Sprintf("posters/%d/%s", movieID, hex.EncodeToString(hash[:8])),
+		ContentType: "image/" + format,
+		Width:       config.Width,
+		Height:      config.Height,
+		Size:        len(data),
+		Sizes:       make([]string, 0, len(s.cfg.Sizes)),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if err := s.storage.Put(ctx, poster.Key+"/"+domain.PosterOriginal, data, poster.ContentType); err != nil {
+		return domain.Poster{}, err
+	}
+
+	for name, width := range s.cfg.Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, thumbnail.Resize(img, width), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return domain.Poster{}, err
+		}
+
+		if err := s.storage.Put(ctx, poster.Key+"/"+name, buf.Bytes(), "image/jpeg"); err != nil {
+			return domain.Poster{}, err
+		}
+
+		poster.Sizes = append(poster.Sizes, name)
+	}
+	sort.Strings(poster.Sizes)
+
+	old, err := s.repo.Get(ctx, movieID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return domain.Poster{}, err
+	}
+
+	if err := s.repo.Set(ctx, poster); err != nil {
+		return domain.Poster{}, err
+	}
+
+	// the same image uploaded again keeps its key
+	if old.Key != "" && old.Key != poster.Key {
+		s.DeleteImages(ctx, old)
+	}
+
+	return withURLs(poster), nil
+}
+
+func (s *Posters) Get(ctx context.Context, movieID int64) (domain.Poster, error) {
+	poster, err := s.repo.Get(ctx, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return poster, domain.ErrPosterNotFound
+	}
+	if err != nil {
+		return poster, err
+	}
+
+	return withURLs(poster), nil
+}
+
+// Image returns the original or a thumbnail of the poster.
+func (s *Posters) Image(ctx context.Context, movieID int64, size string) (domain.PosterImage, error) {
+	poster, err := s.Get(ctx, movieID)
+	if err != nil {
+		return domain.PosterImage{}, err
+	}
+
+	contentType := "image/jpeg"
+	if size == domain.PosterOriginal {
+		contentType = poster.ContentType
+	} else if !contains(poster.Sizes, size) {
+		return domain.PosterImage{}, domain.ErrPosterNotFound
+	}
+
+	data, err := s.storage.Get(ctx, poster.Key+"/"+size)
+	if errors.Is(err, blob.ErrNotFound) {
+		return domain.PosterImage{}, domain.ErrPosterNotFound
+	}
+	if err != nil {
+		return domain.PosterImage{}, err
+	}
+
+	return domain.PosterImage{
+		ContentType: contentType,
+		// the key changes with the image, sizes of one image don't change
+		ETag:      fmt.Sprintf(`"%s-%s"`, path.Base(poster.Key), size),
+		UpdatedAt: poster.UpdatedAt,
+		MaxAge:    s.cfg.MaxAge,
+		Data:      data,
+	}, nil
+}
+
+// Delete removes the poster of the movie, the movie may already be deleted.
+func (s *Posters) Delete(ctx context.Context, movieID int64) error {
+	poster, err := s.Get(ctx, movieID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, movieID); err != nil {
+		return err
+	}
+
+	s.DeleteImages(ctx, poster)
+
+	return nil
+}
+
+// DeleteImages removes the stored images of the poster, failures only leave unused files
+// behind and are logged.
+func (s *Posters) DeleteImages(ctx context.Context, poster domain.Poster) {
+	for _, size := range append([]string{domain.PosterOriginal}, poster.Sizes...) {
+		if err := s.storage.Delete(ctx, poster.Key+"/"+size); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "Posters.DeleteImages",
+				"key":    poster.Key + "/" + size,
+			}).Error(err)
+		}
+	}
+}
+
+func (s *Posters) checkMovie(ctx context.Context, movieID int64) error {
+	_, err := s.movies.GetMovieByID(ctx, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrBookNotFound
+	}
+
+	return err
+}
+
+func withURLs(poster domain.Poster) domain.Poster {
+	poster.URLs = make(map[string]string, len(poster.Sizes)+1)
+	for _, size := range append([]string{domain.PosterOriginal}, poster.Sizes...) {
+		poster.URLs[size] = fmt.Sprintf("/movies/%d/poster?size=%s", poster.MovieID, size)
+	}
+
+	return poster
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/blob"
+)
+
+type memoryPosters struct {
+	mu      sync.Mutex
+	posters map[int64]domain.Poster
+}
+
+func (r *memoryPosters) Get(ctx context.Context, movieID int64) (domain.Poster, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	poster, ok := r.posters[movieID]
+	if !ok {
+		return domain.Poster{}, sql.ErrNoRows
+	}
+
+	return poster, nil
+}
+
+func (r *memoryPosters) Set(ctx context.Context, poster domain.Poster) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.posters == nil {
+		r.posters = make(map[int64]domain.Poster)
+	}
+	r.posters[poster.MovieID] = poster
+
+	return nil
+}
+
+func (r *memoryPosters) Delete(ctx context.Context, movieID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.posters, movieID)
+
+	return nil
+}
+
+// pngImage returns a PNG of the size filled with the color.
+func pngImage(t *testing.T, width, height int, c color.Color) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func newPostersEnv(t *testing.T) (*Posters, *blob.Local) {
+	t.Helper()
+
+	storage := blob.NewLocal(t.TempDir())
+	posters := NewPosters(&memoryPosters{}, memoryMovies{1: {ID: 1, Title: "Heat"}}, storage, PostersConfig{
+		MaxSize:    1 << 20,
+		Sizes:      map[string]int{"small": 10},
+		MaxUploads: 1,
+	})
+
+	return posters, storage
+}
+
+func TestUploadPoster(t *testing.T) {
+	ctx := context.Background()
+	posters, storage := newPostersEnv(t)
+
+	first, err := posters.Upload(ctx, 1, bytes.NewReader(pngImage(t, 40, 60, color.White)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.ContentType != "image/png" || first.Width != 40 || first.Height != 60 || len(first.Sizes) != 1 || first.Sizes[0] != "small" {
+		t.Fatalf("Upload() = %+v, want a 40x60 PNG with the small size", first)
+	}
+
+	small, err := posters.Image(ctx, 1, "small")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(small.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if small.ContentType != "image/jpeg" || format != "jpeg" || config.Width != 10 || config.Height != 15 {
+		t.Errorf("small image is a %dx%d %s served as %s, want a 10x15 JPEG", config.Width, config.Height, format, small.ContentType)
+	}
+
+	if _, err := posters.Image(ctx, 1, "large"); !errors.Is(err, domain.ErrPosterNotFound) {
+		t.Errorf("Image() of an unknown size error = %v, want %v", err, domain.ErrPosterNotFound)
+	}
+
+	second, err := posters.Upload(ctx, 1, bytes.NewReader(pngImage(t, 40, 60, color.Black)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.Key == first.Key {
+		t.Fatal("another image kept the key of the previous poster")
+	}
+
+	if _, err := storage.Get(ctx, first.Key+"/"+domain.PosterOriginal); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("images of the replaced poster weren't deleted: %v", err)
+	}
+
+	if err := posters.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := storage.Get(ctx, second.Key+"/small"); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("images of the deleted poster weren't deleted: %v", err)
+	}
+
+	if _, err := posters.Get(ctx, 1); !errors.Is(err, domain.ErrPosterNotFound) {
+		t.Errorf("Get() of a deleted poster error = %v, want %v", err, domain.ErrPosterNotFound)
+	}
+}
+
+func TestUploadPosterRejected(t *testing.T) {
+	ctx := context.Background()
+	posters, _ := newPostersEnv(t)
+
+	tests := []struct {
+		name    string
+		movieID int64
+		data    []byte
+		err     error
+	}{
+		{"unknown movie", 2, pngImage(t, 4, 4, color.White), domain.ErrBookNotFound},
+		{"not an image", 1, []byte("plain text"), domain.ErrPosterType},
+		{"too large", 1, make([]byte, 1<<20+1), domain.ErrPosterTooLarge},
+		{"too wide", 1, pngImage(t, domain.PosterMaxWidth+1, 1, color.White), domain.ErrPosterSize},
+	}
+
+	for _, tt := range tests {
+		if _, err := posters.Upload(ctx, tt.movieID, bytes.NewReader(tt.data)); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Upload() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMergeDeletesDroppedPoster(t *testing.T) {
+	ctx := context.Background()
+	posters, storage := newPostersEnv(t)
+
+	poster, err := posters.Upload(ctx, 1, bytes.NewReader(pngImage(t, 4, 4, color.White)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newDuplicatesRepo()
+	repo.dropped = poster
+
+	if _, err := NewDuplicates(repo, posters).Merge(ctx, 2, domain.MergeMovieInput{Into: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []string{domain.PosterOriginal, "small"} {
+		if _, err := storage.Get(ctx, poster.Key+"/"+size); !errors.Is(err, blob.ErrNotFound) {
+			t.Errorf("%s image of the merged movie wasn't deleted: %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	audit "github.com/BalamutDiana/crud_audit/pkg/domain"
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/mail"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *Users) Profile(ctx context.Context, userID int64) (domain.Profile, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.Profile{}, err
+	}
+
+	return user.Profile(), nil
+}
+
+// UpdateProfile changes the name right away. A new email is stored as
+// pending and replaces the current one once confirmed with VerifyEmail.
+func (s *Users) UpdateProfile(ctx context.Context, userID int64, inp domain.UpdateProfileInput) (domain.Profile, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.Profile{}, err
+	}
+
+	if inp.Email != nil && *inp.Email != user.Email {
+		exist, err := s.repo.CheckUserExist(ctx, *inp.Email)
+		if err != nil {
+			return domain.Profile{}, err
+		}
+
+		if exist {
+			return domain.Profile{}, domain.ErrUserAlreadyExists
+		}
+	}
+
+	if inp.Name != nil && *inp.Name != user.Name {
+		if err := s.repo.UpdateName(ctx, userID, *inp.Name); err != nil {
+			return domain.Profile{}, err
+		}
+	}
+
+	if inp.Email != nil && *inp.Email != user.Email {
+		if err := s.requestEmailChange(ctx, user, *inp.Email); err != nil {
+			return domain.Profile{}, err
+		}
+	}
+
+	s.sendAuditEvent(ctx, "Users.UpdateProfile", audit.ACTION_UPDATE, userID)
+
+	return s.Profile(ctx, userID)
+}
+
+// DeleteAccount erases the user after checking the password: personal data
+// is deleted, shared data is unlinked from the user and all issued tokens
+// are revoked.
+func (s *Users) DeleteAccount(ctx context.Context, userID int64, inp domain.DeleteAccountInput) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	password, err := s.hasher.Hash(inp.Password)
+	if err != nil {
+		return err
+	}
+
+	if password != user.Password {
+		return domain.ErrWrongPassword
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.DeleteAccount", domain.AuditActionErase, userID)
+
+	return nil
+}
+
+func (s *Users) requestEmailChange(ctx context.Context, user domain.User, email string) error {
+	if err := s.repo.SetPendingEmail(ctx, user.ID, email); err != nil {
+		return err
+	}
+
+	if err := s.userTokens.DeleteByUser(ctx, user.ID, domain.UserTokenChangeEmail); err != nil {
+		return err
+	}
+
+	token, err := s.issueUserToken(ctx, user.ID, domain.UserTokenChangeEmail, s.links.VerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	link, err := withToken(s.links.VerifyURL, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your new email by following the link below:\n%s\n\nThe link expires in %s.\n",
+			user.Name, link, s.links.VerifyTTL),
+	}); err != nil {
+		return err
+	}
+
+	// the current address is told about the change in case the account was
+	// taken over
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your email is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nsomeone asked to change the email of your account to %s. "+
+			"If it wasn't you, change your password right away.\n", user.Name, email),
+	}); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "Users.UpdateProfile",
+		}).Error("failed to send email change notice:", err)
+	}
+
+	return nil
+}
+
+func (s *Users) confirmEmailChange(ctx context.Context, plainToken string) error {
+	token, err := s.consumeUserToken(ctx, domain.UserTokenChangeEmail, plainToken)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.getUser(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+
+	if user.PendingEmail == nil {
+		return domain.ErrInvalidUserToken
+	}
+
+	// the email could have been taken since the change was requested
+	exist, err := s.repo.CheckUserExist(ctx, *user.PendingEmail)
+	if err != nil {
+		return err
+	}
+
+	if exist {
+		return domain.ErrUserAlreadyExists
+	}
+
+	if err := s.repo.ConfirmPendingEmail(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.VerifyEmail", domain.AuditActionEmailChange, user.ID)
+
+	return nil
+}
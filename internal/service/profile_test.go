@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (r *memoryUsers) UpdateName(ctx context.Context, id int64, name string) error {
+	return r.update(id, func(u *domain.User) { u.Name = name })
+}
+
+func (r *memoryUsers) SetPendingEmail(ctx context.Context, id int64, email string) error {
+	return r.update(id, func(u *domain.User) { u.PendingEmail = &email })
+}
+
+func (r *memoryUsers) ConfirmPendingEmail(ctx context.Context, id int64) error {
+	return r.update(id, func(u *domain.User) {
+		u.Email = *u.PendingEmail
+		u.PendingEmail = nil
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.signedUpUser(t)
+
+	name, email := "Annie", "annie@example.com"
+
+	profile, err := env.users.UpdateProfile(ctx, 1, domain.UpdateProfileInput{Name: &name, Email: &email})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new email has to be confirmed first
+	if profile.Name != name || profile.Email != "ann@example.com" || profile.PendingEmail == nil || *profile.PendingEmail != email {
+		t.Errorf("UpdateProfile() = %+v, want the new name and a pending email", profile)
+	}
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: env.mailer.lastToken(t, email)}); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err = env.users.Profile(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Email != email || profile.PendingEmail != nil {
+		t.Errorf("Profile() after confirmation = %+v, want email %s", profile, email)
+	}
+}
+
+func TestUpdateProfileTakenEmail(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.signedUpUser(t)
+
+	if err := env.repo.Create(ctx, domain.User{Name: "Bob", Email: "bob@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	email := "bob@example.com"
+	if _, err := env.users.UpdateProfile(ctx, 1, domain.UpdateProfileInput{Email: &email}); !errors.Is(err, domain.ErrUserAlreadyExists) {
+		t.Errorf("UpdateProfile() with a taken email error = %v, want %v", err, domain.ErrUserAlreadyExists)
+	}
+}
+
+func TestConfirmEmailTakenMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.signedUpUser(t)
+
+	email := "bob@example.com"
+	if _, err := env.users.UpdateProfile(ctx, 1, domain.UpdateProfileInput{Email: &email}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.repo.Create(ctx, domain.User{Name: "Bob", Email: email}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: env.mailer.lastToken(t, email)}); !errors.Is(err, domain.ErrUserAlreadyExists) {
+		t.Errorf("VerifyEmail() of a taken email error = %v, want %v", err, domain.ErrUserAlreadyExists)
+	}
+
+	if user, _ := env.repo.GetByID(ctx, 1); user.Email != "ann@example.com" {
+		t.Errorf("email = %s, want it unchanged", user.Email)
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type ReleasesRepository interface {
+	SetReleases(ctx context.Context, id int64, releases []domain.CountryRelease) error
+}
+
+// Releases manages the release dates of movies in the countries.
+type Releases struct {
+	repo   ReleasesRepository
+	movies MoviesGetter
+}
+
+func NewReleases(repo ReleasesRepository, movies MoviesGetter) *Releases {
+	return &Releases{
+		repo:   repo,
+		movies: movies,
+	}
+}
+
+// Set replaces the country releases of the movie and returns the movie.
+func (s *Releases) Set(ctx context.Context, movieID int64, inp domain.CountryReleasesInput) (domain.Movie, error) {
+	if _, err := s.movies.GetMovieByID(ctx, movieID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Movie{}, domain.ErrBookNotFound
+		}
+
+		return domain.Movie{}, err
+	}
+
+	if err := s.repo.SetReleases(ctx, movieID, inp.Releases); err != nil {
+		return domain.Movie{}, err
+	}
+
+	return s.movies.GetMovieByID(ctx, movieID)
+}
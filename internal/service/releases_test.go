@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryReleases map[int64][]domain.CountryRelease
+
+func (r memoryReleases) SetReleases(ctx context.Context, id int64, releases []domain.CountryRelease) error {
+	r[id] = releases
+
+	return nil
+}
+
+func TestSetReleases(t *testing.T) {
+	ctx := context.Background()
+	repo := memoryReleases{}
+	releases := NewReleases(repo, memoryMovies{1: {ID: 1, Title: "Inception"}})
+
+	date, err := domain.ParseReleaseDate("2010-07-16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inp := domain.CountryReleasesInput{Releases: []domain.CountryRelease{{Country: "US", Date: date}}}
+
+	if _, err := releases.Set(ctx, 2, inp); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("Set() of an unknown movie error = %v, want %v", err, domain.ErrBookNotFound)
+	}
+
+	if _, ok := repo[2]; ok {
+		t.Error("releases of an unknown movie were stored")
+	}
+
+	movie, err := releases.Set(ctx, 1, inp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if movie.ID != 1 || len(repo[1]) != 1 || repo[1][0].Country != "US" {
+		t.Errorf("Set() = %+v, stored %+v, want the US release of movie 1", movie, repo[1])
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type RevocationsRepository interface {
+	RevokeToken(ctx context.Context, token domain.RevokedToken) error
+	RevokeUser(ctx context.Context, revocation domain.UserRevocation) error
+	ListTokens(ctx context.Context, at time.Time) ([]domain.RevokedToken, error)
+	ListUsers(ctx context.Context, after time.Time) ([]domain.UserRevocation, error)
+	DeleteExpired(ctx context.Context, tokensBefore, usersBefore time.Time) error
+}
+
+// Revocations is an in-memory denylist of access tokens backed by the
+// database. It is consulted on every authenticated request, so lookups never
+// hit the database; the list is periodically reloaded to pick up revocations
+// made by other instances.
+type Revocations struct {
+	mu     sync.RWMutex
+	repo   RevocationsRepository
+	tokens map[string]time.Time
+	users  map[int64]time.Time
+
+	// maxTokenAge is the longest time an access token may be accepted, after
+	// which a user revocation no longer matters.
+	maxTokenAge time.Duration
+}
+
+func NewRevocations(ctx context.Context, repo RevocationsRepository, maxTokenAge time.Duration) (*Revocations, error) {
+	r := &Revocations{
+		repo:        repo,
+		maxTokenAge: maxTokenAge,
+	}
+
+	if err := r.reload(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// RevokeToken rejects a single access token until it expires.
+func (r *Revocations) RevokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	if err := r.repo.RevokeToken(ctx, domain.RevokedToken{
+		ID:        jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.tokens[jti] = expiresAt
+	r.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser rejects all access tokens of the user issued until now.
+func (r *Revocations) RevokeUser(ctx context.Context, userID int64) error {
+	// the database keeps microseconds, the same as iat of issued tokens
+	now := time.Now().Round(time.Microsecond)
+
+	if err := r.repo.RevokeUser(ctx, domain.UserRevocation{
+		UserID:    userID,
+		RevokedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.users[userID] = now
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *Revocations) IsRevoked(jti string, userID int64, issuedAt time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.tokens[jti]; ok {
+		return true
+	}
+
+	revokedAt, ok := r.users[userID]
+	if !ok {
+		return false
+	}
+
+	return !issuedAt.After(revokedAt)
+}
+
+// Run periodically reloads the denylist and deletes expired entries until ctx
+// is done.
+func (r *Revocations) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := r.repo.DeleteExpired(ctx, now, now.Add(-r.maxTokenAge)); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"method": "Revocations.Run",
+				}).Error("failed to delete expired revocations:", err)
+			}
+
+			if err := r.reload(ctx); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"method": "Revocations.Run",
+				}).Error("failed to reload revocations:", err)
+			}
+		}
+	}
+}
+
+func (r *Revocations) reload(ctx context.Context) error {
+	now := time.Now()
+
+	tokens, err := r.repo.ListTokens(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	users, err := r.repo.ListUsers(ctx, now.Add(-r.maxTokenAge))
+	if err != nil {
+		return err
+	}
+
+	tokensMap := make(map[string]time.Time, len(tokens))
+	for _, t := range tokens {
+		tokensMap[t.ID] = t.ExpiresAt
+	}
+
+	usersMap := make(map[int64]time.Time, len(users))
+	for _, u := range users {
+		usersMap[u.UserID] = u.RevokedAt
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// keep local revocations that may have been made while loading
+	for jti, expiresAt := range r.tokens {
+		if _, ok := tokensMap[jti]; !ok && expiresAt.After(now) {
+			tokensMap[jti] = expiresAt
+		}
+	}
+
+	for userID, revokedAt := range r.users {
+		if cur, ok := usersMap[userID]; (!ok || cur.Before(revokedAt)) && revokedAt.After(now.Add(-r.maxTokenAge)) {
+			usersMap[userID] = revokedAt
+		}
+	}
+
+	r.tokens = tokensMap
+	r.users = usersMap
+
+	return nil
+}
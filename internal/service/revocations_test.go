@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/BalamutDiana/crud_audit/pkg/domain"
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/keyring"
+)
+
+type memoryRevocationsRepo struct {
+	mu     sync.Mutex
+	tokens []domain.RevokedToken
+	users  []domain.UserRevocation
+}
+
+func (r *memoryRevocationsRepo) RevokeToken(ctx context.Context, token domain.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = append(r.tokens, token)
+
+	return nil
+}
+
+func (r *memoryRevocationsRepo) RevokeUser(ctx context.Context, revocation domain.UserRevocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users = append(r.users, revocation)
+
+	return nil
+}
+
+func (r *memoryRevocationsRepo) ListTokens(ctx context.Context, at time.Time) ([]domain.RevokedToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens := make([]domain.RevokedToken, 0)
+	for _, t := range r.tokens {
+		if t.ExpiresAt.After(at) {
+			tokens = append(tokens, t)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (r *memoryRevocationsRepo) ListUsers(ctx context.Context, after time.Time) ([]domain.UserRevocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make([]domain.UserRevocation, 0)
+	for _, u := range r.users {
+		if u.RevokedAt.After(after) {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+func (r *memoryRevocationsRepo) DeleteExpired(ctx context.Context, tokensBefore, usersBefore time.Time) error {
+	return nil
+}
+
+type memorySessions struct {
+	SessionsRepository
+
+	mu       sync.Mutex
+	sessions []domain.RefreshSession
+}
+
+func (r *memorySessions) Create(ctx context.Context, token domain.RefreshSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions = append(r.sessions, token)
+
+	return nil
+}
+
+func (r *memorySessions) Get(ctx context.Context, token string) (domain.RefreshSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.Token == token {
+			return session, nil
+		}
+	}
+
+	return domain.RefreshSession{}, sql.ErrNoRows
+}
+
+func (r *memorySessions) Delete(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.sessions[:0]
+	for _, session := range r.sessions {
+		if session.UserID != userID {
+			kept = append(kept, session)
+		}
+	}
+	r.sessions = kept
+
+	return nil
+}
+
+func (r *memorySessions) DeleteToken(ctx context.Context, userID int64, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.sessions[:0]
+	for _, session := range r.sessions {
+		if session.UserID != userID || session.Token != token {
+			kept = append(kept, session)
+		}
+	}
+	r.sessions = kept
+
+	return nil
+}
+
+type discardAudit struct{}
+
+func (discardAudit) SendLogRequest(ctx context.Context, req audit.LogItem) error {
+	return nil
+}
+
+// newTokenUsers returns a service that can only issue, parse and revoke
+// tokens.
+func newTokenUsers(t *testing.T, revocations RevocationStore, sessions SessionsRepository) *Users {
+	t.Helper()
+
+	keys, err := keyring.New(keyring.Options{Algorithm: "EdDSA", RotateEvery: time.Hour, Retain: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Users{
+		sessionsRepo: sessions,
+		revocations:  revocations,
+		auditClient:  discardAudit{},
+		signer:       keys,
+		tokens: TokenConfig{
+			AccessTTL:  time.Minute,
+			RefreshTTL: time.Hour,
+			Issuer:     "movies",
+			Audience:   "movies",
+			Leeway:     time.Second,
+		},
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	ctx := context.Background()
+
+	revocations, err := NewRevocations(ctx, &memoryRevocationsRepo{}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := newTokenUsers(t, revocations, &memorySessions{})
+
+	before, _, err := users.generateTokens(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// most likely in the same second as the token was issued
+	if err := revocations.RevokeUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.ParseToken(ctx, before); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("ParseToken() of a token issued before the revocation error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+
+	// iat has a microsecond resolution
+	time.Sleep(time.Millisecond)
+
+	after, _, err := users.generateTokens(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, err := users.ParseToken(ctx, after); err != nil || id != 1 {
+		t.Errorf("ParseToken() of a token issued after the revocation = %d, %v, want 1", id, err)
+	}
+}
+
+func TestRevocationsReload(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryRevocationsRepo{}
+
+	first, err := NewRevocations(ctx, repo, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := newTokenUsers(t, first, &memorySessions{})
+
+	token, _, err := users.generateTokens(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the revocation is made by another instance
+	second, err := NewRevocations(ctx, repo, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := second.RevokeUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.ParseToken(ctx, token); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("ParseToken() after reload error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+
+	revocations, err := NewRevocations(ctx, &memoryRevocationsRepo{}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := &memorySessions{}
+	users := newTokenUsers(t, revocations, sessions)
+
+	laptopAccess, laptopRefresh, err := users.generateTokens(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phoneAccess, phoneRefresh, err := users.generateTokens(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.Logout(ctx, laptopAccess, laptopRefresh); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.ParseToken(ctx, laptopAccess); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("ParseToken() after logout error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+
+	if _, err := sessions.Get(ctx, laptopRefresh); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("refresh session of the logged out device wasn't deleted: %v", err)
+	}
+
+	if _, err := users.ParseToken(ctx, phoneAccess); err != nil {
+		t.Errorf("ParseToken() of another device error = %v", err)
+	}
+
+	if _, err := sessions.Get(ctx, phoneRefresh); err != nil {
+		t.Errorf("refresh session of another device was deleted: %v", err)
+	}
+}
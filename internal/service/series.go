@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type SeriesRepository interface {
+	ListSeasons(ctx context.Context, movieID, userID int64) ([]domain.Season, error)
+	GetSeason(ctx context.Context, movieID int64, number int) (domain.Season, error)
+	CreateSeason(ctx context.Context, movieID int64, inp domain.SeasonInput) (domain.Season, error)
+	UpdateSeason(ctx context.Context, id int64, inp domain.SeasonInput) error
+	DeleteSeason(ctx context.Context, id int64) error
+	GetEpisode(ctx context.Context, seasonID int64, number int) (domain.Episode, error)
+	CreateEpisode(ctx context.Context, season domain.Season, inp domain.EpisodeInput) (domain.Episode, error)
+	UpdateEpisode(ctx context.Context, id int64, inp domain.EpisodeInput) error
+	DeleteEpisode(ctx context.Context, id int64) error
+	SetWatched(ctx context.Context, userID, episodeID int64, watchedAt time.Time) error
+	UnsetWatched(ctx context.Context, userID, episodeID int64) error
+	NextEpisode(ctx context.Context, movieID, userID int64) (domain.Episode, error)
+}
+
+// Series manages seasons and episodes of the movies of the series type and
+// the watched episodes of users. Seasons and episodes are addressed by their
+// numbers.
+type Series struct {
+	repo   SeriesRepository
+	movies MoviesGetter
+}
+
+func NewSeries(repo SeriesRepository, movies MoviesGetter) *Series {
+	return &Series{
+		repo:   repo,
+		movies: movies,
+	}
+}
+
+func (s *Series) Get(ctx context.Context, userID, id int64) (domain.Series, error) {
+	movie, err := s.getSeries(ctx, id)
+	if err != nil {
+		return domain.Series{}, err
+	}
+
+	seasons, err := s.repo.ListSeasons(ctx, id, userID)
+	if err != nil {
+		return domain.Series{}, err
+	}
+
+	return domain.Series{Movie: movie, Seasons: seasons}, nil
+}
+
+func (s *Series) AddSeason(ctx context.Context, id int64, inp domain.SeasonInput) (domain.Season, error) {
+	if _, err := s.getSeries(ctx, id); err != nil {
+		return domain.Season{}, err
+	}
+
+	if err := s.checkSeasonFree(ctx, id, inp.Number); err != nil {
+		return domain.Season{}, err
+	}
+
+	return s.repo.CreateSeason(ctx, id, inp)
+}
+
+func (s *Series) UpdateSeason(ctx context.Context, id int64, number int, inp domain.SeasonInput) error {
+	season, err := s.getSeason(ctx, id, number)
+	if err != nil {
+		return err
+	}
+
+	if inp.Number != number {
+		if err := s.checkSeasonFree(ctx, id, inp.Number); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.UpdateSeason(ctx, season.ID, inp)
+}
+
+func (s *Series) DeleteSeason(ctx context.Context, id int64, number int) error {
+	season, err := s.getSeason(ctx, id, number)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteSeason(ctx, season.ID)
+}
+
+func (s *Series) AddEpisode(ctx context.Context, id int64, seasonNumber int, inp domain.EpisodeInput) (domain.Episode, error) {
+	season, err := s.getSeason(ctx, id, seasonNumber)
+	if err != nil {
+		return domain.Episode{}, err
+	}
+
+	if err := s.checkEpisodeFree(ctx, season.ID, inp.Number); err != nil {
+		return domain.Episode{}, err
+	}
+
+	return s.repo.CreateEpisode(ctx, season, inp)
+}
+
+func (s *Series) UpdateEpisode(ctx context.Context, id int64, seasonNumber, number int, inp domain.EpisodeInput) error {
+	season, episode, err := s.getEpisode(ctx, id, seasonNumber, number)
+	if err != nil {
+		return err
+	}
+
+	if inp.Number != number {
+		if err := s.checkEpisodeFree(ctx, season.ID, inp.Number); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.UpdateEpisode(ctx, episode.ID, inp)
+}
+
+func (s *Series) DeleteEpisode(ctx context.Context, id int64, seasonNumber, number int) error {
+	_, episode, err := s.getEpisode(ctx, id, seasonNumber, number)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteEpisode(ctx, episode.ID)
+}
+
+// SetEpisodeWatched marks the episode as watched now or clears the mark.
+func (s *Series) SetEpisodeWatched(ctx context.Context, userID, id int64, seasonNumber, number int, watched bool) error {
+	_, episode, err := s.getEpisode(ctx, id, seasonNumber, number)
+	if err != nil {
+		return err
+	}
+
+	if !watched {
+		return s.repo.UnsetWatched(ctx, userID, episode.ID)
+	}
+
+	return s.repo.SetWatched(ctx, userID, episode.ID, time.Now().UTC())
+}
+
+// NextEpisode returns the episode following the last watched one.
+func (s *Series) NextEpisode(ctx context.Context, userID, id int64) (domain.Episode, error) {
+	if _, err := s.getSeries(ctx, id); err != nil {
+		return domain.Episode{}, err
+	}
+
+	episode, err := s.repo.NextEpisode(ctx, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return episode, domain.ErrNoNextEpisode
+	}
+
+	return episode, err
+}
+
+func (s *Series) getSeries(ctx context.Context, id int64) (domain.Movie, error) {
+	movie, err := s.movies.GetMovieByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return movie, domain.ErrSeriesNotFound
+		}
+
+		return movie, err
+	}
+
+	if movie.Type != domain.TitleTypeSeries {
+		return movie, domain.ErrSeriesNotFound
+	}
+
+	return movie, nil
+}
+
+func (s *Series) getSeason(ctx context.Context, id int64, number int) (domain.Season, error) {
+	if _, err := s.getSeries(ctx, id); err != nil {
+		return domain.Season{}, err
+	}
+
+	season, err := s.repo.GetSeason(ctx, id, number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return season, domain.ErrSeasonNotFound
+	}
+
+	return season, err
+}
+
+func (s *Series) getEpisode(ctx context.Context, id int64, seasonNumber, number int) (domain.Season, domain.Episode, error) {
+	season, err := s.getSeason(ctx, id, seasonNumber)
+	if err != nil {
+		return season, domain.Episode{}, err
+	}
+
+	episode, err := s.repo.GetEpisode(ctx, season.ID, number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return season, episode, domain.ErrEpisodeNotFound
+	}
+
+	return season, episode, err
+}
+
+func (s *Series) checkSeasonFree(ctx context.Context, id int64, number int) error {
+	_, err := s.repo.GetSeason(ctx, id, number)
+	switch {
+	case err == nil:
+		return domain.ErrSeasonExists
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	default:
+		return err
+	}
+}
+
+func (s *Series) checkEpisodeFree(ctx context.Context, seasonID int64, number int) error {
+	_, err := s.repo.GetEpisode(ctx, seasonID, number)
+	switch {
+	case err == nil:
+		return domain.ErrEpisodeExists
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	default:
+		return err
+	}
+}
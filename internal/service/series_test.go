@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memorySeries struct {
+	SeriesRepository
+
+	mu       sync.Mutex
+	seasons  []domain.Season
+	episodes map[int64][]domain.Episode
+	watched  map[int64]bool
+}
+
+func (r *memorySeries) GetSeason(ctx context.Context, movieID int64, number int) (domain.Season, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, season := range r.seasons {
+		if season.Number == number {
+			return season, nil
+		}
+	}
+
+	return domain.Season{}, sql.ErrNoRows
+}
+
+func (r *memorySeries) CreateSeason(ctx context.Context, movieID int64, inp domain.SeasonInput) (domain.Season, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	season := domain.Season{ID: int64(len(r.seasons) + 1), Number: inp.Number, Title: inp.Title}
+	r.seasons = append(r.seasons, season)
+
+	return season, nil
+}
+
+func (r *memorySeries) GetEpisode(ctx context.Context, seasonID int64, number int) (domain.Episode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, episode := range r.episodes[seasonID] {
+		if episode.Number == number {
+			return episode, nil
+		}
+	}
+
+	return domain.Episode{}, sql.ErrNoRows
+}
+
+func (r *memorySeries) CreateEpisode(ctx context.Context, season domain.Season, inp domain.EpisodeInput) (domain.Episode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.episodes == nil {
+		r.episodes = make(map[int64][]domain.Episode)
+	}
+
+	episode := domain.Episode{ID: int64(len(r.episodes[season.ID]) + 1), SeasonNumber: season.Number, Number: inp.Number}
+	r.episodes[season.ID] = append(r.episodes[season.ID], episode)
+
+	return episode, nil
+}
+
+func (r *memorySeries) UpdateEpisode(ctx context.Context, id int64, inp domain.EpisodeInput) error {
+	return nil
+}
+
+func (r *memorySeries) SetWatched(ctx context.Context, userID, episodeID int64, watchedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.watched == nil {
+		r.watched = make(map[int64]bool)
+	}
+	r.watched[episodeID] = true
+
+	return nil
+}
+
+// NextEpisode returns the first unwatched episode of the first season.
+func (r *memorySeries) NextEpisode(ctx context.Context, movieID, userID int64) (domain.Episode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, episode := range r.episodes[1] {
+		if !r.watched[episode.ID] {
+			return episode, nil
+		}
+	}
+
+	return domain.Episode{}, sql.ErrNoRows
+}
+
+func TestSeriesNumbers(t *testing.T) {
+	ctx := context.Background()
+	series := NewSeries(&memorySeries{}, memoryMovies{
+		1: {ID: 1, Title: "Dark", Type: domain.TitleTypeSeries},
+		2: {ID: 2, Title: "Heat", Type: domain.TitleTypeMovie},
+	})
+
+	if _, err := series.AddSeason(ctx, 2, domain.SeasonInput{Number: 1}); !errors.Is(err, domain.ErrSeriesNotFound) {
+		t.Errorf("AddSeason() to a movie error = %v, want %v", err, domain.ErrSeriesNotFound)
+	}
+
+	if _, err := series.AddSeason(ctx, 1, domain.SeasonInput{Number: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := series.AddSeason(ctx, 1, domain.SeasonInput{Number: 1}); !errors.Is(err, domain.ErrSeasonExists) {
+		t.Errorf("AddSeason() with a taken number error = %v, want %v", err, domain.ErrSeasonExists)
+	}
+
+	if _, err := series.AddEpisode(ctx, 1, 2, domain.EpisodeInput{Number: 1}); !errors.Is(err, domain.ErrSeasonNotFound) {
+		t.Errorf("AddEpisode() to an unknown season error = %v, want %v", err, domain.ErrSeasonNotFound)
+	}
+
+	for _, number := range []int{1, 2} {
+		if _, err := series.AddEpisode(ctx, 1, 1, domain.EpisodeInput{Number: number}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := series.AddEpisode(ctx, 1, 1, domain.EpisodeInput{Number: 2}); !errors.Is(err, domain.ErrEpisodeExists) {
+		t.Errorf("AddEpisode() with a taken number error = %v, want %v", err, domain.ErrEpisodeExists)
+	}
+
+	if err := series.UpdateEpisode(ctx, 1, 1, 1, domain.EpisodeInput{Number: 2}); !errors.Is(err, domain.ErrEpisodeExists) {
+		t.Errorf("UpdateEpisode() to a taken number error = %v, want %v", err, domain.ErrEpisodeExists)
+	}
+
+	if err := series.UpdateEpisode(ctx, 1, 1, 1, domain.EpisodeInput{Number: 1, Title: "Secrets"}); err != nil {
+		t.Errorf("UpdateEpisode() keeping the number error = %v", err)
+	}
+}
+
+func TestNextEpisode(t *testing.T) {
+	ctx := context.Background()
+	series := NewSeries(&memorySeries{}, memoryMovies{1: {ID: 1, Title: "Dark", Type: domain.TitleTypeSeries}})
+
+	if _, err := series.AddSeason(ctx, 1, domain.SeasonInput{Number: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, number := range []int{1, 2} {
+		if _, err := series.AddEpisode(ctx, 1, 1, domain.EpisodeInput{Number: number}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []int{1, 2} {
+		episode, err := series.NextEpisode(ctx, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if episode.Number != want {
+			t.Fatalf("NextEpisode() = episode %d, want %d", episode.Number, want)
+		}
+
+		if err := series.SetEpisodeWatched(ctx, 1, 1, 1, want, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := series.NextEpisode(ctx, 1, 1); !errors.Is(err, domain.ErrNoNextEpisode) {
+		t.Errorf("NextEpisode() after the last episode error = %v, want %v", err, domain.ErrNoNextEpisode)
+	}
+}
+
+func TestAddLibraryMovieType(t *testing.T) {
+	env := newLibrariesEnv(t)
+
+	err := env.libraries.AddMovie(context.Background(), 1, 1, domain.Movie{Title: "Dark", Type: "show"})
+	if !errors.Is(err, domain.ErrInvalidType) {
+		t.Errorf("AddMovie() with an unknown type error = %v, want %v", err, domain.ErrInvalidType)
+	}
+}
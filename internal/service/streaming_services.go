@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type StreamingServicesRepository interface {
+	List(ctx context.Context) ([]domain.StreamingService, error)
+	GetByID(ctx context.Context, id int64) (domain.StreamingService, error)
+	FindByKey(ctx context.Context, key string) (domain.StreamingService, error)
+	KeysUsed(ctx context.Context, keys []string, exceptID int64) (bool, error)
+	Create(ctx context.Context, inp domain.StreamingServiceInput, keys []string) (int64, error)
+	Update(ctx context.Context, id int64, inp domain.StreamingServiceInput, keys []string) error
+	Delete(ctx context.Context, id int64) error
+	CountUses(ctx context.Context, id int64) (int, error)
+}
+
+// StreamingServices is the catalog of streaming services. Everybody reads
+// it, only admins change it.
+type StreamingServices struct {
+	repo  StreamingServicesRepository
+	users UsersRepository
+}
+
+func NewStreamingServices(repo StreamingServicesRepository, users UsersRepository) *StreamingServices {
+	return &StreamingServices{
+		repo:  repo,
+		users: users,
+	}
+}
+
+func (s *StreamingServices) List(ctx context.Context) ([]domain.StreamingService, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *StreamingServices) Get(ctx context.Context, id int64) (domain.StreamingService, error) {
+	service, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service, domain.ErrStreamingServiceNotFound
+	}
+
+	return service, err
+}
+
+func (s *StreamingServices) Create(ctx context.Context, userID int64, inp domain.StreamingServiceInput) (domain.StreamingService, error) {
+	if err := authorizeAdmin(ctx, s.users, userID); err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	keys, err := s.keys(ctx, inp, 0)
+	if err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	id, err := s.repo.Create(ctx, inp, keys)
+	if err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Update replaces the entry, movies of the service get the new name.
+func (s *StreamingServices) Update(ctx context.Context, userID, id int64, inp domain.StreamingServiceInput) (domain.StreamingService, error) {
+	if err := authorizeAdmin(ctx, s.users, userID); err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	if _, err := s.Get(ctx, id); err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	keys, err := s.keys(ctx, inp, id)
+	if err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	if err := s.repo.Update(ctx, id, inp, keys); err != nil {
+		return domain.StreamingService{}, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Delete removes a service no movie or availability uses.
+func (s *StreamingServices) Delete(ctx context.Context, userID, id int64) error {
+	if err := authorizeAdmin(ctx, s.users, userID); err != nil {
+		return err
+	}
+
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	n, err := s.repo.CountUses(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		return domain.ErrStreamingServiceInUse
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+// NormalizeMovie replaces the streaming service of the movie with its
+// canonical name. Movies without a service are left as they are.
+func (s *StreamingServices) NormalizeMovie(ctx context.Context, movie *domain.Movie) error {
+	movie.StreamingServiceID = nil
+
+	key := domain.StreamingServiceKey(movie.StreamingService)
+	if key == "" {
+		movie.StreamingService = ""
+		return nil
+	}
+
+	service, err := s.repo.FindByKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrUnknownStreamingService
+		}
+
+		return err
+	}
+
+	movie.StreamingService = service.Name
+	movie.StreamingServiceID = &service.ID
+
+	return nil
+}
+
+func (s *StreamingServices) keys(ctx context.Context, inp domain.StreamingServiceInput, id int64) ([]string, error) {
+	keys := inp.Keys()
+
+	used, err := s.repo.KeysUsed(ctx, keys, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if used {
+		return nil, domain.ErrStreamingServiceExists
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryStreamingServices struct {
+	StreamingServicesRepository
+
+	mu       sync.Mutex
+	services []domain.StreamingService
+	keys     map[string]int64
+	uses     map[int64]int
+}
+
+func (r *memoryStreamingServices) GetByID(ctx context.Context, id int64) (domain.StreamingService, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, service := range r.services {
+		if service.ID == id {
+			return service, nil
+		}
+	}
+
+	return domain.StreamingService{}, sql.ErrNoRows
+}
+
+func (r *memoryStreamingServices) FindByKey(ctx context.Context, key string) (domain.StreamingService, error) {
+	r.mu.Lock()
+	id, ok := r.keys[key]
+	r.mu.Unlock()
+
+	if !ok {
+		return domain.StreamingService{}, sql.ErrNoRows
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *memoryStreamingServices) KeysUsed(ctx context.Context, keys []string, exceptID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		if id, ok := r.keys[key]; ok && id != exceptID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *memoryStreamingServices) Create(ctx context.Context, inp domain.StreamingServiceInput, keys []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := int64(len(r.services) + 1)
+	r.services = append(r.services, domain.StreamingService{ID: id, Name: inp.Name, Aliases: inp.Aliases, Regions: inp.Regions})
+
+	if r.keys == nil {
+		r.keys = make(map[string]int64)
+	}
+	for _, key := range keys {
+		r.keys[key] = id
+	}
+
+	return id, nil
+}
+
+func (r *memoryStreamingServices) CountUses(ctx context.Context, id int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.uses[id], nil
+}
+
+func (r *memoryStreamingServices) Delete(ctx context.Context, id int64) error {
+	return nil
+}
+
+// newStreamingEnv returns a catalog with Disney+ (1) and Netflix (2), the
+// user 1 is an admin and the user 2 isn't.
+func newStreamingEnv(t *testing.T) (*StreamingServices, *memoryStreamingServices) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	users := &memoryUsers{}
+	for _, u := range []domain.User{
+		{Name: "Admin", Email: "admin@example.com", Admin: true},
+		{Name: "Ann", Email: "ann@example.com"},
+	} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := &memoryStreamingServices{}
+	services := NewStreamingServices(repo, users)
+
+	for _, inp := range []domain.StreamingServiceInput{
+		{Name: "Disney+", Aliases: []string{"Disney Plus"}},
+		{Name: "Netflix"},
+	} {
+		if _, err := services.Create(ctx, 1, inp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return services, repo
+}
+
+func TestCreateStreamingService(t *testing.T) {
+	ctx := context.Background()
+	services, _ := newStreamingEnv(t)
+
+	if _, err := services.Create(ctx, 2, domain.StreamingServiceInput{Name: "Hulu"}); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Create() by a user error = %v, want %v", err, domain.ErrForbidden)
+	}
+
+	if _, err := services.Create(ctx, 1, domain.StreamingServiceInput{Name: "Max", Aliases: []string{"disney plus"}}); !errors.Is(err, domain.ErrStreamingServiceExists) {
+		t.Errorf("Create() with a used alias error = %v, want %v", err, domain.ErrStreamingServiceExists)
+	}
+}
+
+func TestDeleteStreamingServiceInUse(t *testing.T) {
+	ctx := context.Background()
+	services, repo := newStreamingEnv(t)
+	repo.uses = map[int64]int{1: 3}
+
+	if err := services.Delete(ctx, 1, 1); !errors.Is(err, domain.ErrStreamingServiceInUse) {
+		t.Errorf("Delete() of a used service error = %v, want %v", err, domain.ErrStreamingServiceInUse)
+	}
+
+	if err := services.Delete(ctx, 1, 2); err != nil {
+		t.Errorf("Delete() of an unused service error = %v", err)
+	}
+
+	if err := services.Delete(ctx, 1, 3); !errors.Is(err, domain.ErrStreamingServiceNotFound) {
+		t.Errorf("Delete() of an unknown service error = %v, want %v", err, domain.ErrStreamingServiceNotFound)
+	}
+}
+
+func TestNormalizeMovie(t *testing.T) {
+	services, _ := newStreamingEnv(t)
+
+	tests := []struct {
+		service string
+		name    string
+		id      int64
+		err     error
+	}{
+		{"", "", 0, nil},
+		{"  ", "", 0, nil},
+		{"disney plus", "Disney+", 1, nil},
+		{"NETFLIX", "Netflix", 2, nil},
+		{"Hulu", "", 0, domain.ErrUnknownStreamingService},
+	}
+
+	for _, tt := range tests {
+		staleID := int64(10)
+		movie := domain.Movie{StreamingService: tt.service, StreamingServiceID: &staleID}
+
+		err := services.NormalizeMovie(context.Background(), &movie)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("NormalizeMovie(%q) error = %v, want %v", tt.service, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		var id int64
+		if movie.StreamingServiceID != nil {
+			id = *movie.StreamingServiceID
+		}
+
+		if movie.StreamingService != tt.name || id != tt.id {
+			t.Errorf("NormalizeMovie(%q) = %q (%d), want %q (%d)", tt.service, movie.StreamingService, id, tt.name, tt.id)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type SubscriptionsRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]domain.Subscription, error)
+	Replace(ctx context.Context, userID int64, subscriptions []domain.SubscriptionInput) error
+}
+
+// Subscriptions are the streaming services users pay for.
+type Subscriptions struct {
+	repo     SubscriptionsRepository
+	services StreamingServicesRepository
+}
+
+func NewSubscriptions(repo SubscriptionsRepository, services StreamingServicesRepository) *Subscriptions {
+	return &Subscriptions{
+		repo:     repo,
+		services: services,
+	}
+}
+
+func (s *Subscriptions) List(ctx context.Context, userID int64) ([]domain.Subscription, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Replace sets the subscriptions of the user, every service must operate in
+// the region of the subscription.
+func (s *Subscriptions) Replace(ctx context.Context, userID int64, inp domain.SubscriptionsInput) ([]domain.Subscription, error) {
+	for _, subscription := range inp.Subscriptions {
+		if err := checkServiceRegion(ctx, s.services, subscription.ServiceID, subscription.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Replace(ctx, userID, inp.Subscriptions); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListByUser(ctx, userID)
+}
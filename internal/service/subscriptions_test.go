@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memorySubscriptions struct {
+	mu            sync.Mutex
+	subscriptions map[int64][]domain.SubscriptionInput
+}
+
+func (r *memorySubscriptions) ListByUser(ctx context.Context, userID int64) ([]domain.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var subscriptions []domain.Subscription
+	for _, inp := range r.subscriptions[userID] {
+		subscriptions = append(subscriptions, domain.Subscription{ServiceID: inp.ServiceID, Region: inp.Region})
+	}
+
+	return subscriptions, nil
+}
+
+func (r *memorySubscriptions) Replace(ctx context.Context, userID int64, subscriptions []domain.SubscriptionInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.subscriptions == nil {
+		r.subscriptions = make(map[int64][]domain.SubscriptionInput)
+	}
+	r.subscriptions[userID] = subscriptions
+
+	return nil
+}
+
+func (r *memoryAvailability) Available(ctx context.Context, filter domain.AvailableFilter) ([]domain.AvailableMovie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.available = filter
+
+	return nil, nil
+}
+
+func TestReplaceSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	_, services := newStreamingEnv(t)
+	services.services[1].Regions = []string{"US"}
+
+	repo := &memorySubscriptions{}
+	subscriptions := NewSubscriptions(repo, services)
+
+	first := domain.SubscriptionsInput{Subscriptions: []domain.SubscriptionInput{{ServiceID: 1, Region: "DE"}, {ServiceID: 2, Region: "US"}}}
+	if _, err := subscriptions.Replace(ctx, 1, first); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		inp  domain.SubscriptionInput
+		err  error
+	}{
+		{"service outside its regions", domain.SubscriptionInput{ServiceID: 2, Region: "DE"}, domain.ErrServiceNotInRegion},
+		{"unknown service", domain.SubscriptionInput{ServiceID: 3, Region: "DE"}, domain.ErrUnknownStreamingService},
+	} {
+		inp := domain.SubscriptionsInput{Subscriptions: []domain.SubscriptionInput{{ServiceID: 1, Region: "US"}, tt.inp}}
+		if _, err := subscriptions.Replace(ctx, 1, inp); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Replace() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// rejected replacements keep the subscriptions
+	list, err := subscriptions.List(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 || list[0].Region != "DE" || list[1].Region != "US" {
+		t.Errorf("List() = %+v, want the first subscriptions", list)
+	}
+}
+
+func TestAvailableAt(t *testing.T) {
+	availability, repo := newAvailabilityEnv(t)
+
+	before := time.Now().UTC()
+	if _, err := availability.Available(context.Background(), domain.AvailableFilter{UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.available.At.Before(before) || repo.available.At.Location() != time.UTC {
+		t.Errorf("Available() at %s, want the current UTC time", repo.available.At)
+	}
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := availability.Available(context.Background(), domain.AvailableFilter{UserID: 1, At: at}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !repo.available.At.Equal(at) {
+		t.Errorf("Available() at %s, want %s", repo.available.At, at)
+	}
+}
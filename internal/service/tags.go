@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+const (
+	defaultTagsLimit = 10
+	maxTagsLimit     = 100
+)
+
+type TagsRepository interface {
+	Search(ctx context.Context, prefix string, limit int) ([]domain.Tag, error)
+	GetByID(ctx context.Context, id int64) (domain.Tag, error)
+	GetByName(ctx context.Context, name string) (domain.Tag, error)
+	AddToMovie(ctx context.Context, movieID int64, names []string) error
+	RemoveFromMovie(ctx context.Context, movieID int64, name string) (bool, error)
+	Rename(ctx context.Context, id int64, name string) error
+	Merge(ctx context.Context, id, into int64) error
+}
+
+type GenresRepository interface {
+	List(ctx context.Context) ([]domain.Genre, error)
+	SetForMovie(ctx context.Context, movieID int64, names []string) error
+}
+
+// Tags manages genres and free-form tags of the movies. Names are stored
+// normalized, see domain.NormalizeTag.
+type Tags struct {
+	repo   TagsRepository
+	genres GenresRepository
+	movies MoviesGetter
+	users  UsersRepository
+}
+
+func NewTags(repo TagsRepository, genres GenresRepository, movies MoviesGetter, users UsersRepository) *Tags {
+	return &Tags{
+		repo:   repo,
+		genres: genres,
+		movies: movies,
+		users:  users,
+	}
+}
+
+// Search autocompletes tag names.
+func (s *Tags) Search(ctx context.Context, prefix string, limit int) ([]domain.Tag, error) {
+	if limit <= 0 {
+		limit = defaultTagsLimit
+	}
+	if limit > maxTagsLimit {
+		limit = maxTagsLimit
+	}
+
+	return s.repo.Search(ctx, domain.NormalizeTag(prefix), limit)
+}
+
+func (s *Tags) AddToMovie(ctx context.Context, movieID int64, inp domain.TagsInput) (domain.Movie, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.Movie{}, err
+	}
+
+	if err := s.repo.AddToMovie(ctx, movieID, domain.NormalizeTags(inp.Tags)); err != nil {
+		return domain.Movie{}, err
+	}
+
+	return s.movies.GetMovieByID(ctx, movieID)
+}
+
+func (s *Tags) RemoveFromMovie(ctx context.Context, movieID int64, name string) error {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.RemoveFromMovie(ctx, movieID, domain.NormalizeTag(name))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Rename is allowed to admins only. It fails with domain.ErrTagExists if
+// another tag has the name, such tags are merged with Merge.
+func (s *Tags) Rename(ctx context.Context, userID, id int64, inp domain.RenameTagInput) (domain.Tag, error) {
+	if err := authorizeAdmin(ctx, s.users, userID); err != nil {
+		return domain.Tag{}, err
+	}
+
+	tag, err := s.get(ctx, id)
+	if err != nil {
+		return tag, err
+	}
+
+	name := domain.NormalizeTag(inp.Name)
+
+	existing, err := s.repo.GetByName(ctx, name)
+	switch {
+	case err == nil && existing.ID != id:
+		return tag, domain.ErrTagExists
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return tag, err
+	}
+
+	if err := s.repo.Rename(ctx, id, name); err != nil {
+		return tag, err
+	}
+
+	return s.get(ctx, id)
+}
+
+// Merge moves the movies of the tag to the target tag and deletes the tag.
+// It's allowed to admins only.
+func (s *Tags) Merge(ctx context.Context, userID, id int64, inp domain.MergeTagInput) (domain.Tag, error) {
+	if err := authorizeAdmin(ctx, s.users, userID); err != nil {
+		return domain.Tag{}, err
+	}
+
+	if _, err := s.get(ctx, id); err != nil {
+		return domain.Tag{}, err
+	}
+
+	if _, err := s.get(ctx, inp.Into); err != nil {
+		return domain.Tag{}, err
+	}
+
+	if id != inp.Into {
+		if err := s.repo.Merge(ctx, id, inp.Into); err != nil {
+			return domain.Tag{}, err
+		}
+	}
+
+	return s.get(ctx, inp.Into)
+}
+
+func (s *Tags) Genres(ctx context.Context) ([]domain.Genre, error) {
+	return s.genres.List(ctx)
+}
+
+func (s *Tags) SetGenres(ctx context.Context, movieID int64, inp domain.GenresInput) (domain.Movie, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.Movie{}, err
+	}
+
+	if err := s.genres.SetForMovie(ctx, movieID, domain.NormalizeTags(inp.Genres)); err != nil {
+		return domain.Movie{}, err
+	}
+
+	return s.movies.GetMovieByID(ctx, movieID)
+}
+
+func (s *Tags) get(ctx context.Context, id int64) (domain.Tag, error) {
+	tag, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, domain.ErrTagNotFound
+	}
+
+	return tag, err
+}
+
+func (s *Tags) checkMovie(ctx context.Context, movieID int64) error {
+	_, err := s.movies.GetMovieByID(ctx, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrBookNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type memoryTags struct {
+	TagsRepository
+
+	mu     sync.Mutex
+	tags   []domain.Tag
+	limit  int
+	merged [][2]int64
+}
+
+func (r *memoryTags) Search(ctx context.Context, prefix string, limit int) ([]domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.limit = limit
+
+	return nil, nil
+}
+
+func (r *memoryTags) GetByID(ctx context.Context, id int64) (domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tag := range r.tags {
+		if tag.ID == id {
+			return tag, nil
+		}
+	}
+
+	return domain.Tag{}, sql.ErrNoRows
+}
+
+func (r *memoryTags) GetByName(ctx context.Context, name string) (domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tag := range r.tags {
+		if tag.Name == name {
+			return tag, nil
+		}
+	}
+
+	return domain.Tag{}, sql.ErrNoRows
+}
+
+func (r *memoryTags) Rename(ctx context.Context, id int64, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tags {
+		if r.tags[i].ID == id {
+			r.tags[i].Name = name
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryTags) Merge(ctx context.Context, id, into int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.merged = append(r.merged, [2]int64{id, into})
+
+	return nil
+}
+
+// newTagsEnv returns a service with the admin user 1, the user 2 and the
+// tags "heist" (1) and "noir" (2).
+func newTagsEnv(t *testing.T) (*Tags, *memoryTags) {
+	t.Helper()
+
+	users := &memoryUsers{}
+	for _, u := range []domain.User{
+		{Name: "Admin", Email: "admin@example.com", Admin: true},
+		{Name: "Ann", Email: "ann@example.com"},
+	} {
+		if err := users.Create(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := &memoryTags{tags: []domain.Tag{{ID: 1, Name: "heist"}, {ID: 2, Name: "noir"}}}
+
+	return NewTags(repo, nil, memoryMovies{}, users), repo
+}
+
+func TestSearchTagsLimit(t *testing.T) {
+	tags, repo := newTagsEnv(t)
+
+	for _, tt := range []struct{ limit, want int }{
+		{0, defaultTagsLimit},
+		{-1, defaultTagsLimit},
+		{5, 5},
+		{1000, maxTagsLimit},
+	} {
+		if _, err := tags.Search(context.Background(), "he", tt.limit); err != nil {
+			t.Fatal(err)
+		}
+
+		if repo.limit != tt.want {
+			t.Errorf("Search() with limit %d searched %d tags, want %d", tt.limit, repo.limit, tt.want)
+		}
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	ctx := context.Background()
+	tags, _ := newTagsEnv(t)
+
+	tests := []struct {
+		name   string
+		userID int64
+		id     int64
+		rename string
+		err    error
+	}{
+		{"not an admin", 2, 1, "caper", domain.ErrForbidden},
+		{"unknown tag", 1, 3, "caper", domain.ErrTagNotFound},
+		{"name of another tag", 1, 1, " NOIR ", domain.ErrTagExists},
+		{"own name", 1, 1, "Heist", nil},
+		{"new name", 1, 1, "  Caper  Movie ", nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := tags.Rename(ctx, tt.userID, tt.id, domain.RenameTagInput{Name: tt.rename}); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Rename() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	tag, err := tags.get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tag.Name != "caper movie" {
+		t.Errorf("renamed tag = %q, want the normalized name", tag.Name)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	ctx := context.Background()
+	tags, repo := newTagsEnv(t)
+
+	tests := []struct {
+		name   string
+		userID int64
+		id     int64
+		into   int64
+		err    error
+	}{
+		{"not an admin", 2, 1, 2, domain.ErrForbidden},
+		{"unknown tag", 1, 3, 2, domain.ErrTagNotFound},
+		{"unknown target", 1, 1, 3, domain.ErrTagNotFound},
+		{"into itself", 1, 1, 1, nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := tags.Merge(ctx, tt.userID, tt.id, domain.MergeTagInput{Into: tt.into}); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Merge() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if len(repo.merged) != 0 {
+		t.Fatalf("tags %v were merged by rejected or no-op requests", repo.merged)
+	}
+
+	tag, err := tags.Merge(ctx, 1, 1, domain.MergeTagInput{Into: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tag.ID != 2 || len(repo.merged) != 1 || repo.merged[0] != [2]int64{1, 2} {
+		t.Errorf("Merge() = %+v, merged %v, want tag 1 merged into 2", tag, repo.merged)
+	}
+}
@@ -2,10 +2,11 @@ package service
 
 import (
 	"context"
+	crand "crypto/rand"
 	"database/sql"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math"
 	"strconv"
 	"time"
 
@@ -23,34 +24,105 @@ type PasswordHasher interface {
 type UsersRepository interface {
 	Create(ctx context.Context, user domain.User) error
 	GetByCredentials(ctx context.Context, email, password string) (domain.User, error)
+	GetByID(ctx context.Context, id int64) (domain.User, error)
+	GetByEmail(ctx context.Context, email string) (domain.User, error)
 	CheckUserExist(ctx context.Context, email string) (bool, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	MarkVerified(ctx context.Context, id int64) error
+	SetTOTPSecret(ctx context.Context, id int64, secret string) error
+	EnableTOTP(ctx context.Context, id int64) error
+	DisableTOTP(ctx context.Context, id int64) error
+	UseTOTPStep(ctx context.Context, id int64, step int64) (bool, error)
+	UpdateName(ctx context.Context, id int64, name string) error
+	SetPendingEmail(ctx context.Context, id int64, email string) error
+	ConfirmPendingEmail(ctx context.Context, id int64) error
+	Disable(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64) error
 }
 
 type SessionsRepository interface {
 	Create(ctx context.Context, token domain.RefreshSession) error
 	Get(ctx context.Context, token string) (domain.RefreshSession, error)
+	Delete(ctx context.Context, userID int64) error
+	DeleteToken(ctx context.Context, userID int64, token string) error
+}
+
+// LoginGuard throttles repeated failed sign in attempts.
+type LoginGuard interface {
+	RetryAfter(keys ...string) time.Duration
+	Fail(keys ...string)
+	Reset(keys ...string)
+}
+
+// RevocationStore is a denylist of access tokens.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	RevokeUser(ctx context.Context, userID int64) error
+	IsRevoked(jti string, userID int64, issuedAt time.Time) bool
+}
+
+// TokenSigner signs access tokens and resolves keys to verify them.
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+	Keyfunc(token *jwt.Token) (interface{}, error)
 }
 
 type AuditClient interface {
 	SendLogRequest(ctx context.Context, req audit.LogItem) error
 }
 
-type Users struct {
-	repo         UsersRepository
-	hasher       PasswordHasher
-	sessionsRepo SessionsRepository
-	auditClient  AuditClient
+// TokenConfig controls lifetimes and validation of issued tokens.
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	Issuer     string
+	Audience   string
+	// Leeway is the allowed clock skew when checking exp and iat claims.
+	Leeway time.Duration
+	// MFAChallengeTTL is the lifetime of the token issued after a correct
+	// password when two-factor authentication is enabled.
+	MFAChallengeTTL time.Duration
+	// OIDCStateTTL is how long a sign in through an identity provider may
+	// take.
+	OIDCStateTTL time.Duration
+}
 
-	hmacSecret []byte
+type Users struct {
+	repo          UsersRepository
+	hasher        PasswordHasher
+	sessionsRepo  SessionsRepository
+	auditClient   AuditClient
+	revocations   RevocationStore
+	userTokens    UserTokensRepository
+	recoveryCodes RecoveryCodesRepository
+	mailer        Mailer
+	lockout       LoginGuard
+	identities    IdentitiesRepository
+	providers     map[string]IdentityProvider
+
+	signer TokenSigner
+	tokens TokenConfig
+	links  LinksConfig
 }
 
-func NewUsers(repo UsersRepository, sessionsRepo SessionsRepository, auditClient AuditClient, hasher PasswordHasher, secret []byte) *Users {
+func NewUsers(repo UsersRepository, sessionsRepo SessionsRepository, revocations RevocationStore, userTokens UserTokensRepository,
+	recoveryCodes RecoveryCodesRepository, identities IdentitiesRepository, providers map[string]IdentityProvider, auditClient AuditClient,
+	mailer Mailer, lockout LoginGuard, hasher PasswordHasher, signer TokenSigner, tokens TokenConfig, links LinksConfig) *Users {
 	return &Users{
-		repo:         repo,
-		hasher:       hasher,
-		sessionsRepo: sessionsRepo,
-		auditClient:  auditClient,
-		hmacSecret:   secret,
+		repo:          repo,
+		hasher:        hasher,
+		sessionsRepo:  sessionsRepo,
+		revocations:   revocations,
+		userTokens:    userTokens,
+		recoveryCodes: recoveryCodes,
+		auditClient:   auditClient,
+		mailer:        mailer,
+		lockout:       lockout,
+		identities:    identities,
+		providers:     providers,
+		signer:        signer,
+		tokens:        tokens,
+		links:         links,
 	}
 }
 
@@ -96,24 +168,54 @@ func (s *Users) SignUp(ctx context.Context, inp domain.SignUpInput) error {
 		}).Error("failed to send log request:", err)
 	}
 
+	if err := s.sendVerification(ctx, user); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "Users.SignUp",
+		}).Error("failed to send verification email:", err)
+	}
+
 	return nil
 }
 
-func (s *Users) SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error) {
+func (s *Users) SignIn(ctx context.Context, inp domain.SignInInput, clientIP string) (domain.SignInResult, error) {
+	keys := []string{emailKey(inp.Email), ipKey(clientIP)}
+
+	if wait := s.lockout.RetryAfter(keys...); wait > 0 {
+		return domain.SignInResult{}, &domain.TooManyAttemptsError{RetryAfter: wait}
+	}
+
 	password, err := s.hasher.Hash(inp.Password)
 	if err != nil {
-		return "", "", err
+		return domain.SignInResult{}, err
 	}
 
 	user, err := s.repo.GetByCredentials(ctx, inp.Email, password)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", "", domain.ErrUserNotFound
+			s.signInFailed(ctx, inp.Email, keys...)
+			return domain.SignInResult{}, domain.ErrUserNotFound
 		}
 
-		return "", "", err
+		return domain.SignInResult{}, err
+	}
+
+	if user.VerifiedAt == nil {
+		return domain.SignInResult{}, domain.ErrEmailNotVerified
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.newMFAToken(user.ID)
+		if err != nil {
+			return domain.SignInResult{}, err
+		}
+
+		return domain.SignInResult{MFAToken: mfaToken}, nil
 	}
 
+	// with 2FA the failures are only forgotten once the code is verified,
+	// otherwise signing in again would clear the MFA attempts
+	s.lockout.Reset(emailKey(inp.Email))
+
 	if err := s.auditClient.SendLogRequest(ctx, audit.LogItem{
 		Action:    audit.ACTION_LOGIN,
 		Entity:    audit.ENTITY_USER,
@@ -125,51 +227,187 @@ func (s *Users) SignIn(ctx context.Context, inp domain.SignInInput) (string, str
 		}).Error("failed to send log request:", err)
 	}
 
-	return s.generateTokens(ctx, user.ID)
+	accessToken, refreshToken, err := s.generateTokens(ctx, user.ID)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	return domain.SignInResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// signInFailed records a failed attempt and publishes an audit event for the
+// account the attempt was made against, if it exists.
+func (s *Users) signInFailed(ctx context.Context, email string, keys ...string) {
+	s.lockout.Fail(keys...)
+
+	var userID int64
+	if user, err := s.repo.GetByEmail(ctx, email); err == nil {
+		userID = user.ID
+	}
+
+	s.sendAuditEvent(ctx, "Users.SignIn", domain.AuditActionLoginFailed, userID)
 }
 
 func (s *Users) ParseToken(ctx context.Context, token string) (int64, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	claims, err := s.parseClaims(token, s.tokens.Audience)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.revocations.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt) {
+		return 0, domain.ErrTokenRevoked
+	}
+
+	return claims.UserID, nil
+}
+
+// Logout revokes the access token and the refresh session it was sent with,
+// other sessions of the user are kept.
+func (s *Users) Logout(ctx context.Context, token, refreshToken string) error {
+	claims, err := s.parseClaims(token, s.tokens.Audience)
+	if err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt); err != nil {
+		return err
+	}
+
+	if refreshToken != "" {
+		if err := s.sessionsRepo.DeleteToken(ctx, claims.UserID, refreshToken); err != nil {
+			return err
 		}
+	}
+
+	s.sendAuditEvent(ctx, "Users.Logout", domain.AuditActionLogout, claims.UserID)
+
+	return nil
+}
+
+// Disable blocks the user from signing in and revokes all issued tokens.
+// Only admins can disable users, but not themselves.
+func (s *Users) Disable(ctx context.Context, adminID, userID int64) error {
+	if adminID == userID {
+		return domain.ErrForbidden
+	}
+
+	if err := authorizeAdmin(ctx, s.repo, adminID); err != nil {
+		return err
+	}
+
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Disable(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.sessionsRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.Disable", domain.AuditActionDisable, userID)
 
-		return s.hmacSecret, nil
-	})
+	return nil
+}
+
+type accessClaims struct {
+	ID        string
+	UserID    int64
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+func (s *Users) parseClaims(token, audience string) (accessClaims, error) {
+	parser := jwt.Parser{SkipClaimsValidation: true}
+
+	t, err := parser.Parse(token, s.signer.Keyfunc)
 	if err != nil {
-		return 0, err
+		return accessClaims{}, err
 	}
 
 	if !t.Valid {
-		return 0, errors.New("invalid token")
+		return accessClaims{}, errors.New("invalid token")
 	}
 
 	claims, ok := t.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, errors.New("invalid claims")
+		return accessClaims{}, errors.New("invalid claims")
+	}
+
+	if err := s.verifyClaims(claims, audience); err != nil {
+		return accessClaims{}, err
 	}
 
 	subject, ok := claims["sub"].(string)
 	if !ok {
-		return 0, errors.New("invalid subject")
+		return accessClaims{}, errors.New("invalid subject")
 	}
 
 	id, err := strconv.Atoi(subject)
 	if err != nil {
-		return 0, errors.New("invalid subject")
+		return accessClaims{}, errors.New("invalid subject")
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return accessClaims{}, errors.New("invalid token id")
 	}
 
-	return int64(id), nil
+	// exp is required by verifyClaims, iat is optional
+	exp, _ := claims["exp"].(float64)
+	iat, _ := claims["iat"].(float64)
+
+	return accessClaims{
+		ID:        jti,
+		UserID:    int64(id),
+		IssuedAt:  time.UnixMicro(int64(math.Round(iat * 1e6))),
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
+}
+
+// verifyClaims checks time based claims with the configured leeway and
+// makes sure the token was issued by this service for the given audience.
+func (s *Users) verifyClaims(claims jwt.MapClaims, audience string) error {
+	now := time.Now()
+
+	if !claims.VerifyExpiresAt(now.Add(-s.tokens.Leeway).Unix(), true) {
+		return errors.New("token is expired")
+	}
+
+	if !claims.VerifyIssuedAt(now.Add(s.tokens.Leeway).Unix(), false) {
+		return errors.New("token used before issued")
+	}
+
+	if !claims.VerifyNotBefore(now.Add(s.tokens.Leeway).Unix(), false) {
+		return errors.New("token is not valid yet")
+	}
+
+	if !claims.VerifyIssuer(s.tokens.Issuer, true) {
+		return errors.New("invalid issuer")
+	}
+
+	if !claims.VerifyAudience(audience, true) {
+		return errors.New("invalid audience")
+	}
+
+	return nil
 }
 
 func (s *Users) generateTokens(ctx context.Context, userId int64) (string, string, error) {
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		Subject:   strconv.Itoa(int(userId)),
-		IssuedAt:  time.Now().Unix(),
-		ExpiresAt: time.Now().Add(time.Minute * 15).Unix(),
-	})
+	now := time.Now()
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", "", err
+	}
 
-	accessToken, err := t.SignedString(s.hmacSecret)
+	accessToken, err := s.signer.Sign(s.userClaims(jti, userId, s.tokens.Audience, now, s.tokens.AccessTTL))
 	if err != nil {
 		return "", "", err
 	}
@@ -182,7 +420,7 @@ func (s *Users) generateTokens(ctx context.Context, userId int64) (string, strin
 	if err := s.sessionsRepo.Create(ctx, domain.RefreshSession{
 		UserID:    userId,
 		Token:     refreshToken,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 30),
+		ExpiresAt: now.Add(s.tokens.RefreshTTL),
 	}); err != nil {
 		return "", "", err
 	}
@@ -190,13 +428,53 @@ func (s *Users) generateTokens(ctx context.Context, userId int64) (string, strin
 	return accessToken, refreshToken, nil
 }
 
+// userClaims are the claims of access and MFA challenge tokens. iat keeps
+// microseconds, so a token issued in the same second as a revocation of its
+// user can't outlive it.
+func (s *Users) userClaims(jti string, userID int64, audience string, now time.Time, ttl time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{
+		"jti": jti,
+		"sub": strconv.Itoa(int(userID)),
+		"iss": s.tokens.Issuer,
+		"aud": audience,
+		"iat": float64(now.UnixMicro()) / 1e6,
+		"exp": now.Add(ttl).Unix(),
+	}
+}
+
+func (s *Users) sendAuditEvent(ctx context.Context, method, action string, userID int64) {
+	sendAuditEvent(ctx, s.auditClient, method, action, userID)
+}
+
+// sendAuditEvent logs failures instead of returning them, the audit log
+// must not break user facing flows.
+func sendAuditEvent(ctx context.Context, client AuditClient, method, action string, userID int64) {
+	if err := client.SendLogRequest(ctx, audit.LogItem{
+		Action:    action,
+		Entity:    audit.ENTITY_USER,
+		EntityID:  userID,
+		Timestamp: time.Now(),
+	}); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": method,
+		}).Error("failed to send log request:", err)
+	}
+}
+
 func newRefreshToken() (string, error) {
 	b := make([]byte, 32)
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
 
-	if _, err := r.Read(b); err != nil {
+	return fmt.Sprintf("%x", b), nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := crand.Read(b); err != nil {
 		return "", err
 	}
 
@@ -215,3 +493,21 @@ func (s *Users) RefreshTokens(ctx context.Context, refreshToken string) (string,
 
 	return s.generateTokens(ctx, session.UserID)
 }
+
+// authorizeAdmin returns domain.ErrForbidden unless the user is an admin.
+func authorizeAdmin(ctx context.Context, users UsersRepository, userID int64) error {
+	user, err := users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrForbidden
+		}
+
+		return err
+	}
+
+	if !user.Admin {
+		return domain.ErrForbidden
+	}
+
+	return nil
+}
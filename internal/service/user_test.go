@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/golang-jwt/jwt"
+)
+
+// memoryUsers keeps users in memory, tests implement the methods they use.
+type memoryUsers struct {
+	UsersRepository
+
+	mu       sync.Mutex
+	users    []domain.User
+	disabled []int64
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.ID = int64(len(r.users) + 1)
+	r.users = append(r.users, user)
+
+	return nil
+}
+
+func (r *memoryUsers) GetByID(ctx context.Context, id int64) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+
+	return domain.User{}, sql.ErrNoRows
+}
+
+func (r *memoryUsers) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return domain.User{}, sql.ErrNoRows
+}
+
+func (r *memoryUsers) Disable(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.disabled = append(r.disabled, id)
+
+	return nil
+}
+
+func TestParseTokenClaims(t *testing.T) {
+	revocations, err := NewRevocations(context.Background(), &memoryRevocationsRepo{}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := newTokenUsers(t, revocations, &memorySessions{})
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		claim string
+		value interface{}
+		ok    bool
+	}{
+		{"valid", "", nil, true},
+		{"other issuer", "iss", "someone", false},
+		{"no issuer", "iss", nil, false},
+		{"other audience", "aud", "someone", false},
+		{"no audience", "aud", nil, false},
+		{"expired within the leeway", "exp", now.Unix(), true},
+		{"expired", "exp", now.Add(-2 * time.Second).Unix(), false},
+		{"no expiry", "exp", nil, false},
+		{"issued in the future", "iat", now.Add(time.Minute).Unix(), false},
+		{"not valid yet", "nbf", now.Add(time.Minute).Unix(), false},
+	}
+
+	for _, tt := range tests {
+		claims := jwt.MapClaims{
+			"jti": "token-" + tt.name,
+			"sub": "1",
+			"iss": "movies",
+			"aud": "movies",
+			"iat": now.Unix(),
+			"exp": now.Add(time.Minute).Unix(),
+		}
+
+		if tt.claim != "" {
+			if tt.value == nil {
+				delete(claims, tt.claim)
+			} else {
+				claims[tt.claim] = tt.value
+			}
+		}
+
+		token, err := users.signer.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, err := users.ParseToken(context.Background(), token)
+		if tt.ok && (err != nil || id != 1) {
+			t.Errorf("%s: ParseToken() = %d, %v, want 1", tt.name, id, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: ParseToken() succeeded", tt.name)
+		}
+	}
+}
+
+func TestDisable(t *testing.T) {
+	ctx := context.Background()
+
+	revocations, err := NewRevocations(ctx, &memoryRevocationsRepo{}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := &memorySessions{}
+	users := newTokenUsers(t, revocations, sessions)
+	repo := &memoryUsers{}
+	users.repo = repo
+
+	for _, u := range []domain.User{
+		{Name: "Admin", Email: "admin@example.com", Admin: true},
+		{Name: "Ann", Email: "ann@example.com"},
+	} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	access, refresh, err := users.generateTokens(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name            string
+		adminID, userID int64
+		err             error
+	}{
+		{"not an admin", 2, 1, domain.ErrForbidden},
+		{"unknown admin", 3, 2, domain.ErrForbidden},
+		{"themselves", 1, 1, domain.ErrForbidden},
+		{"unknown user", 1, 3, domain.ErrUserNotFound},
+	} {
+		if err := users.Disable(ctx, tt.adminID, tt.userID); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Disable() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if len(repo.disabled) != 0 {
+		t.Fatalf("users %v were disabled by rejected requests", repo.disabled)
+	}
+
+	if err := users.Disable(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repo.disabled) != 1 || repo.disabled[0] != 2 {
+		t.Errorf("disabled users = %v, want [2]", repo.disabled)
+	}
+
+	if _, err := users.ParseToken(ctx, access); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("ParseToken() of the disabled user error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+
+	if _, err := sessions.Get(ctx, refresh); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("refresh session of the disabled user wasn't deleted: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/mail"
+)
+
+type UserTokensRepository interface {
+	Create(ctx context.Context, token domain.UserToken) error
+	Consume(ctx context.Context, kind, tokenHash string) (domain.UserToken, error)
+	DeleteByUser(ctx context.Context, userID int64, kind string) error
+}
+
+// Mailer delivers emails to users.
+type Mailer interface {
+	Send(ctx context.Context, msg mail.Message) error
+}
+
+// LinksConfig describes the links sent to users by email. The token is
+// appended to the URL as the "token" query parameter.
+type LinksConfig struct {
+	VerifyURL string
+	VerifyTTL time.Duration
+	ResetURL  string
+	ResetTTL  time.Duration
+}
+
+// VerifyEmail marks the owner of the verification token as verified. It
+// also confirms email changes, the same link format is used for both.
+func (s *Users) VerifyEmail(ctx context.Context, inp domain.VerifyEmailInput) error {
+	token, err := s.consumeUserToken(ctx, domain.UserTokenVerifyEmail, inp.Token)
+	if errors.Is(err, domain.ErrInvalidUserToken) {
+		return s.confirmEmailChange(ctx, inp.Token)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.MarkVerified(ctx, token.UserID); err != nil {
+		return err
+	}
+
+	s.sendAuditEvent(ctx, "Users.VerifyEmail", domain.AuditActionVerify, token.UserID)
+
+	return nil
+}
+
+// ResendVerification sends a new verification email, invalidating previous
+// ones. Unknown and already verified emails are silently ignored so the
+// endpoint can't be used to find registered users.
+func (s *Users) ResendVerification(ctx context.Context, inp domain.ResendVerificationInput) error {
+	user, err := s.repo.GetByEmail(ctx, inp.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	if user.VerifiedAt != nil {
+		return nil
+	}
+
+	if err := s.userTokens.DeleteByUser(ctx, user.ID, domain.UserTokenVerifyEmail); err != nil {
+		return err
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+func (s *Users) sendVerification(ctx context.Context, user domain.User) error {
+	token, err := s.issueUserToken(ctx, user.ID, domain.UserTokenVerifyEmail, s.links.VerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	link, err := withToken(s.links.VerifyURL, token)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email by following the link below:\n%s\n\nThe link expires in %s.\n",
+			user.Name, link, s.links.VerifyTTL),
+	})
+}
+
+// issueUserToken creates a single-use token of the given kind and returns
+// its plain text value.
+func (s *Users) issueUserToken(ctx context.Context, userID int64, kind string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+
+	token := fmt.Sprintf("%x", b)
+
+	if err := s.userTokens.Create(ctx, domain.UserToken{
+		UserID:    userID,
+		Kind:      kind,
+		TokenHash: hashUserToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *Users) consumeUserToken(ctx context.Context, kind, token string) (domain.UserToken, error) {
+	t, err := s.userTokens.Consume(ctx, kind, hashUserToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return t, domain.ErrInvalidUserToken
+		}
+
+		return t, err
+	}
+
+	if t.ExpiresAt.Before(time.Now()) {
+		return t, domain.ErrInvalidUserToken
+	}
+
+	return t, nil
+}
+
+func hashUserToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+func withToken(link, token string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/mail"
+)
+
+func (r *memoryUsers) CheckUserExist(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+
+	return err == nil, nil
+}
+
+func (r *memoryUsers) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
+	user, err := r.GetByEmail(ctx, email)
+	if err != nil || user.Password != password {
+		return domain.User{}, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (r *memoryUsers) MarkVerified(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range r.users {
+		if r.users[i].ID == id {
+			r.users[i].VerifiedAt = &now
+		}
+	}
+
+	return nil
+}
+
+type memoryUserTokens struct {
+	mu     sync.Mutex
+	tokens []domain.UserToken
+}
+
+func (r *memoryUserTokens) Create(ctx context.Context, token domain.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = append(r.tokens, token)
+
+	return nil
+}
+
+func (r *memoryUserTokens) Consume(ctx context.Context, kind, tokenHash string) (domain.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.tokens {
+		if t.Kind == kind && t.TokenHash == tokenHash {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return t, nil
+		}
+	}
+
+	return domain.UserToken{}, sql.ErrNoRows
+}
+
+func (r *memoryUserTokens) DeleteByUser(ctx context.Context, userID int64, kind string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.tokens[:0]
+	for _, t := range r.tokens {
+		if t.UserID != userID || t.Kind != kind {
+			kept = append(kept, t)
+		}
+	}
+	r.tokens = kept
+
+	return nil
+}
+
+type memoryMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *memoryMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+var linkToken = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+// lastToken returns the token of the link in the last message sent to the
+// address.
+func (m *memoryMailer) lastToken(t *testing.T, to string) string {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To != to {
+			continue
+		}
+
+		match := linkToken.FindStringSubmatch(m.messages[i].Body)
+		if match == nil {
+			t.Fatalf("message to %s has no link: %q", to, m.messages[i].Body)
+		}
+
+		return match[1]
+	}
+
+	t.Fatalf("no message was sent to %s", to)
+
+	return ""
+}
+
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+type mailEnv struct {
+	users      *Users
+	repo       *memoryUsers
+	userTokens *memoryUserTokens
+	mailer     *memoryMailer
+	sessions   *memorySessions
+}
+
+// newMailEnv returns a service which sends emails with links to
+// http://movies.test.
+func newMailEnv(t *testing.T) *mailEnv {
+	t.Helper()
+
+	env := &mailEnv{
+		repo:       &memoryUsers{},
+		userTokens: &memoryUserTokens{},
+		mailer:     &memoryMailer{},
+		sessions:   &memorySessions{},
+	}
+
+	revocations, err := NewRevocations(context.Background(), &memoryRevocationsRepo{}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.users = newTokenUsers(t, revocations, env.sessions)
+	env.users.repo = env.repo
+	env.users.hasher = plainHasher{}
+	env.users.userTokens = env.userTokens
+	env.users.mailer = env.mailer
+	env.users.lockout = NewLockout(LockoutConfig{EmailThreshold: 5, IPThreshold: 20, BaseDelay: time.Second, MaxDelay: time.Minute, Window: time.Hour})
+	env.users.links = LinksConfig{
+		VerifyURL: "http://movies.test/verify",
+		VerifyTTL: time.Hour,
+		ResetURL:  "http://movies.test/reset",
+		ResetTTL:  time.Hour,
+	}
+
+	return env
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+
+	if err := env.users.SignUp(ctx, domain.SignUpInput{Name: "Ann", Email: "ann@example.com", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := env.repo.GetByEmail(ctx, "ann@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.VerifiedAt != nil {
+		t.Fatal("user is verified right after sign up")
+	}
+
+	token := env.mailer.lastToken(t, "ann@example.com")
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, _ := env.repo.GetByID(ctx, user.ID); user.VerifiedAt == nil {
+		t.Error("user isn't verified after following the link")
+	}
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: token}); !errors.Is(err, domain.ErrInvalidUserToken) {
+		t.Errorf("VerifyEmail() with a used token error = %v, want %v", err, domain.ErrInvalidUserToken)
+	}
+}
+
+func TestVerifyEmailExpired(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+	env.users.links.VerifyTTL = -time.Minute
+
+	if err := env.users.SignUp(ctx, domain.SignUpInput{Name: "Ann", Email: "ann@example.com", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	token := env.mailer.lastToken(t, "ann@example.com")
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: token}); !errors.Is(err, domain.ErrInvalidUserToken) {
+		t.Errorf("VerifyEmail() with an expired token error = %v, want %v", err, domain.ErrInvalidUserToken)
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+
+	if err := env.users.SignUp(ctx, domain.SignUpInput{Name: "Ann", Email: "ann@example.com", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := env.mailer.lastToken(t, "ann@example.com")
+
+	if err := env.users.ResendVerification(ctx, domain.ResendVerificationInput{Email: "ann@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: first}); !errors.Is(err, domain.ErrInvalidUserToken) {
+		t.Errorf("VerifyEmail() with a replaced token error = %v, want %v", err, domain.ErrInvalidUserToken)
+	}
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: env.mailer.lastToken(t, "ann@example.com")}); err != nil {
+		t.Errorf("VerifyEmail() with the new token error = %v", err)
+	}
+
+	sent := len(env.mailer.messages)
+
+	// unknown and verified emails are ignored without telling the client
+	for _, email := range []string{"bob@example.com", "ann@example.com"} {
+		if err := env.users.ResendVerification(ctx, domain.ResendVerificationInput{Email: email}); err != nil {
+			t.Errorf("ResendVerification(%s) error = %v", email, err)
+		}
+	}
+
+	if len(env.mailer.messages) != sent {
+		t.Errorf("%d emails were sent to unknown or verified users", len(env.mailer.messages)-sent)
+	}
+}
+
+func TestSignInUnverified(t *testing.T) {
+	ctx := context.Background()
+	env := newMailEnv(t)
+
+	if err := env.users.SignUp(ctx, domain.SignUpInput{Name: "Ann", Email: "ann@example.com", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	inp := domain.SignInInput{Email: "ann@example.com", Password: "secret"}
+
+	if _, err := env.users.SignIn(ctx, inp, "127.0.0.1"); !errors.Is(err, domain.ErrEmailNotVerified) {
+		t.Fatalf("SignIn() before verification error = %v, want %v", err, domain.ErrEmailNotVerified)
+	}
+
+	if err := env.users.VerifyEmail(ctx, domain.VerifyEmailInput{Token: env.mailer.lastToken(t, "ann@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := env.users.SignIn(ctx, inp, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.AccessToken == "" || res.RefreshToken == "" {
+		t.Errorf("SignIn() = %+v, want access and refresh tokens", res)
+	}
+}
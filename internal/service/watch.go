@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type WatchStatesRepository interface {
+	Get(ctx context.Context, userID, movieID int64) (domain.WatchState, error)
+	ListByUser(ctx context.Context, userID int64) ([]domain.WatchState, error)
+	Set(ctx context.Context, userID, movieID int64, inp domain.UpdateWatchStateInput) error
+	Delete(ctx context.Context, userID, movieID int64) error
+	AddWatch(ctx context.Context, userID, movieID int64, watchedAt time.Time) (domain.WatchEvent, error)
+	DeleteWatch(ctx context.Context, userID, movieID, id int64) (bool, error)
+}
+
+type MoviesGetter interface {
+	GetMovieByID(ctx context.Context, id int64) (domain.Movie, error)
+}
+
+// Watching keeps the private watch status, progress, rating and notes of
+// users for the movies of the shared list.
+type Watching struct {
+	repo   WatchStatesRepository
+	movies MoviesGetter
+}
+
+func NewWatching(repo WatchStatesRepository, movies MoviesGetter) *Watching {
+	return &Watching{
+		repo:   repo,
+		movies: movies,
+	}
+}
+
+// Get returns the state of the movie, a movie the user hasn't touched yet
+// has an empty status.
+func (s *Watching) Get(ctx context.Context, userID, movieID int64) (domain.WatchState, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.WatchState{}, err
+	}
+
+	state, err := s.repo.Get(ctx, userID, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.WatchState{MovieID: movieID}, nil
+	}
+
+	return state, err
+}
+
+func (s *Watching) Update(ctx context.Context, userID, movieID int64, inp domain.UpdateWatchStateInput) (domain.WatchState, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.WatchState{}, err
+	}
+
+	if err := s.repo.Set(ctx, userID, movieID, inp); err != nil {
+		return domain.WatchState{}, err
+	}
+
+	return s.repo.Get(ctx, userID, movieID)
+}
+
+// Reset forgets everything the user recorded about the movie.
+func (s *Watching) Reset(ctx context.Context, userID, movieID int64) error {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, userID, movieID)
+}
+
+// MarkWatched records a (re)watch of the movie, by default at the current
+// time.
+func (s *Watching) MarkWatched(ctx context.Context, userID, movieID int64, inp domain.MarkWatchedInput) (domain.WatchState, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return domain.WatchState{}, err
+	}
+
+	watchedAt := time.Now()
+	if inp.WatchedAt != nil {
+		watchedAt = *inp.WatchedAt
+	}
+
+	if _, err := s.repo.AddWatch(ctx, userID, movieID, watchedAt.UTC()); err != nil {
+		return domain.WatchState{}, err
+	}
+
+	return s.repo.Get(ctx, userID, movieID)
+}
+
+func (s *Watching) DeleteWatch(ctx context.Context, userID, movieID, id int64) error {
+	ok, err := s.repo.DeleteWatch(ctx, userID, movieID, id)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrWatchEventNotFound
+	}
+
+	return nil
+}
+
+func (s *Watching) checkMovie(ctx context.Context, movieID int64) error {
+	_, err := s.movies.GetMovieByID(ctx, movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrBookNotFound
+	}
+
+	return err
+}
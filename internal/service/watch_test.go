@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+// memoryMovies serves the movies of the shared list by id.
+type memoryMovies map[int64]domain.Movie
+
+func (m memoryMovies) GetMovieByID(ctx context.Context, id int64) (domain.Movie, error) {
+	movie, ok := m[id]
+	if !ok {
+		return domain.Movie{}, sql.ErrNoRows
+	}
+
+	return movie, nil
+}
+
+type watchKey struct {
+	userID, movieID int64
+}
+
+type memoryWatchStates struct {
+	WatchStatesRepository
+
+	mu     sync.Mutex
+	states map[watchKey]domain.WatchState
+}
+
+func (r *memoryWatchStates) Get(ctx context.Context, userID, movieID int64) (domain.WatchState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[watchKey{userID, movieID}]
+	if !ok {
+		return domain.WatchState{}, sql.ErrNoRows
+	}
+
+	return state, nil
+}
+
+func (r *memoryWatchStates) AddWatch(ctx context.Context, userID, movieID int64, watchedAt time.Time) (domain.WatchEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.states == nil {
+		r.states = make(map[watchKey]domain.WatchState)
+	}
+
+	key := watchKey{userID, movieID}
+	state := r.states[key]
+	state.MovieID = movieID
+	state.Status = domain.WatchStatusWatched
+
+	event := domain.WatchEvent{ID: int64(len(state.Watches) + 1), WatchedAt: watchedAt}
+	state.Watches = append(state.Watches, event)
+	r.states[key] = state
+
+	return event, nil
+}
+
+func (r *memoryWatchStates) DeleteWatch(ctx context.Context, userID, movieID, id int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := watchKey{userID, movieID}
+	state := r.states[key]
+
+	for i, event := range state.Watches {
+		if event.ID == id {
+			state.Watches = append(state.Watches[:i], state.Watches[i+1:]...)
+			r.states[key] = state
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestWatching(t *testing.T) {
+	ctx := context.Background()
+	watching := NewWatching(&memoryWatchStates{}, memoryMovies{1: {ID: 1, Title: "Heat"}})
+
+	if _, err := watching.Get(ctx, 1, 2); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("Get() of an unknown movie error = %v, want %v", err, domain.ErrBookNotFound)
+	}
+
+	state, err := watching.Get(ctx, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.MovieID != 1 || state.Status != "" {
+		t.Errorf("Get() of an untouched movie = %+v, want an empty status", state)
+	}
+
+	before := time.Now()
+
+	state, err = watching.MarkWatched(ctx, 1, 1, domain.MarkWatchedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Watches) != 1 || state.Watches[0].WatchedAt.Before(before) || state.Watches[0].WatchedAt.Location() != time.UTC {
+		t.Fatalf("MarkWatched() = %+v, want a watch at the current UTC time", state)
+	}
+
+	// watch states are private
+	if state, _ := watching.Get(ctx, 2, 1); len(state.Watches) != 0 {
+		t.Errorf("Get() of another user = %+v, want no watches", state)
+	}
+
+	if err := watching.DeleteWatch(ctx, 2, 1, state.Watches[0].ID); !errors.Is(err, domain.ErrWatchEventNotFound) {
+		t.Errorf("DeleteWatch() of another user error = %v, want %v", err, domain.ErrWatchEventNotFound)
+	}
+
+	if err := watching.DeleteWatch(ctx, 1, 1, state.Watches[0].ID); err != nil {
+		t.Errorf("DeleteWatch() error = %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+// disableUser blocks a user from signing in and revokes their tokens, it is
+// allowed to admins only.
+func (h *Handler) disableUser(w http.ResponseWriter, r *http.Request) {
+	adminID, id, ok := userAndIDFromRequest(w, r, "disableUser")
+	if !ok {
+		return
+	}
+
+	if err := h.usersService.Disable(r.Context(), adminID, id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrForbidden):
+			writeError(w, http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, err)
+		default:
+			logError("disableUser", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
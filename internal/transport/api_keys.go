@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("createAPIKey", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("createAPIKey", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.CreateAPIKeyInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("createAPIKey", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	key, err := h.apiKeysService.Create(r.Context(), userID, inp)
+	if err != nil {
+		logError("createAPIKey", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(key)
+	if err != nil {
+		logError("createAPIKey", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
+
+func (h *Handler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("listAPIKeys", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeysService.List(r.Context(), userID)
+	if err != nil {
+		logError("listAPIKeys", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(keys)
+	if err != nil {
+		logError("listAPIKeys", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("revokeAPIKey", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("revokeAPIKey", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeysService.Revoke(r.Context(), userID, id); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		logError("revokeAPIKey", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -3,12 +3,17 @@ package transport
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
-	"github.com/sirupsen/logrus"
+)
+
+const (
+	refreshCookieName = "refresh-token"
+	refreshCookiePath = "/auth"
 )
 
 func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) {
@@ -63,45 +68,76 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.usersService.SignIn(r.Context(), inp)
+	result, err := h.usersService.SignIn(r.Context(), inp, clientIP(r))
 	if err != nil {
+		var tooMany *domain.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			handleTooManyAttempts(w, tooMany)
+			return
+		}
+
 		if errors.Is(err, domain.ErrUserNotFound) {
 			handleNotFoundError(w, err)
 			return
 		}
 
+		if errors.Is(err, domain.ErrEmailNotVerified) {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+
 		logError("signIn", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	h.writeSignInResult(w, "signIn", result)
+}
+
+// writeSignInResult responds with either the access token or the MFA
+// challenge of a successful first sign in step.
+func (h *Handler) writeSignInResult(w http.ResponseWriter, method string, result domain.SignInResult) {
+	if result.MFAToken != "" {
+		response, err := json.Marshal(map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		if err != nil {
+			logError(method, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.Write(response)
+		return
+	}
+
 	response, err := json.Marshal(map[string]string{
-		"token": accessToken,
+		"token": result.AccessToken,
 	})
 	if err != nil {
-		logError("signIn", err)
+		logError(method, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
+	h.setRefreshCookie(w, result.RefreshToken)
 	w.Header().Add("Content-Type", "application/json")
 	w.Write(response)
 }
 
 func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("refresh-token")
+	cookie, err := r.Cookie(refreshCookieName)
 	if err != nil {
 		logError("refresh", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	logrus.Infof("%s", cookie.Value)
-
 	accessToken, refreshToken, err := h.usersService.RefreshTokens(r.Context(), cookie.Value)
 	if err != nil {
-		logError("signIn", err)
+		logError("refresh", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -110,22 +146,162 @@ func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
 		"token": accessToken,
 	})
 	if err != nil {
-		logError("signIn", err)
+		logError("refresh", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token='%s'; HttpOnly", refreshToken))
+	h.setRefreshCookie(w, refreshToken)
 	w.Header().Add("Content-Type", "application/json")
 	w.Write(response)
 }
 
+func (h *Handler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("verifyEmail", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.VerifyEmailInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("verifyEmail", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("verifyEmail", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.VerifyEmail(r.Context(), inp); err != nil {
+		if errors.Is(err, domain.ErrInvalidUserToken) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUserAlreadyExists) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		logError("verifyEmail", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ResendVerificationInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.ResendVerification(r.Context(), inp); err != nil {
+		logError("resendVerification", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	token, err := getTokenFromRequest(r)
+	if err != nil {
+		logError("logout", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// the cookie is optional, without it only the access token is revoked
+	var refreshToken string
+	if cookie, err := r.Cookie(refreshCookieName); err == nil {
+		refreshToken = cookie.Value
+	}
+
+	if err := h.usersService.Logout(r.Context(), token, refreshToken); err != nil {
+		logError("logout", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) setRefreshCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    token,
+		Path:     refreshCookiePath,
+		MaxAge:   int(h.cookies.MaxAge.Seconds()),
+		Secure:   h.cookies.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (h *Handler) clearRefreshCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+		Secure:   h.cookies.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
 func handleNotFoundError(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusBadRequest, err)
+}
+
+func handleTooManyAttempts(w http.ResponseWriter, err *domain.TooManyAttemptsError) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	writeError(w, http.StatusTooManyRequests, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
 	response, _ := json.Marshal(map[string]string{
 		"error": err.Error(),
 	})
 
 	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+func (h *Handler) jwks(w http.ResponseWriter, r *http.Request) {
+	response, err := json.Marshal(h.keys.JWKS())
+	if err != nil {
+		logError("jwks", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "public, max-age=300")
 	w.Write(response)
 }
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type stubUsers struct {
+	User
+}
+
+func (stubUsers) SignIn(ctx context.Context, inp domain.SignInInput, clientIP string) (domain.SignInResult, error) {
+	return domain.SignInResult{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func (stubUsers) RefreshTokens(ctx context.Context, refreshToken string) (string, string, error) {
+	if refreshToken != "refresh" {
+		return "", "", domain.ErrRefreshTokenExpired
+	}
+
+	return "access", "refreshed", nil
+}
+
+func TestAuthRoutes(t *testing.T) {
+	h := &Handler{usersService: stubUsers{}, cookies: CookieConfig{Secure: true, MaxAge: time.Hour}}
+	router := h.InitRouter()
+
+	tests := []struct {
+		method, path, body string
+		cookie             *http.Cookie
+		status             int
+		refreshCookie      string
+	}{
+		{http.MethodGet, "/auth/sign-in", "", nil, http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/auth/sign-in", `{"email": "ann@example.com", "password": "secret"}`, nil, http.StatusOK, "refresh"},
+		{http.MethodGet, "/auth/refresh", "", &http.Cookie{Name: refreshCookieName, Value: "refresh"}, http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/auth/refresh", "", &http.Cookie{Name: refreshCookieName, Value: "refresh"}, http.StatusOK, "refreshed"},
+		{http.MethodPost, "/auth/refresh", "", nil, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.cookie != nil {
+			r.AddCookie(tt.cookie)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.status)
+			continue
+		}
+
+		if tt.refreshCookie == "" {
+			continue
+		}
+
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Errorf("%s %s: cookies = %v, want the refresh cookie", tt.method, tt.path, cookies)
+			continue
+		}
+
+		c := cookies[0]
+		if c.Name != refreshCookieName || c.Value != tt.refreshCookie || c.Path != refreshCookiePath ||
+			!c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteStrictMode || c.MaxAge != 3600 {
+			t.Errorf("%s %s: cookie = %+v, want a secure HttpOnly strict %q cookie", tt.method, tt.path, c, tt.refreshCookie)
+		}
+	}
+}
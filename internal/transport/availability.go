@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) listAvailability(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("listAvailability", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	availability, err := h.availabilityService.List(r.Context(), id, strings.ToUpper(r.URL.Query().Get("region")))
+	if err != nil {
+		handleAvailabilityError(w, "listAvailability", err)
+		return
+	}
+
+	writeJSON(w, "listAvailability", http.StatusOK, availability)
+}
+
+func (h *Handler) addAvailability(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("addAvailability", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.AvailabilityInput
+	if !readInput(w, r, "addAvailability", &inp) {
+		return
+	}
+
+	availability, err := h.availabilityService.Create(r.Context(), id, inp)
+	if err != nil {
+		handleAvailabilityError(w, "addAvailability", err)
+		return
+	}
+
+	writeJSON(w, "addAvailability", http.StatusCreated, availability)
+}
+
+func (h *Handler) updateAvailability(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("updateAvailability", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	availabilityID, err := getVarFromRequest(r, "availabilityID")
+	if err != nil {
+		logError("updateAvailability", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.AvailabilityInput
+	if !readInput(w, r, "updateAvailability", &inp) {
+		return
+	}
+
+	availability, err := h.availabilityService.Update(r.Context(), id, availabilityID, inp)
+	if err != nil {
+		handleAvailabilityError(w, "updateAvailability", err)
+		return
+	}
+
+	writeJSON(w, "updateAvailability", http.StatusOK, availability)
+}
+
+func (h *Handler) deleteAvailability(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteAvailability", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	availabilityID, err := getVarFromRequest(r, "availabilityID")
+	if err != nil {
+		logError("deleteAvailability", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.availabilityService.Delete(r.Context(), id, availabilityID); err != nil {
+		handleAvailabilityError(w, "deleteAvailability", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// searchAvailability answers questions like "what in my watchlist is on my
+// services in my region": GET /availability?region=DE&services=netflix,2&status=want_to_watch.
+func (h *Handler) searchAvailability(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("searchAvailability", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.AvailabilityFilter{
+		UserID:   userID,
+		Region:   query.Get("region"),
+		Services: splitList(query.Get("services")),
+		Kinds:    splitList(query.Get("kinds")),
+		Status:   query.Get("status"),
+	}
+
+	if err := filter.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	movies, err := h.availabilityService.Search(r.Context(), filter)
+	if err != nil {
+		handleAvailabilityError(w, "searchAvailability", err)
+		return
+	}
+
+	writeJSON(w, "searchAvailability", http.StatusOK, movies)
+}
+
+// splitList splits a comma separated query parameter.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func handleAvailabilityError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound), errors.Is(err, domain.ErrAvailabilityNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrUnknownStreamingService), errors.Is(err, domain.ErrServiceNotInRegion):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
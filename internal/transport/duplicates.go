@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+type duplicateResponse struct {
+	Error      string                      `json:"error"`
+	Candidates []domain.DuplicateCandidate `json:"candidates"`
+}
+
+// MergeMovie doc
+// @Summary     Merge movies
+// @Description Merge a duplicate into another movie with its tags, ratings and history
+// @Accept      json
+// @Produce     json
+// @Param       id    path     string                 true "movie id"
+// @Param       input body     domain.MergeMovieInput true "movie to keep"
+// @Success     200   {object} domain.Movie
+// @Router      /movies/{id}/merge [post]
+func (h *Handler) mergeMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("mergeMovie", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.MergeMovieInput
+	if !readInput(w, r, "mergeMovie", &inp) {
+		return
+	}
+
+	movie, err := h.duplicatesService.Merge(r.Context(), id, inp)
+	if err != nil {
+		handleMergeError(w, "mergeMovie", err)
+		return
+	}
+
+	writeJSON(w, "mergeMovie", http.StatusOK, movie)
+}
+
+func handleMergeError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrMergeSameMovie), errors.Is(err, domain.ErrMergeTypeMismatch):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -2,11 +2,15 @@ package transport
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"net/http"
 
 	_ "github.com/BalamutDiana/crud_movie_manager/docs"
 	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/keyring"
+	"github.com/BalamutDiana/crud_movie_manager/pkg/ratelimit"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -20,55 +24,382 @@ const (
 type Movies interface {
 	Create(ctx context.Context, movie domain.Movie) error
 	GetMovieByID(ctx context.Context, id int64) (domain.Movie, error)
-	List(ctx context.Context) ([]domain.Movie, error)
+	List(ctx context.Context, filter domain.MovieFilter) ([]domain.Movie, error)
 	DeleteMovie(ctx context.Context, id int64) error
 	UpdateMovie(ctx context.Context, id int64, newMovie domain.Movie) error
 }
 
+type Releases interface {
+	Set(ctx context.Context, movieID int64, inp domain.CountryReleasesInput) (domain.Movie, error)
+}
+
 type User interface {
 	SignUp(ctx context.Context, inp domain.SignUpInput) error
-	SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error)
+	SignIn(ctx context.Context, inp domain.SignInInput, clientIP string) (domain.SignInResult, error)
 	ParseToken(ctx context.Context, token string) (int64, error)
 	RefreshTokens(ctx context.Context, refreshToken string) (string, string, error)
+	Logout(ctx context.Context, token, refreshToken string) error
+	Disable(ctx context.Context, adminID, userID int64) error
+	VerifyEmail(ctx context.Context, inp domain.VerifyEmailInput) error
+	ResendVerification(ctx context.Context, inp domain.ResendVerificationInput) error
+	ForgotPassword(ctx context.Context, inp domain.ForgotPasswordInput) error
+	ResetPassword(ctx context.Context, inp domain.ResetPasswordInput) error
+	ChangePassword(ctx context.Context, userID int64, inp domain.ChangePasswordInput) error
+	EnrollTOTP(ctx context.Context, userID int64) (domain.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int64, inp domain.MFACodeInput) ([]string, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, inp domain.MFACodeInput) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int64, inp domain.MFACodeInput) error
+	VerifyMFA(ctx context.Context, inp domain.VerifyMFAInput, clientIP string) (string, string, error)
+	StartOIDC(ctx context.Context, provider string) (domain.OIDCLoginRequest, error)
+	FinishOIDC(ctx context.Context, inp domain.OIDCCallbackInput) (domain.SignInResult, error)
+	Profile(ctx context.Context, userID int64) (domain.Profile, error)
+	UpdateProfile(ctx context.Context, userID int64, inp domain.UpdateProfileInput) (domain.Profile, error)
+	DeleteAccount(ctx context.Context, userID int64, inp domain.DeleteAccountInput) error
+}
+
+type APIKeys interface {
+	Create(ctx context.Context, userID int64, inp domain.CreateAPIKeyInput) (domain.CreatedAPIKey, error)
+	List(ctx context.Context, userID int64) ([]domain.APIKey, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	Authenticate(ctx context.Context, key string) (domain.APIKey, error)
+}
+
+type Libraries interface {
+	Create(ctx context.Context, userID int64, inp domain.CreateLibraryInput) (domain.Library, error)
+	List(ctx context.Context, userID int64) ([]domain.Library, error)
+	Get(ctx context.Context, userID, id int64) (domain.LibraryDetails, error)
+	Rename(ctx context.Context, userID, id int64, inp domain.CreateLibraryInput) error
+	Delete(ctx context.Context, userID, id int64) error
+	Invite(ctx context.Context, userID, id int64, inp domain.InviteMemberInput) error
+	AcceptInvitation(ctx context.Context, userID int64, inp domain.AcceptInvitationInput) (domain.Library, error)
+	UpdateMember(ctx context.Context, userID, id, memberID int64, inp domain.UpdateMemberInput) error
+	RemoveMember(ctx context.Context, userID, id, memberID int64) error
+	ListMovies(ctx context.Context, userID, id int64) ([]domain.Movie, error)
+	GetMovie(ctx context.Context, userID, id, movieID int64) (domain.Movie, error)
+	AddMovie(ctx context.Context, userID, id int64, movie domain.Movie) error
+	UpdateMovie(ctx context.Context, userID, id, movieID int64, movie domain.Movie) error
+	DeleteMovie(ctx context.Context, userID, id, movieID int64) error
+}
+
+type Exports interface {
+	Export(ctx context.Context, userID int64) (domain.DataExport, error)
+}
+
+type Watching interface {
+	Get(ctx context.Context, userID, movieID int64) (domain.WatchState, error)
+	Update(ctx context.Context, userID, movieID int64, inp domain.UpdateWatchStateInput) (domain.WatchState, error)
+	Reset(ctx context.Context, userID, movieID int64) error
+	MarkWatched(ctx context.Context, userID, movieID int64, inp domain.MarkWatchedInput) (domain.WatchState, error)
+	DeleteWatch(ctx context.Context, userID, movieID, id int64) error
+}
+
+type Tags interface {
+	Search(ctx context.Context, prefix string, limit int) ([]domain.Tag, error)
+	AddToMovie(ctx context.Context, movieID int64, inp domain.TagsInput) (domain.Movie, error)
+	RemoveFromMovie(ctx context.Context, movieID int64, name string) error
+	Rename(ctx context.Context, userID, id int64, inp domain.RenameTagInput) (domain.Tag, error)
+	Merge(ctx context.Context, userID, id int64, inp domain.MergeTagInput) (domain.Tag, error)
+	Genres(ctx context.Context) ([]domain.Genre, error)
+	SetGenres(ctx context.Context, movieID int64, inp domain.GenresInput) (domain.Movie, error)
+}
+
+type Series interface {
+	Get(ctx context.Context, userID, id int64) (domain.Series, error)
+	AddSeason(ctx context.Context, id int64, inp domain.SeasonInput) (domain.Season, error)
+	UpdateSeason(ctx context.Context, id int64, number int, inp domain.SeasonInput) error
+	DeleteSeason(ctx context.Context, id int64, number int) error
+	AddEpisode(ctx context.Context, id int64, season int, inp domain.EpisodeInput) (domain.Episode, error)
+	UpdateEpisode(ctx context.Context, id int64, season, number int, inp domain.EpisodeInput) error
+	DeleteEpisode(ctx context.Context, id int64, season, number int) error
+	SetEpisodeWatched(ctx context.Context, userID, id int64, season, number int, watched bool) error
+	NextEpisode(ctx context.Context, userID, id int64) (domain.Episode, error)
+}
+
+type People interface {
+	Create(ctx context.Context, inp domain.PersonInput) (domain.Person, error)
+	Search(ctx context.Context, query string, limit int) ([]domain.Person, error)
+	Get(ctx context.Context, userID, id int64) (domain.PersonDetails, error)
+	Rename(ctx context.Context, id int64, inp domain.PersonInput) (domain.Person, error)
+	ListCredits(ctx context.Context, movieID int64) ([]domain.Credit, error)
+	AddCredit(ctx context.Context, movieID int64, inp domain.CreditInput) (domain.Credit, error)
+	DeleteCredit(ctx context.Context, movieID, id int64) error
+}
+
+type StreamingServices interface {
+	List(ctx context.Context) ([]domain.StreamingService, error)
+	Get(ctx context.Context, id int64) (domain.StreamingService, error)
+	Create(ctx context.Context, userID int64, inp domain.StreamingServiceInput) (domain.StreamingService, error)
+	Update(ctx context.Context, userID, id int64, inp domain.StreamingServiceInput) (domain.StreamingService, error)
+	Delete(ctx context.Context, userID, id int64) error
+	NormalizeMovie(ctx context.Context, movie *domain.Movie) error
+}
+
+type Availability interface {
+	List(ctx context.Context, movieID int64, region string) ([]domain.Availability, error)
+	Create(ctx context.Context, movieID int64, inp domain.AvailabilityInput) (domain.Availability, error)
+	Update(ctx context.Context, movieID, id int64, inp domain.AvailabilityInput) (domain.Availability, error)
+	Delete(ctx context.Context, movieID, id int64) error
+	Search(ctx context.Context, filter domain.AvailabilityFilter) ([]domain.AvailableMovie, error)
+	Available(ctx context.Context, filter domain.AvailableFilter) ([]domain.AvailableMovie, error)
+}
+
+type Duplicates interface {
+	Find(ctx context.Context, movie domain.Movie) ([]domain.DuplicateCandidate, error)
+	Merge(ctx context.Context, id int64, inp domain.MergeMovieInput) (domain.Movie, error)
+}
+
+type Posters interface {
+	Upload(ctx context.Context, movieID int64, r io.Reader) (domain.Poster, error)
+	Image(ctx context.Context, movieID int64, size string) (domain.PosterImage, error)
+	Delete(ctx context.Context, movieID int64) error
+}
+
+type Subscriptions interface {
+	List(ctx context.Context, userID int64) ([]domain.Subscription, error)
+	Replace(ctx context.Context, userID int64, inp domain.SubscriptionsInput) ([]domain.Subscription, error)
+}
+
+type Keys interface {
+	JWKS() keyring.JWKSet
+}
+
+// CookieConfig controls attributes of the refresh token cookie.
+type CookieConfig struct {
+	Secure bool
+	// MaxAge should match the refresh session lifetime.
+	MaxAge time.Duration
 }
 
 type Handler struct {
-	movieService Movies
-	usersService User
+	movieService         Movies
+	usersService         User
+	apiKeysService       APIKeys
+	exportsService       Exports
+	librariesService     Libraries
+	watchingService      Watching
+	tagsService          Tags
+	seriesService        Series
+	peopleService        People
+	streamingService     StreamingServices
+	releasesService      Releases
+	availabilityService  Availability
+	subscriptionsService Subscriptions
+	duplicatesService    Duplicates
+	postersService       Posters
+	keys                 Keys
+	cookies              CookieConfig
+	limiter              ratelimit.Store
+	limits               RateLimits
 }
 
 type statusResponse struct {
 	Message string `json:"status"`
 }
 
-func NewHandler(movies Movies, users User) *Handler {
+func NewHandler(movies Movies, users User, apiKeys APIKeys, exports Exports, libraries Libraries, watching Watching, tags Tags, series Series, people People,
+	streamingServices StreamingServices, releases Releases, availability Availability,
+	subscriptions Subscriptions, duplicates Duplicates, posters Posters, keys Keys, cookies CookieConfig, limiter ratelimit.Store, limits RateLimits) *Handler {
 	return &Handler{
-		movieService: movies,
-		usersService: users,
+		movieService:         movies,
+		usersService:         users,
+		apiKeysService:       apiKeys,
+		exportsService:       exports,
+		librariesService:     libraries,
+		watchingService:      watching,
+		tagsService:          tags,
+		seriesService:        series,
+		peopleService:        people,
+		streamingService:     streamingServices,
+		releasesService:      releases,
+		availabilityService:  availability,
+		subscriptionsService: subscriptions,
+		duplicatesService:    duplicates,
+		postersService:       posters,
+		keys:                 keys,
+		cookies:              cookies,
+		limiter:              limiter,
+		limits:               limits,
 	}
 }
 
 func (h *Handler) InitRouter() *mux.Router {
 	r := mux.NewRouter()
 	r.Use(loggingMiddleware)
+	r.Use(h.globalRateLimitMiddleware)
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.HandleFunc("/.well-known/jwks.json", h.jwks).Methods(http.MethodGet)
 
 	auth := r.PathPrefix("/auth").Subrouter()
 	{
+		auth.Use(h.rateLimitMiddleware("auth"))
+
 		auth.HandleFunc("/sign-up", h.signUp).Methods(http.MethodPost)
-		auth.HandleFunc("/sign-in", h.signIn).Methods(http.MethodGet)
-		auth.HandleFunc("/refresh", h.refresh).Methods(http.MethodGet)
+		auth.HandleFunc("/sign-in", h.signIn).Methods(http.MethodPost)
+		auth.HandleFunc("/refresh", h.refresh).Methods(http.MethodPost)
+		auth.HandleFunc("/logout", h.logout).Methods(http.MethodPost)
+		auth.HandleFunc("/verify", h.verifyEmail).Methods(http.MethodPost)
+		auth.HandleFunc("/verify/resend", h.resendVerification).Methods(http.MethodPost)
+		auth.HandleFunc("/password/forgot", h.forgotPassword).Methods(http.MethodPost)
+		auth.HandleFunc("/password/reset", h.resetPassword).Methods(http.MethodPost)
+		auth.Handle("/password", h.sessionAuthMiddleware(http.HandlerFunc(h.changePassword))).Methods(http.MethodPut)
+		auth.HandleFunc("/oidc/{provider}/login", h.oidcLogin).Methods(http.MethodGet)
+		auth.HandleFunc("/oidc/{provider}/callback", h.oidcCallback).Methods(http.MethodGet)
+		auth.HandleFunc("/2fa/verify", h.verifyMFA).Methods(http.MethodPost)
+		auth.Handle("/2fa/enroll", h.sessionAuthMiddleware(http.HandlerFunc(h.enrollTOTP))).Methods(http.MethodPost)
+		auth.Handle("/2fa/confirm", h.sessionAuthMiddleware(http.HandlerFunc(h.confirmTOTP))).Methods(http.MethodPost)
+		auth.Handle("/2fa/recovery-codes", h.sessionAuthMiddleware(http.HandlerFunc(h.regenerateRecoveryCodes))).Methods(http.MethodPost)
+		auth.Handle("/2fa", h.sessionAuthMiddleware(http.HandlerFunc(h.disableTOTP))).Methods(http.MethodDelete)
 	}
 
 	books := r.PathPrefix("/movies").Subrouter()
 	{
 		books.Use(h.authMiddleware)
+		books.Use(h.rateLimitMiddleware("movies"))
+
+		books.Handle("", requireScope(domain.ScopeMoviesWrite, h.insertMovie)).Methods(http.MethodPost)
+		books.Handle("", requireScope(domain.ScopeMoviesRead, h.getMovies)).Methods(http.MethodGet)
+		books.Handle("/available", requireScope(domain.ScopeMoviesRead, h.availableMovies)).Methods(http.MethodGet)
+		books.Handle("/{id}", requireScope(domain.ScopeMoviesRead, h.getMovieByID)).Methods(http.MethodGet)
+		books.Handle("/{id}", requireScope(domain.ScopeMoviesWrite, h.deleteMovie)).Methods(http.MethodDelete)
+		books.Handle("/{id}", requireScope(domain.ScopeMoviesWrite, h.updateMovie)).Methods(http.MethodPut)
+		books.Handle("/{id}/merge", requireScope(domain.ScopeMoviesWrite, h.mergeMovie)).Methods(http.MethodPost)
+		books.Handle("/{id}/poster", requireScope(domain.ScopeMoviesRead, h.getPoster)).Methods(http.MethodGet)
+		books.Handle("/{id}/poster", requireScope(domain.ScopeMoviesWrite, h.uploadPoster)).Methods(http.MethodPut)
+		books.Handle("/{id}/poster", requireScope(domain.ScopeMoviesWrite, h.deletePoster)).Methods(http.MethodDelete)
+		books.Handle("/{id}/watch", requireScope(domain.ScopeMoviesRead, h.getWatchState)).Methods(http.MethodGet)
+		books.Handle("/{id}/watch", requireScope(domain.ScopeMoviesWrite, h.updateWatchState)).Methods(http.MethodPut)
+		books.Handle("/{id}/watch", requireScope(domain.ScopeMoviesWrite, h.resetWatchState)).Methods(http.MethodDelete)
+		books.Handle("/{id}/watch/watches", requireScope(domain.ScopeMoviesWrite, h.markWatched)).Methods(http.MethodPost)
+		books.Handle("/{id}/watch/watches/{watchID}", requireScope(domain.ScopeMoviesWrite, h.deleteWatch)).Methods(http.MethodDelete)
+		books.Handle("/{id}/tags", requireScope(domain.ScopeMoviesWrite, h.addMovieTags)).Methods(http.MethodPost)
+		books.Handle("/{id}/tags/{tag}", requireScope(domain.ScopeMoviesWrite, h.removeMovieTag)).Methods(http.MethodDelete)
+		books.Handle("/{id}/genres", requireScope(domain.ScopeMoviesWrite, h.setMovieGenres)).Methods(http.MethodPut)
+		books.Handle("/{id}/releases", requireScope(domain.ScopeMoviesWrite, h.setMovieReleases)).Methods(http.MethodPut)
+		books.Handle("/{id}/availability", requireScope(domain.ScopeMoviesRead, h.listAvailability)).Methods(http.MethodGet)
+		books.Handle("/{id}/availability", requireScope(domain.ScopeMoviesWrite, h.addAvailability)).Methods(http.MethodPost)
+		books.Handle("/{id}/availability/{availabilityID}", requireScope(domain.ScopeMoviesWrite, h.updateAvailability)).Methods(http.MethodPut)
+		books.Handle("/{id}/availability/{availabilityID}", requireScope(domain.ScopeMoviesWrite, h.deleteAvailability)).Methods(http.MethodDelete)
+		books.Handle("/{id}/credits", requireScope(domain.ScopeMoviesRead, h.listCredits)).Methods(http.MethodGet)
+		books.Handle("/{id}/credits", requireScope(domain.ScopeMoviesWrite, h.addCredit)).Methods(http.MethodPost)
+		books.Handle("/{id}/credits/{creditID}", requireScope(domain.ScopeMoviesWrite, h.deleteCredit)).Methods(http.MethodDelete)
+	}
+
+	series := r.PathPrefix("/series").Subrouter()
+	{
+		series.Use(h.authMiddleware)
+		series.Use(h.rateLimitMiddleware("movies"))
+
+		series.Handle("/{id}", requireScope(domain.ScopeMoviesRead, h.getSeries)).Methods(http.MethodGet)
+		series.Handle("/{id}/next", requireScope(domain.ScopeMoviesRead, h.nextEpisode)).Methods(http.MethodGet)
+		series.Handle("/{id}/seasons", requireScope(domain.ScopeMoviesWrite, h.addSeason)).Methods(http.MethodPost)
+		series.Handle("/{id}/seasons/{season}", requireScope(domain.ScopeMoviesWrite, h.updateSeason)).Methods(http.MethodPut)
+		series.Handle("/{id}/seasons/{season}", requireScope(domain.ScopeMoviesWrite, h.deleteSeason)).Methods(http.MethodDelete)
+		series.Handle("/{id}/seasons/{season}/episodes", requireScope(domain.ScopeMoviesWrite, h.addEpisode)).Methods(http.MethodPost)
+		series.Handle("/{id}/seasons/{season}/episodes/{episode}", requireScope(domain.ScopeMoviesWrite, h.updateEpisode)).Methods(http.MethodPut)
+		series.Handle("/{id}/seasons/{season}/episodes/{episode}", requireScope(domain.ScopeMoviesWrite, h.deleteEpisode)).Methods(http.MethodDelete)
+		series.Handle("/{id}/seasons/{season}/episodes/{episode}/watched", requireScope(domain.ScopeMoviesWrite, h.setEpisodeWatched)).Methods(http.MethodPut, http.MethodDelete)
+	}
+
+	people := r.PathPrefix("/people").Subrouter()
+	{
+		people.Use(h.authMiddleware)
+		people.Use(h.rateLimitMiddleware("movies"))
+
+		people.Handle("", requireScope(domain.ScopeMoviesWrite, h.createPerson)).Methods(http.MethodPost)
+		people.Handle("", requireScope(domain.ScopeMoviesRead, h.searchPeople)).Methods(http.MethodGet)
+		people.Handle("/{id}", requireScope(domain.ScopeMoviesRead, h.getPerson)).Methods(http.MethodGet)
+		people.Handle("/{id}", requireScope(domain.ScopeMoviesWrite, h.renamePerson)).Methods(http.MethodPatch)
+	}
+
+	streamingServices := r.PathPrefix("/streaming-services").Subrouter()
+	{
+		streamingServices.Use(h.authMiddleware)
+		streamingServices.Use(h.rateLimitMiddleware("movies"))
+
+		streamingServices.Handle("", requireScope(domain.ScopeMoviesRead, h.listStreamingServices)).Methods(http.MethodGet)
+		streamingServices.Handle("/{id}", requireScope(domain.ScopeMoviesRead, h.getStreamingService)).Methods(http.MethodGet)
+		streamingServices.Handle("", requireSession(h.createStreamingService)).Methods(http.MethodPost)
+		streamingServices.Handle("/{id}", requireSession(h.updateStreamingService)).Methods(http.MethodPut)
+		streamingServices.Handle("/{id}", requireSession(h.deleteStreamingService)).Methods(http.MethodDelete)
+	}
+
+	availability := r.PathPrefix("/availability").Subrouter()
+	{
+		availability.Use(h.authMiddleware)
+		availability.Use(h.rateLimitMiddleware("movies"))
+
+		availability.Handle("", requireScope(domain.ScopeMoviesRead, h.searchAvailability)).Methods(http.MethodGet)
+	}
+
+	tags := r.PathPrefix("/tags").Subrouter()
+	{
+		tags.Use(h.authMiddleware)
+		tags.Use(h.rateLimitMiddleware("movies"))
+
+		tags.Handle("", requireScope(domain.ScopeMoviesRead, h.searchTags)).Methods(http.MethodGet)
+		tags.Handle("/{id}", requireSession(h.renameTag)).Methods(http.MethodPatch)
+		tags.Handle("/{id}/merge", requireSession(h.mergeTag)).Methods(http.MethodPost)
+	}
+
+	genres := r.PathPrefix("/genres").Subrouter()
+	{
+		genres.Use(h.authMiddleware)
+		genres.Use(h.rateLimitMiddleware("movies"))
+
+		genres.Handle("", requireScope(domain.ScopeMoviesRead, h.listGenres)).Methods(http.MethodGet)
+	}
+
+	libraries := r.PathPrefix("/libraries").Subrouter()
+	{
+		libraries.Use(h.authMiddleware)
+		libraries.Use(h.rateLimitMiddleware("movies"))
+
+		libraries.Handle("", requireSession(h.createLibrary)).Methods(http.MethodPost)
+		libraries.Handle("", requireSession(h.listLibraries)).Methods(http.MethodGet)
+		libraries.Handle("/invitations/accept", requireSession(h.acceptInvitation)).Methods(http.MethodPost)
+		libraries.Handle("/{id}", requireSession(h.getLibrary)).Methods(http.MethodGet)
+		libraries.Handle("/{id}", requireSession(h.renameLibrary)).Methods(http.MethodPatch)
+		libraries.Handle("/{id}", requireSession(h.deleteLibrary)).Methods(http.MethodDelete)
+		libraries.Handle("/{id}/invitations", requireSession(h.inviteMember)).Methods(http.MethodPost)
+		libraries.Handle("/{id}/members/{userID}", requireSession(h.updateMember)).Methods(http.MethodPut)
+		libraries.Handle("/{id}/members/{userID}", requireSession(h.removeMember)).Methods(http.MethodDelete)
+
+		libraries.Handle("/{id}/movies", requireScope(domain.ScopeMoviesRead, h.listLibraryMovies)).Methods(http.MethodGet)
+		libraries.Handle("/{id}/movies", requireScope(domain.ScopeMoviesWrite, h.addLibraryMovie)).Methods(http.MethodPost)
+		libraries.Handle("/{id}/movies/{movieID}", requireScope(domain.ScopeMoviesRead, h.getLibraryMovie)).Methods(http.MethodGet)
+		libraries.Handle("/{id}/movies/{movieID}", requireScope(domain.ScopeMoviesWrite, h.updateLibraryMovie)).Methods(http.MethodPut)
+		libraries.Handle("/{id}/movies/{movieID}", requireScope(domain.ScopeMoviesWrite, h.deleteLibraryMovie)).Methods(http.MethodDelete)
+	}
+
+	me := r.PathPrefix("/me").Subrouter()
+	{
+		me.Use(h.sessionAuthMiddleware)
+		me.Use(h.rateLimitMiddleware("auth"))
+
+		me.HandleFunc("", h.getProfile).Methods(http.MethodGet)
+		me.HandleFunc("", h.updateProfile).Methods(http.MethodPatch)
+		me.HandleFunc("", h.deleteAccount).Methods(http.MethodDelete)
+		me.HandleFunc("/export", h.exportData).Methods(http.MethodPost)
+		me.HandleFunc("/subscriptions", h.listSubscriptions).Methods(http.MethodGet)
+		me.HandleFunc("/subscriptions", h.replaceSubscriptions).Methods(http.MethodPut)
+	}
+
+	apiKeys := r.PathPrefix("/api-keys").Subrouter()
+	{
+		apiKeys.Use(h.sessionAuthMiddleware)
+		apiKeys.Use(h.rateLimitMiddleware("auth"))
+
+		apiKeys.HandleFunc("", h.createAPIKey).Methods(http.MethodPost)
+		apiKeys.HandleFunc("", h.listAPIKeys).Methods(http.MethodGet)
+		apiKeys.HandleFunc("/{id}", h.revokeAPIKey).Methods(http.MethodDelete)
+	}
+
+	admin := r.PathPrefix("/admin").Subrouter()
+	{
+		admin.Use(h.authMiddleware)
+		admin.Use(h.rateLimitMiddleware("auth"))
 
-		books.HandleFunc("", h.insertMovie).Methods(http.MethodPost)
-		books.HandleFunc("", h.getMovies).Methods(http.MethodGet)
-		books.HandleFunc("/{id}", h.getMovieByID).Methods(http.MethodGet)
-		books.HandleFunc("/{id}", h.deleteMovie).Methods(http.MethodDelete)
-		books.HandleFunc("/{id}", h.updateMovie).Methods(http.MethodPut)
+		admin.Handle("/users/{id}/disable", requireSession(h.disableUser)).Methods(http.MethodPost)
 	}
 
 	return r
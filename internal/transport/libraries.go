@@ -0,0 +1,384 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+// validatable is implemented by the domain input types.
+type validatable interface {
+	Validate() error
+}
+
+func (h *Handler) createLibrary(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("createLibrary", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var inp domain.CreateLibraryInput
+	if !readInput(w, r, "createLibrary", &inp) {
+		return
+	}
+
+	library, err := h.librariesService.Create(r.Context(), userID, inp)
+	if err != nil {
+		handleLibraryError(w, "createLibrary", err)
+		return
+	}
+
+	writeJSON(w, "createLibrary", http.StatusCreated, library)
+}
+
+func (h *Handler) listLibraries(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("listLibraries", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	libraries, err := h.librariesService.List(r.Context(), userID)
+	if err != nil {
+		handleLibraryError(w, "listLibraries", err)
+		return
+	}
+
+	writeJSON(w, "listLibraries", http.StatusOK, libraries)
+}
+
+func (h *Handler) getLibrary(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "getLibrary")
+	if !ok {
+		return
+	}
+
+	library, err := h.librariesService.Get(r.Context(), userID, id)
+	if err != nil {
+		handleLibraryError(w, "getLibrary", err)
+		return
+	}
+
+	writeJSON(w, "getLibrary", http.StatusOK, library)
+}
+
+func (h *Handler) renameLibrary(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "renameLibrary")
+	if !ok {
+		return
+	}
+
+	var inp domain.CreateLibraryInput
+	if !readInput(w, r, "renameLibrary", &inp) {
+		return
+	}
+
+	if err := h.librariesService.Rename(r.Context(), userID, id, inp); err != nil {
+		handleLibraryError(w, "renameLibrary", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteLibrary(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "deleteLibrary")
+	if !ok {
+		return
+	}
+
+	if err := h.librariesService.Delete(r.Context(), userID, id); err != nil {
+		handleLibraryError(w, "deleteLibrary", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) inviteMember(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "inviteMember")
+	if !ok {
+		return
+	}
+
+	var inp domain.InviteMemberInput
+	if !readInput(w, r, "inviteMember", &inp) {
+		return
+	}
+
+	if err := h.librariesService.Invite(r.Context(), userID, id, inp); err != nil {
+		handleLibraryError(w, "inviteMember", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) acceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("acceptInvitation", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var inp domain.AcceptInvitationInput
+	if !readInput(w, r, "acceptInvitation", &inp) {
+		return
+	}
+
+	library, err := h.librariesService.AcceptInvitation(r.Context(), userID, inp)
+	if err != nil {
+		handleLibraryError(w, "acceptInvitation", err)
+		return
+	}
+
+	writeJSON(w, "acceptInvitation", http.StatusOK, library)
+}
+
+func (h *Handler) updateMember(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "updateMember")
+	if !ok {
+		return
+	}
+
+	memberID, err := getVarFromRequest(r, "userID")
+	if err != nil {
+		logError("updateMember", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.UpdateMemberInput
+	if !readInput(w, r, "updateMember", &inp) {
+		return
+	}
+
+	if err := h.librariesService.UpdateMember(r.Context(), userID, id, memberID, inp); err != nil {
+		handleLibraryError(w, "updateMember", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) removeMember(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "removeMember")
+	if !ok {
+		return
+	}
+
+	memberID, err := getVarFromRequest(r, "userID")
+	if err != nil {
+		logError("removeMember", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.librariesService.RemoveMember(r.Context(), userID, id, memberID); err != nil {
+		handleLibraryError(w, "removeMember", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) listLibraryMovies(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "listLibraryMovies")
+	if !ok {
+		return
+	}
+
+	movies, err := h.librariesService.ListMovies(r.Context(), userID, id)
+	if err != nil {
+		handleLibraryError(w, "listLibraryMovies", err)
+		return
+	}
+
+	writeJSON(w, "listLibraryMovies", http.StatusOK, movies)
+}
+
+func (h *Handler) getLibraryMovie(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "getLibraryMovie")
+	if !ok {
+		return
+	}
+
+	movieID, err := getVarFromRequest(r, "movieID")
+	if err != nil {
+		logError("getLibraryMovie", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	movie, err := h.librariesService.GetMovie(r.Context(), userID, id, movieID)
+	if err != nil {
+		handleLibraryError(w, "getLibraryMovie", err)
+		return
+	}
+
+	writeJSON(w, "getLibraryMovie", http.StatusOK, movie)
+}
+
+func (h *Handler) addLibraryMovie(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "addLibraryMovie")
+	if !ok {
+		return
+	}
+
+	var movie domain.Movie
+	if !readInput(w, r, "addLibraryMovie", &movie) {
+		return
+	}
+
+	if err := h.librariesService.AddMovie(r.Context(), userID, id, movie); err != nil {
+		handleLibraryError(w, "addLibraryMovie", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) updateLibraryMovie(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "updateLibraryMovie")
+	if !ok {
+		return
+	}
+
+	movieID, err := getVarFromRequest(r, "movieID")
+	if err != nil {
+		logError("updateLibraryMovie", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var movie domain.Movie
+	if !readInput(w, r, "updateLibraryMovie", &movie) {
+		return
+	}
+
+	if err := h.librariesService.UpdateMovie(r.Context(), userID, id, movieID, movie); err != nil {
+		handleLibraryError(w, "updateLibraryMovie", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteLibraryMovie(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "deleteLibraryMovie")
+	if !ok {
+		return
+	}
+
+	movieID, err := getVarFromRequest(r, "movieID")
+	if err != nil {
+		logError("deleteLibraryMovie", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.librariesService.DeleteMovie(r.Context(), userID, id, movieID); err != nil {
+		handleLibraryError(w, "deleteLibraryMovie", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// libraryRequest reads the user and the library id of the request, it
+// writes the error response when it returns false.
+func userAndIDFromRequest(w http.ResponseWriter, r *http.Request, method string) (int64, int64, bool) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return userID, id, true
+}
+
+// readInput decodes and validates the request body, it writes the error
+// response when it returns false.
+func readInput(w http.ResponseWriter, r *http.Request, method string, inp interface{}) bool {
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+
+	if err := json.Unmarshal(reqBytes, inp); err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+
+	if v, ok := inp.(validatable); ok {
+		if err := v.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+func handleLibraryError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrLibraryNotFound), errors.Is(err, domain.ErrMemberNotFound), errors.Is(err, domain.ErrBookNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrForbidden), errors.Is(err, domain.ErrInvitationNotForUser):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrLastOwner):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrInvalidUserToken), errors.Is(err, domain.ErrUnknownStreamingService), errors.Is(err, domain.ErrInvalidType),
+		errors.Is(err, domain.ErrInvalidRuntime):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, method string, status int, v interface{}) {
+	response, err := json.Marshal(v)
+	if err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+func getVarFromRequest(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if id == 0 {
+		return 0, errors.New(name + " can't be 0")
+	}
+
+	return id, nil
+}
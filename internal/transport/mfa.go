@@ -0,0 +1,171 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) verifyMFA(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.VerifyMFAInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, err := h.usersService.VerifyMFA(r.Context(), inp, clientIP(r))
+	if err != nil {
+		var tooMany *domain.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			handleTooManyAttempts(w, tooMany)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	response, err := json.Marshal(map[string]string{
+		"token": accessToken,
+	})
+	if err != nil {
+		logError("verifyMFA", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.setRefreshCookie(w, refreshToken)
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func (h *Handler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("enrollTOTP", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.usersService.EnrollTOTP(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTOTPAlreadyEnabled) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		logError("enrollTOTP", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(enrollment)
+	if err != nil {
+		logError("enrollTOTP", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func (h *Handler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	h.handleMFACode(w, r, "confirmTOTP", func(userID int64, inp domain.MFACodeInput) (interface{}, error) {
+		codes, err := h.usersService.ConfirmTOTP(r.Context(), userID, inp)
+		return map[string][]string{"recovery_codes": codes}, err
+	})
+}
+
+func (h *Handler) regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	h.handleMFACode(w, r, "regenerateRecoveryCodes", func(userID int64, inp domain.MFACodeInput) (interface{}, error) {
+		codes, err := h.usersService.RegenerateRecoveryCodes(r.Context(), userID, inp)
+		return map[string][]string{"recovery_codes": codes}, err
+	})
+}
+
+func (h *Handler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	h.handleMFACode(w, r, "disableTOTP", func(userID int64, inp domain.MFACodeInput) (interface{}, error) {
+		return statusResponse{Message: "two-factor authentication disabled"}, h.usersService.DisableTOTP(r.Context(), userID, inp)
+	})
+}
+
+// handleMFACode decodes a code from the request of an authenticated user,
+// passes it to fn and writes the returned value as JSON.
+func (h *Handler) handleMFACode(w http.ResponseWriter, r *http.Request, handler string, fn func(userID int64, inp domain.MFACodeInput) (interface{}, error)) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.MFACodeInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := fn(userID, inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMFACode):
+			writeError(w, http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrTOTPAlreadyEnabled):
+			writeError(w, http.StatusConflict, err)
+		case errors.Is(err, domain.ErrTOTPNotEnrolled):
+			writeError(w, http.StatusBadRequest, err)
+		default:
+			logError(handler, err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
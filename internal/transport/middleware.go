@@ -3,9 +3,12 @@ package transport
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -13,6 +16,9 @@ type CtxValue int
 
 const (
 	ctxUserID CtxValue = iota
+	// ctxAPIKey holds the API key used to authenticate, it is not set for
+	// sessions which have full access.
+	ctxAPIKey
 )
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -25,18 +31,44 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// authMiddleware accepts both access tokens and API keys.
 func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, err := getTokenFromRequest(r)
+		key := getAPIKeyFromRequest(r)
+		if key == "" {
+			h.sessionAuthMiddleware(next).ServeHTTP(w, r)
+			return
+		}
+
+		apiKey, err := h.apiKeysService.Authenticate(r.Context(), key)
 		if err != nil {
 			logError("authMiddleware", err)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
+		ctx := context.WithValue(r.Context(), ctxUserID, apiKey.UserID)
+		ctx = context.WithValue(ctx, ctxAPIKey, apiKey)
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionAuthMiddleware accepts access tokens only, it guards account
+// management routes which must not be reachable with an API key.
+func (h *Handler) sessionAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := getTokenFromRequest(r)
+		if err != nil {
+			logError("sessionAuthMiddleware", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		userId, err := h.usersService.ParseToken(r.Context(), token)
 		if err != nil {
-			logError("authMiddleware", err)
+			logError("sessionAuthMiddleware", err)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -48,6 +80,47 @@ func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requireScope rejects requests made with an API key lacking the scope.
+func requireScope(scope string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, ok := r.Context().Value(ctxAPIKey).(domain.APIKey)
+		if !ok || apiKey.HasScope(scope) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeError(w, http.StatusForbidden, fmt.Errorf("api key lacks the %s scope", scope))
+	})
+}
+
+// requireSession rejects requests made with an API key, for routes behind
+// authMiddleware that manage more than movies.
+func requireSession(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(ctxAPIKey).(domain.APIKey); ok {
+			writeError(w, http.StatusForbidden, errors.New("api keys can't be used for this route"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getAPIKeyFromRequest reads the key from the X-API-Key header or an
+// "Authorization: ApiKey <key>" header.
+func getAPIKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	headerParts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(headerParts) == 2 && headerParts[0] == "ApiKey" {
+		return headerParts[1]
+	}
+
+	return ""
+}
+
 func getTokenFromRequest(r *http.Request) (string, error) {
 	header := r.Header.Get("Authorization")
 	if header == "" {
@@ -65,3 +138,22 @@ func getTokenFromRequest(r *http.Request) (string, error) {
 
 	return headerParts[1], nil
 }
+
+func getUserIDFromContext(r *http.Request) (int64, error) {
+	userID, ok := r.Context().Value(ctxUserID).(int64)
+	if !ok {
+		return 0, errors.New("user id not found in context")
+	}
+
+	return userID, nil
+}
+
+// clientIP returns the address of the client without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func TestRequireScope(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	readOnly := domain.APIKey{UserID: 1, Scopes: []string{domain.ScopeMoviesRead}}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		key     *domain.APIKey
+		status  int
+	}{
+		{"session", requireScope(domain.ScopeMoviesWrite, ok), nil, http.StatusOK},
+		{"key with the scope", requireScope(domain.ScopeMoviesRead, ok), &readOnly, http.StatusOK},
+		{"key without the scope", requireScope(domain.ScopeMoviesWrite, ok), &readOnly, http.StatusForbidden},
+		{"session only route with a session", requireSession(ok), nil, http.StatusOK},
+		{"session only route with a key", requireSession(ok), &readOnly, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/movies", nil)
+		if tt.key != nil {
+			r = r.WithContext(context.WithValue(r.Context(), ctxAPIKey, *tt.key))
+		}
+
+		w := httptest.NewRecorder()
+		tt.handler.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
 	"github.com/gorilla/mux"
@@ -14,13 +15,40 @@ import (
 
 // GetMovies godoc
 // @Summary     Get movies
-// @Description Get all movies list
+// @Description Get all movies list with the watch state of the user
 // @Accept      json
 // @Produce     json
+// @Param       type       query    string false "movie or series"
+// @Param       status     query    string false "watch status"
+// @Param       rating_min query    int    false "minimal rating"
+// @Param       rating_max query    int    false "maximal rating"
+// @Param       tags       query    string false "comma separated tags"
+// @Param       match      query    string false "any (default) or all of the tags"
+// @Param       director   query    string false "director id or name"
+// @Param       actor      query    string false "actor id or name"
+// @Param       year_from  query    int    false "first release year"
+// @Param       year_to    query    int    false "last release year"
+// @Param       sort       query    string false "title, release or -release"
 // @Success     200 {object} []domain.Movie
 // @Router      /movies [get]
 func (h *Handler) getMovies(w http.ResponseWriter, r *http.Request) {
-	m, err := h.movieService.List(r.Context())
+	filter, err := getMovieFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter.UserID, err = getUserIDFromContext(r)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"handler": "getMovies",
+			"problem": "getting user id",
+		}).Error(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m, err := h.movieService.List(r.Context(), filter)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"handler": "getMovies",
@@ -100,7 +128,9 @@ func (h *Handler) getMovieByID(w http.ResponseWriter, r *http.Request) {
 // @Accept      json
 // @Produce     json
 // @Param       input body     domain.MovieMainInfo true "Add movie to list, 'id' and 'savedAt' not necessary params"
+// @Param       force query    bool                 false "save even if the movie may already exist"
 // @Success     200,201      {object}             domain.MovieMainInfo
+// @Failure     409          {object}             duplicateResponse
 // @Router      /movies [post]
 func (h *Handler) insertMovie(w http.ResponseWriter, r *http.Request) {
 	reqBytes, err := ioutil.ReadAll(r.Body)
@@ -124,6 +154,43 @@ func (h *Handler) insertMovie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := movie.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.streamingService.NormalizeMovie(r.Context(), &movie); err != nil {
+		handleStreamingServiceError(w, "insertMovie", err)
+		return
+	}
+
+	movie.UserID, err = getUserIDFromContext(r)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"handler": "insertMovie",
+			"problem": "getting user id",
+		}).Error(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); !force {
+		candidates, err := h.duplicatesService.Find(r.Context(), movie)
+		if err != nil {
+			logError("insertMovie", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if len(candidates) > 0 {
+			writeJSON(w, "insertMovie", http.StatusConflict, duplicateResponse{
+				Error:      domain.ErrDuplicateMovie.Error(),
+				Candidates: candidates,
+			})
+			return
+		}
+	}
+
 	err = h.movieService.Create(r.Context(), movie)
 
 	if err != nil {
@@ -165,6 +232,12 @@ func (h *Handler) deleteMovie(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	// the movie is gone already, a poster left behind is only logged
+	if err := h.postersService.Delete(r.Context(), id); err != nil && !errors.Is(err, domain.ErrPosterNotFound) {
+		logError("deleteMovie", err)
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -208,6 +281,16 @@ func (h *Handler) updateMovie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := upd.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.streamingService.NormalizeMovie(r.Context(), &upd); err != nil {
+		handleStreamingServiceError(w, "updateMovie", err)
+		return
+	}
+
 	err = h.movieService.UpdateMovie(r.Context(), id, upd)
 
 	if err != nil {
@@ -221,6 +304,47 @@ func (h *Handler) updateMovie(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// SetMovieReleases doc
+// @Summary     Set country releases
+// @Description Replace the release dates of the movie in the countries
+// @Accept      json
+// @Produce     json
+// @Param       id    path     string                      true "movie id"
+// @Param       input body     domain.CountryReleasesInput true "release dates by ISO country code"
+// @Success     200   {object} domain.Movie
+// @Router      /movies/{id}/releases [put]
+func (h *Handler) setMovieReleases(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("setMovieReleases", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.CountryReleasesInput
+	if !readInput(w, r, "setMovieReleases", &inp) {
+		return
+	}
+
+	movie, err := h.releasesService.Set(r.Context(), id, inp)
+	if err != nil {
+		handleMovieError(w, "setMovieReleases", err)
+		return
+	}
+
+	writeJSON(w, "setMovieReleases", http.StatusOK, movie)
+}
+
+func handleMovieError(w http.ResponseWriter, method string, err error) {
+	if errors.Is(err, domain.ErrBookNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	logError(method, err)
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
 func getIdFromRequest(r *http.Request) (int64, error) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -234,3 +358,39 @@ func getIdFromRequest(r *http.Request) (int64, error) {
 
 	return id, nil
 }
+
+func getMovieFilter(r *http.Request) (domain.MovieFilter, error) {
+	query := r.URL.Query()
+	filter := domain.MovieFilter{
+		Type:     query.Get("type"),
+		Status:   query.Get("status"),
+		Match:    query.Get("match"),
+		Director: query.Get("director"),
+		Actor:    query.Get("actor"),
+		Sort:     query.Get("sort"),
+	}
+
+	if query.Get("tags") != "" {
+		filter.Tags = domain.NormalizeTags(strings.Split(query.Get("tags"), ","))
+	}
+
+	for name, value := range map[string]*int{
+		"rating_min": &filter.MinRating,
+		"rating_max": &filter.MaxRating,
+		"year_from":  &filter.YearFrom,
+		"year_to":    &filter.YearTo,
+	} {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(query.Get(name))
+		if err != nil {
+			return filter, errors.New(name + " must be a number")
+		}
+
+		*value = n
+	}
+
+	return filter, filter.Validate()
+}
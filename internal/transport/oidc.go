@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+const (
+	oidcStateCookieName = "oidc-state"
+	oidcStateCookiePath = "/auth/oidc"
+)
+
+func (h *Handler) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	req, err := h.usersService.StartOIDC(r.Context(), mux.Vars(r)["provider"])
+	if err != nil {
+		if errors.Is(err, domain.ErrUnknownProvider) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		logError("oidcLogin", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	// Lax, the cookie has to be sent when the provider redirects back
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    req.State,
+		Path:     oidcStateCookiePath,
+		Secure:   h.cookies.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, req.URL, http.StatusFound)
+}
+
+func (h *Handler) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if providerErr := q.Get("error"); providerErr != "" {
+		writeError(w, http.StatusBadRequest, errors.New("identity provider error: "+providerErr))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, domain.ErrInvalidOIDCState)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     oidcStateCookiePath,
+		MaxAge:   -1,
+		Secure:   h.cookies.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	inp := domain.OIDCCallbackInput{
+		Provider:     mux.Vars(r)["provider"],
+		Code:         q.Get("code"),
+		State:        q.Get("state"),
+		RequestState: cookie.Value,
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("oidcCallback", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.usersService.FinishOIDC(r.Context(), inp)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnknownProvider):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvalidOIDCState):
+			writeError(w, http.StatusBadRequest, err)
+		case errors.Is(err, domain.ErrIdentityNotVerified), errors.Is(err, domain.ErrUserNotFound):
+			writeError(w, http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrUserAlreadyExists), errors.Is(err, domain.ErrAccountNotVerified):
+			writeError(w, http.StatusConflict, err)
+		default:
+			logError("oidcCallback", err)
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		return
+	}
+
+	h.writeSignInResult(w, "oidcCallback", result)
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ForgotPasswordInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.ForgotPassword(r.Context(), inp); err != nil {
+		logError("forgotPassword", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ResetPasswordInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.ResetPassword(r.Context(), inp); err != nil {
+		if errors.Is(err, domain.ErrInvalidUserToken) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		logError("resetPassword", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.ChangePasswordInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.ChangePassword(r.Context(), userID, inp); err != nil {
+		if errors.Is(err, domain.ErrWrongPassword) {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+
+		logError("changePassword", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	w.WriteHeader(http.StatusOK)
+}
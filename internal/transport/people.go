@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) createPerson(w http.ResponseWriter, r *http.Request) {
+	var inp domain.PersonInput
+	if !readInput(w, r, "createPerson", &inp) {
+		return
+	}
+
+	person, err := h.peopleService.Create(r.Context(), inp)
+	if err != nil {
+		handlePeopleError(w, "createPerson", err)
+		return
+	}
+
+	writeJSON(w, "createPerson", http.StatusCreated, person)
+}
+
+func (h *Handler) searchPeople(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a number"))
+			return
+		}
+		limit = n
+	}
+
+	people, err := h.peopleService.Search(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		handlePeopleError(w, "searchPeople", err)
+		return
+	}
+
+	writeJSON(w, "searchPeople", http.StatusOK, people)
+}
+
+func (h *Handler) getPerson(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "getPerson")
+	if !ok {
+		return
+	}
+
+	person, err := h.peopleService.Get(r.Context(), userID, id)
+	if err != nil {
+		handlePeopleError(w, "getPerson", err)
+		return
+	}
+
+	writeJSON(w, "getPerson", http.StatusOK, person)
+}
+
+func (h *Handler) renamePerson(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("renamePerson", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.PersonInput
+	if !readInput(w, r, "renamePerson", &inp) {
+		return
+	}
+
+	person, err := h.peopleService.Rename(r.Context(), id, inp)
+	if err != nil {
+		handlePeopleError(w, "renamePerson", err)
+		return
+	}
+
+	writeJSON(w, "renamePerson", http.StatusOK, person)
+}
+
+func (h *Handler) listCredits(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("listCredits", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	credits, err := h.peopleService.ListCredits(r.Context(), id)
+	if err != nil {
+		handlePeopleError(w, "listCredits", err)
+		return
+	}
+
+	writeJSON(w, "listCredits", http.StatusOK, credits)
+}
+
+func (h *Handler) addCredit(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("addCredit", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.CreditInput
+	if !readInput(w, r, "addCredit", &inp) {
+		return
+	}
+
+	credit, err := h.peopleService.AddCredit(r.Context(), id, inp)
+	if err != nil {
+		handlePeopleError(w, "addCredit", err)
+		return
+	}
+
+	writeJSON(w, "addCredit", http.StatusCreated, credit)
+}
+
+func (h *Handler) deleteCredit(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deleteCredit", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	creditID, err := getVarFromRequest(r, "creditID")
+	if err != nil {
+		logError("deleteCredit", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.peopleService.DeleteCredit(r.Context(), id, creditID); err != nil {
+		handlePeopleError(w, "deleteCredit", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func handlePeopleError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound), errors.Is(err, domain.ErrPersonNotFound), errors.Is(err, domain.ErrCreditNotFound):
+		writeError(w, http.StatusNotFound, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+// UploadPoster doc
+// @Summary     Upload a poster
+// @Description Replace the poster of the movie with a JPEG, PNG or GIF image sent as the body or the "poster" field of a multipart form
+// @Accept      image/jpeg,image/png,image/gif,multipart/form-data
+// @Produce     json
+// @Param       id  path     string true "movie id"
+// @Success     200 {object} domain.Poster
+// @Router      /movies/{id}/poster [put]
+func (h *Handler) uploadPoster(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("uploadPoster", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, err := posterBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	poster, err := h.postersService.Upload(r.Context(), id, body)
+	if err != nil {
+		handlePosterError(w, "uploadPoster", err)
+		return
+	}
+
+	writeJSON(w, "uploadPoster", http.StatusOK, poster)
+}
+
+// GetPoster doc
+// @Summary     Get a poster
+// @Description Get the original poster or one of its JPEG thumbnails
+// @Produce     image/jpeg,image/png,image/gif
+// @Param       id   path     string true  "movie id"
+// @Param       size query    string false "original (default) or a thumbnail size, e.g. small, medium, large"
+// @Success     200
+// @Router      /movies/{id}/poster [get]
+func (h *Handler) getPoster(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getPoster", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		size = domain.PosterOriginal
+	}
+
+	img, err := h.postersService.Image(r.Context(), id, size)
+	if err != nil {
+		handlePosterError(w, "getPoster", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("ETag", img.ETag)
+	// responses depend on the signed in user, shared caches must not keep them
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(img.MaxAge.Seconds())))
+
+	// ServeContent answers conditional requests with 304 Not Modified
+	http.ServeContent(w, r, "", img.UpdatedAt, bytes.NewReader(img.Data))
+}
+
+func (h *Handler) deletePoster(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("deletePoster", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.postersService.Delete(r.Context(), id); err != nil {
+		handlePosterError(w, "deletePoster", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// posterBody returns the "poster" field of a multipart form, or the first
+// file of the form, or the body itself.
+func posterBody(r *http.Request) (io.Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("the form has no poster file")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == "poster" || part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
+
+func handlePosterError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound), errors.Is(err, domain.ErrPosterNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrPosterTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, domain.ErrPosterType):
+		writeError(w, http.StatusUnsupportedMediaType, err)
+	case errors.Is(err, domain.ErrPosterSize):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,176 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("getProfile", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	profile, err := h.usersService.Profile(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		logError("getProfile", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeProfile(w, "getProfile", profile)
+}
+
+func (h *Handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.UpdateProfileInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	profile, err := h.usersService.UpdateProfile(r.Context(), userID, inp)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUserAlreadyExists) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		logError("updateProfile", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeProfile(w, "updateProfile", profile)
+}
+
+func (h *Handler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("deleteAccount", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	reqBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError("deleteAccount", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.DeleteAccountInput
+	if err = json.Unmarshal(reqBytes, &inp); err != nil {
+		logError("deleteAccount", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := inp.Validate(); err != nil {
+		logError("deleteAccount", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usersService.DeleteAccount(r.Context(), userID, inp); err != nil {
+		if errors.Is(err, domain.ErrWrongPassword) {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		logError("deleteAccount", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) exportData(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("exportData", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	export, err := h.exportsService.Export(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		logError("exportData", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		logError("exportData", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("export-%d-%s.json", userID, export.ExportedAt.Format("20060102150405"))
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Add("Cache-Control", "no-store")
+	w.Write(response)
+}
+
+func writeProfile(w http.ResponseWriter, method string, profile domain.Profile) {
+	response, err := json.Marshal(profile)
+	if err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/pkg/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// RateLimits holds request limits. Global applies to every request per
+// client IP, Routes apply per route group ("auth", "movies", ...) per user,
+// or per client IP for unauthenticated routes.
+type RateLimits struct {
+	Global ratelimit.Limit
+	Routes map[string]ratelimit.Limit
+}
+
+func (h *Handler) globalRateLimitMiddleware(next http.Handler) http.Handler {
+	return h.limit("global", h.limits.Global, next)
+}
+
+func (h *Handler) rateLimitMiddleware(route string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return h.limit(route, h.limits.Routes[route], next)
+	}
+}
+
+func (h *Handler) limit(route string, limit ratelimit.Limit, next http.Handler) http.Handler {
+	if !limit.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := fmt.Sprintf("%s:ip:%s", route, clientIP(r))
+		if userID, err := getUserIDFromContext(r); err == nil {
+			key = fmt.Sprintf("%s:user:%d", route, userID)
+		}
+
+		res, err := h.limiter.Take(r.Context(), key, limit)
+		if err != nil {
+			// don't take the API down with the limiter backend
+			logError("rateLimitMiddleware", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BalamutDiana/crud_movie_manager/pkg/ratelimit"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	h := &Handler{
+		limiter: ratelimit.NewMemoryStore(),
+		limits: RateLimits{Routes: map[string]ratelimit.Limit{
+			"auth": {Requests: 1, Period: time.Minute, Burst: 2},
+		}},
+	}
+
+	handler := h.rateLimitMiddleware("auth")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(remoteAddr string, userID int64) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/auth/sign-in", nil)
+		r.RemoteAddr = remoteAddr
+		if userID != 0 {
+			r = r.WithContext(context.WithValue(r.Context(), ctxUserID, userID))
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := request("10.0.0.1:1234", 0); w.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst: status = %d", i+1, w.Code)
+		}
+	}
+
+	w := request("10.0.0.1:4321", 0)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the burst: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("headers = %v, want Retry-After and no remaining requests", w.Header())
+	}
+
+	// other clients and signed in users have their own buckets
+	if w := request("10.0.0.2:1234", 0); w.Code != http.StatusOK {
+		t.Errorf("request from another ip: status = %d", w.Code)
+	}
+
+	if w := request("10.0.0.1:1234", 1); w.Code != http.StatusOK {
+		t.Errorf("request of a signed in user: status = %d", w.Code)
+	}
+}
@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) getSeries(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "getSeries")
+	if !ok {
+		return
+	}
+
+	series, err := h.seriesService.Get(r.Context(), userID, id)
+	if err != nil {
+		handleSeriesError(w, "getSeries", err)
+		return
+	}
+
+	writeJSON(w, "getSeries", http.StatusOK, series)
+}
+
+func (h *Handler) nextEpisode(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "nextEpisode")
+	if !ok {
+		return
+	}
+
+	episode, err := h.seriesService.NextEpisode(r.Context(), userID, id)
+	if err != nil {
+		handleSeriesError(w, "nextEpisode", err)
+		return
+	}
+
+	writeJSON(w, "nextEpisode", http.StatusOK, episode)
+}
+
+func (h *Handler) addSeason(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("addSeason", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.SeasonInput
+	if !readInput(w, r, "addSeason", &inp) {
+		return
+	}
+
+	season, err := h.seriesService.AddSeason(r.Context(), id, inp)
+	if err != nil {
+		handleSeriesError(w, "addSeason", err)
+		return
+	}
+
+	writeJSON(w, "addSeason", http.StatusCreated, season)
+}
+
+func (h *Handler) updateSeason(w http.ResponseWriter, r *http.Request) {
+	id, numbers, ok := seriesRequest(w, r, "updateSeason", "season")
+	if !ok {
+		return
+	}
+
+	var inp domain.SeasonInput
+	if !readInput(w, r, "updateSeason", &inp) {
+		return
+	}
+
+	if err := h.seriesService.UpdateSeason(r.Context(), id, numbers[0], inp); err != nil {
+		handleSeriesError(w, "updateSeason", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteSeason(w http.ResponseWriter, r *http.Request) {
+	id, numbers, ok := seriesRequest(w, r, "deleteSeason", "season")
+	if !ok {
+		return
+	}
+
+	if err := h.seriesService.DeleteSeason(r.Context(), id, numbers[0]); err != nil {
+		handleSeriesError(w, "deleteSeason", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) addEpisode(w http.ResponseWriter, r *http.Request) {
+	id, numbers, ok := seriesRequest(w, r, "addEpisode", "season")
+	if !ok {
+		return
+	}
+
+	var inp domain.EpisodeInput
+	if !readInput(w, r, "addEpisode", &inp) {
+		return
+	}
+
+	episode, err := h.seriesService.AddEpisode(r.Context(), id, numbers[0], inp)
+	if err != nil {
+		handleSeriesError(w, "addEpisode", err)
+		return
+	}
+
+	writeJSON(w, "addEpisode", http.StatusCreated, episode)
+}
+
+func (h *Handler) updateEpisode(w http.ResponseWriter, r *http.Request) {
+	id, numbers, ok := seriesRequest(w, r, "updateEpisode", "season", "episode")
+	if !ok {
+		return
+	}
+
+	var inp domain.EpisodeInput
+	if !readInput(w, r, "updateEpisode", &inp) {
+		return
+	}
+
+	if err := h.seriesService.UpdateEpisode(r.Context(), id, numbers[0], numbers[1], inp); err != nil {
+		handleSeriesError(w, "updateEpisode", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) deleteEpisode(w http.ResponseWriter, r *http.Request) {
+	id, numbers, ok := seriesRequest(w, r, "deleteEpisode", "season", "episode")
+	if !ok {
+		return
+	}
+
+	if err := h.seriesService.DeleteEpisode(r.Context(), id, numbers[0], numbers[1]); err != nil {
+		handleSeriesError(w, "deleteEpisode", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// setEpisodeWatched marks the episode with PUT and clears the mark with
+// DELETE.
+func (h *Handler) setEpisodeWatched(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("setEpisodeWatched", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id, numbers, ok := seriesRequest(w, r, "setEpisodeWatched", "season", "episode")
+	if !ok {
+		return
+	}
+
+	watched := r.Method == http.MethodPut
+	if err := h.seriesService.SetEpisodeWatched(r.Context(), userID, id, numbers[0], numbers[1], watched); err != nil {
+		handleSeriesError(w, "setEpisodeWatched", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// seriesRequest reads the series id and the season and episode numbers
+// named by vars, it writes the error response when it returns false.
+func seriesRequest(w http.ResponseWriter, r *http.Request, method string, vars ...string) (int64, []int, bool) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError(method, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, nil, false
+	}
+
+	numbers := make([]int, 0, len(vars))
+	for _, name := range vars {
+		n, err := strconv.Atoi(mux.Vars(r)[name])
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errors.New(name+" must be a number"))
+			return 0, nil, false
+		}
+		numbers = append(numbers, n)
+	}
+
+	return id, numbers, true
+}
+
+func handleSeriesError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSeriesNotFound), errors.Is(err, domain.ErrSeasonNotFound),
+		errors.Is(err, domain.ErrEpisodeNotFound), errors.Is(err, domain.ErrNoNextEpisode):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrSeasonExists), errors.Is(err, domain.ErrEpisodeExists):
+		writeError(w, http.StatusConflict, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) listStreamingServices(w http.ResponseWriter, r *http.Request) {
+	services, err := h.streamingService.List(r.Context())
+	if err != nil {
+		handleStreamingServiceError(w, "listStreamingServices", err)
+		return
+	}
+
+	writeJSON(w, "listStreamingServices", http.StatusOK, services)
+}
+
+func (h *Handler) getStreamingService(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("getStreamingService", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	service, err := h.streamingService.Get(r.Context(), id)
+	if err != nil {
+		handleStreamingServiceError(w, "getStreamingService", err)
+		return
+	}
+
+	writeJSON(w, "getStreamingService", http.StatusOK, service)
+}
+
+func (h *Handler) createStreamingService(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("createStreamingService", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var inp domain.StreamingServiceInput
+	if !readInput(w, r, "createStreamingService", &inp) {
+		return
+	}
+
+	service, err := h.streamingService.Create(r.Context(), userID, inp)
+	if err != nil {
+		handleStreamingServiceError(w, "createStreamingService", err)
+		return
+	}
+
+	writeJSON(w, "createStreamingService", http.StatusCreated, service)
+}
+
+func (h *Handler) updateStreamingService(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "updateStreamingService")
+	if !ok {
+		return
+	}
+
+	var inp domain.StreamingServiceInput
+	if !readInput(w, r, "updateStreamingService", &inp) {
+		return
+	}
+
+	service, err := h.streamingService.Update(r.Context(), userID, id, inp)
+	if err != nil {
+		handleStreamingServiceError(w, "updateStreamingService", err)
+		return
+	}
+
+	writeJSON(w, "updateStreamingService", http.StatusOK, service)
+}
+
+func (h *Handler) deleteStreamingService(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "deleteStreamingService")
+	if !ok {
+		return
+	}
+
+	if err := h.streamingService.Delete(r.Context(), userID, id); err != nil {
+		handleStreamingServiceError(w, "deleteStreamingService", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleStreamingServiceError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrStreamingServiceNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrForbidden):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrStreamingServiceExists), errors.Is(err, domain.ErrStreamingServiceInUse):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrUnknownStreamingService):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("listSubscriptions", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	subscriptions, err := h.subscriptionsService.List(r.Context(), userID)
+	if err != nil {
+		handleAvailabilityError(w, "listSubscriptions", err)
+		return
+	}
+
+	writeJSON(w, "listSubscriptions", http.StatusOK, subscriptions)
+}
+
+func (h *Handler) replaceSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("replaceSubscriptions", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var inp domain.SubscriptionsInput
+	if !readInput(w, r, "replaceSubscriptions", &inp) {
+		return
+	}
+
+	subscriptions, err := h.subscriptionsService.Replace(r.Context(), userID, inp)
+	if err != nil {
+		handleAvailabilityError(w, "replaceSubscriptions", err)
+		return
+	}
+
+	writeJSON(w, "replaceSubscriptions", http.StatusOK, subscriptions)
+}
+
+// AvailableMovies godoc
+// @Summary     What can I watch tonight
+// @Description Unwatched movies available on the subscriptions of the user
+// @Produce     json
+// @Param       region      query    string false "ISO country code of the subscriptions"
+// @Param       max_runtime query    int    false "maximal runtime in minutes"
+// @Param       genre       query    string false "genre"
+// @Success     200 {object} []domain.AvailableMovie
+// @Router      /movies/available [get]
+func (h *Handler) availableMovies(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r)
+	if err != nil {
+		logError("availableMovies", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.AvailableFilter{
+		UserID: userID,
+		Region: query.Get("region"),
+		Genre:  query.Get("genre"),
+	}
+
+	if value := query.Get("max_runtime"); value != "" {
+		if filter.MaxRuntime, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("max_runtime must be a number"))
+			return
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	movies, err := h.availabilityService.Available(r.Context(), filter)
+	if err != nil {
+		handleAvailabilityError(w, "availableMovies", err)
+		return
+	}
+
+	writeJSON(w, "availableMovies", http.StatusOK, movies)
+}
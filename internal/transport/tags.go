@@ -0,0 +1,154 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) searchTags(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a number"))
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.tagsService.Search(r.Context(), r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		handleTagError(w, "searchTags", err)
+		return
+	}
+
+	writeJSON(w, "searchTags", http.StatusOK, tags)
+}
+
+func (h *Handler) renameTag(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "renameTag")
+	if !ok {
+		return
+	}
+
+	var inp domain.RenameTagInput
+	if !readInput(w, r, "renameTag", &inp) {
+		return
+	}
+
+	tag, err := h.tagsService.Rename(r.Context(), userID, id, inp)
+	if err != nil {
+		handleTagError(w, "renameTag", err)
+		return
+	}
+
+	writeJSON(w, "renameTag", http.StatusOK, tag)
+}
+
+func (h *Handler) mergeTag(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "mergeTag")
+	if !ok {
+		return
+	}
+
+	var inp domain.MergeTagInput
+	if !readInput(w, r, "mergeTag", &inp) {
+		return
+	}
+
+	tag, err := h.tagsService.Merge(r.Context(), userID, id, inp)
+	if err != nil {
+		handleTagError(w, "mergeTag", err)
+		return
+	}
+
+	writeJSON(w, "mergeTag", http.StatusOK, tag)
+}
+
+func (h *Handler) addMovieTags(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("addMovieTags", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.TagsInput
+	if !readInput(w, r, "addMovieTags", &inp) {
+		return
+	}
+
+	movie, err := h.tagsService.AddToMovie(r.Context(), id, inp)
+	if err != nil {
+		handleTagError(w, "addMovieTags", err)
+		return
+	}
+
+	writeJSON(w, "addMovieTags", http.StatusOK, movie)
+}
+
+func (h *Handler) removeMovieTag(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("removeMovieTag", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tagsService.RemoveFromMovie(r.Context(), id, mux.Vars(r)["tag"]); err != nil {
+		handleTagError(w, "removeMovieTag", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) listGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.tagsService.Genres(r.Context())
+	if err != nil {
+		handleTagError(w, "listGenres", err)
+		return
+	}
+
+	writeJSON(w, "listGenres", http.StatusOK, genres)
+}
+
+func (h *Handler) setMovieGenres(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromRequest(r)
+	if err != nil {
+		logError("setMovieGenres", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var inp domain.GenresInput
+	if !readInput(w, r, "setMovieGenres", &inp) {
+		return
+	}
+
+	movie, err := h.tagsService.SetGenres(r.Context(), id, inp)
+	if err != nil {
+		handleTagError(w, "setMovieGenres", err)
+		return
+	}
+
+	writeJSON(w, "setMovieGenres", http.StatusOK, movie)
+}
+
+func handleTagError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound), errors.Is(err, domain.ErrTagNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrTagExists):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, domain.ErrForbidden):
+		writeError(w, http.StatusForbidden, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
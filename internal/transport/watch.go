@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BalamutDiana/crud_movie_manager/internal/domain"
+)
+
+func (h *Handler) getWatchState(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "getWatchState")
+	if !ok {
+		return
+	}
+
+	state, err := h.watchingService.Get(r.Context(), userID, id)
+	if err != nil {
+		handleWatchError(w, "getWatchState", err)
+		return
+	}
+
+	writeJSON(w, "getWatchState", http.StatusOK, state)
+}
+
+func (h *Handler) updateWatchState(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "updateWatchState")
+	if !ok {
+		return
+	}
+
+	var inp domain.UpdateWatchStateInput
+	if !readInput(w, r, "updateWatchState", &inp) {
+		return
+	}
+
+	state, err := h.watchingService.Update(r.Context(), userID, id, inp)
+	if err != nil {
+		handleWatchError(w, "updateWatchState", err)
+		return
+	}
+
+	writeJSON(w, "updateWatchState", http.StatusOK, state)
+}
+
+func (h *Handler) resetWatchState(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "resetWatchState")
+	if !ok {
+		return
+	}
+
+	if err := h.watchingService.Reset(r.Context(), userID, id); err != nil {
+		handleWatchError(w, "resetWatchState", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) markWatched(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "markWatched")
+	if !ok {
+		return
+	}
+
+	var inp domain.MarkWatchedInput
+	if r.ContentLength != 0 && !readInput(w, r, "markWatched", &inp) {
+		return
+	}
+
+	state, err := h.watchingService.MarkWatched(r.Context(), userID, id, inp)
+	if err != nil {
+		handleWatchError(w, "markWatched", err)
+		return
+	}
+
+	writeJSON(w, "markWatched", http.StatusCreated, state)
+}
+
+func (h *Handler) deleteWatch(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := userAndIDFromRequest(w, r, "deleteWatch")
+	if !ok {
+		return
+	}
+
+	watchID, err := getVarFromRequest(r, "watchID")
+	if err != nil {
+		logError("deleteWatch", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.watchingService.DeleteWatch(r.Context(), userID, id, watchID); err != nil {
+		handleWatchError(w, "deleteWatch", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleWatchError(w http.ResponseWriter, method string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBookNotFound), errors.Is(err, domain.ErrWatchEventNotFound):
+		writeError(w, http.StatusNotFound, err)
+	default:
+		logError(method, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,39 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir())
+
+	if err := s.Put(ctx, "posters/1/original", []byte("image"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.Get(ctx, "posters/1/original")
+	if err != nil || string(data) != "image" {
+		t.Fatalf("Get() = %q, %v, want the stored object", data, err)
+	}
+
+	if err := s.Delete(ctx, "posters/1/original"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get(ctx, "posters/1/original"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a deleted object error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.Delete(ctx, "posters/1/original"); err != nil {
+		t.Errorf("Delete() of a missing object error = %v", err)
+	}
+
+	for _, key := range []string{"", "../secret", "posters/../../secret"} {
+		if err := s.Put(ctx, key, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const rsaKeyBits = 2048
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a single signing key. Keys are used for signing until the next
+// rotation and stay valid for verification until RetiredAt.
+type Key struct {
+	ID        string
+	CreatedAt time.Time
+	RetiredAt time.Time
+
+	private crypto.Signer
+}
+
+// Public returns the public half of the key.
+func (k *Key) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+type Options struct {
+	// Algorithm is either RS256 or EdDSA.
+	Algorithm string
+	// RotateEvery is how long a key is used for signing.
+	RotateEvery time.Duration
+	// Retain is how long a key stays valid for verification after it
+	// stopped being used for signing. It must be at least the access token TTL.
+	Retain time.Duration
+	// Dir, if set, persists keys as PEM files so they survive restarts. Keys
+	// are only read on start, so instances must not share the directory.
+	Dir string
+}
+
+// KeyRing holds the current signing key and all keys that may still be
+// used to verify previously issued tokens.
+type KeyRing struct {
+	mu      sync.RWMutex
+	opts    Options
+	method  jwt.SigningMethod
+	keys    map[string]*Key
+	current *Key
+}
+
+func New(opts Options) (*KeyRing, error) {
+	method := jwt.GetSigningMethod(opts.Algorithm)
+	switch method {
+	case jwt.SigningMethodRS256, jwt.SigningMethodEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %q", opts.Algorithm)
+	}
+
+	if opts.RotateEvery <= 0 {
+		return nil, errors.New("key rotation interval must be positive")
+	}
+
+	k := &KeyRing{
+		opts:   opts,
+		method: method,
+		keys:   make(map[string]*Key),
+	}
+
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+
+	if k.current == nil || k.needsRotation(time.Now()) {
+		if err := k.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Sign signs claims with the current key and sets the kid header.
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	key := k.current
+	k.mu.RUnlock()
+
+	t := jwt.NewWithClaims(k.method, claims)
+	t.Header["kid"] = key.ID
+
+	return t.SignedString(key.private)
+}
+
+// Keyfunc resolves the verification key for a token by its kid header.
+// It is meant to be passed to jwt.Parse.
+func (k *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	k.mu.RUnlock()
+
+	if !ok || time.Now().After(key.RetiredAt) {
+		return nil, ErrUnknownKey
+	}
+
+	return key.Public(), nil
+}
+
+// Keys returns all keys valid for verification, newest first.
+func (k *KeyRing) Keys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys
+}
+
+// Rotate generates a new signing key and drops keys past their retention.
+func (k *KeyRing) Rotate() error {
+	private, err := k.generate()
+	if err != nil {
+		return err
+	}
+
+	id, err := newKeyID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key := &Key{
+		ID:        id,
+		CreatedAt: now,
+		RetiredAt: now.Add(k.opts.RotateEvery + k.opts.Retain),
+		private:   private,
+	}
+
+	if err := k.save(key); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[key.ID] = key
+	k.current = key
+	k.prune(now)
+
+	return nil
+}
+
+// Run rotates keys on schedule until ctx is done.
+func (k *KeyRing) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !k.needsRotation(now) {
+				continue
+			}
+
+			if err := k.Rotate(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (k *KeyRing) needsRotation(now time.Time) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return now.Sub(k.current.CreatedAt) >= k.opts.RotateEvery
+}
+
+// prune removes retired keys. Must be called with the lock held.
+func (k *KeyRing) prune(now time.Time) {
+	for id, key := range k.keys {
+		if key == k.current || now.Before(key.RetiredAt) {
+			continue
+		}
+
+		delete(k.keys, id)
+
+		if k.opts.Dir != "" {
+			os.Remove(k.keyPath(id))
+		}
+	}
+}
+
+func (k *KeyRing) generate() (crypto.Signer, error) {
+	if k.method == jwt.SigningMethodEdDSA {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	}
+
+	return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+}
+
+func (k *KeyRing) keyPath(id string) string {
+	return filepath.Join(k.opts.Dir, id+".pem")
+}
+
+func (k *KeyRing) save(key *Key) error {
+	if k.opts.Dir == "" {
+		return nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(k.opts.Dir, 0700); err != nil {
+		return err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	return os.WriteFile(k.keyPath(key.ID), data, 0600)
+}
+
+// load reads persisted keys. The file modification time is used as the key
+// creation time.
+func (k *KeyRing) load() error {
+	if k.opts.Dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(k.opts.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		key, err := k.loadKey(entry)
+		if err != nil {
+			return fmt.Errorf("loading key %s: %w", entry.Name(), err)
+		}
+
+		k.keys[key.ID] = key
+		if k.current == nil || key.CreatedAt.After(k.current.CreatedAt) {
+			k.current = key
+		}
+	}
+
+	k.prune(now)
+
+	return nil
+}
+
+func (k *KeyRing) loadKey(entry os.DirEntry) (*Key, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(k.opts.Dir, entry.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch p := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.method != jwt.SigningMethodRS256 {
+			return nil, errors.New("RSA key does not match signing algorithm")
+		}
+		private = p
+	case ed25519.PrivateKey:
+		if k.method != jwt.SigningMethodEdDSA {
+			return nil, errors.New("Ed25519 key does not match signing algorithm")
+		}
+		private = p
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	return &Key{
+		ID:        strings.TrimSuffix(entry.Name(), ".pem"),
+		CreatedAt: info.ModTime(),
+		RetiredAt: info.ModTime().Add(k.opts.RotateEvery + k.opts.Retain),
+		private:   private,
+	}, nil
+}
+
+func newKeyID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package keyring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func sign(t *testing.T, k *KeyRing) string {
+	t.Helper()
+
+	token, err := k.Sign(jwt.StandardClaims{Subject: "1", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func kid(t *testing.T, token string) string {
+	t.Helper()
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &jwt.StandardClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := parsed.Header["kid"].(string)
+	return id
+}
+
+func TestKeyRingRotate(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "EdDSA"} {
+		k, err := New(Options{Algorithm: algorithm, RotateEvery: time.Hour, Retain: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		before := sign(t, k)
+		if err := k.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		after := sign(t, k)
+
+		if kid(t, before) == kid(t, after) {
+			t.Errorf("%s: tokens signed before and after rotation have the same kid", algorithm)
+		}
+
+		for _, token := range []string{before, after} {
+			if _, err := jwt.Parse(token, k.Keyfunc); err != nil {
+				t.Errorf("%s: token with kid %s doesn't verify: %v", algorithm, kid(t, token), err)
+			}
+		}
+
+		keys := k.Keys()
+		if len(keys) != 2 || keys[0].ID != kid(t, after) {
+			t.Fatalf("%s: Keys() returned %d keys, want 2 with the current key first", algorithm, len(keys))
+		}
+
+		// once retired the old key is dropped with the next rotation
+		keys[1].RetiredAt = time.Now().Add(-time.Second)
+		if err := k.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := jwt.Parse(before, k.Keyfunc); err == nil {
+			t.Errorf("%s: token of a retired key verifies", algorithm)
+		}
+
+		if n := len(k.Keys()); n != 2 {
+			t.Errorf("%s: Keys() returned %d keys after pruning, want 2", algorithm, n)
+		}
+	}
+}
+
+func TestKeyRingDir(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Algorithm: "EdDSA", RotateEvery: time.Hour, Retain: time.Hour, Dir: dir}
+
+	k, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, k)
+
+	// a restart keeps signing with the persisted key
+	restarted, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := jwt.Parse(token, restarted.Keyfunc); err != nil {
+		t.Errorf("token doesn't verify after restart: %v", err)
+	}
+
+	if got, want := kid(t, sign(t, restarted)), kid(t, token); got != want {
+		t.Errorf("signing key after restart = %s, want %s", got, want)
+	}
+
+	if _, err := New(Options{Algorithm: "RS256", RotateEvery: time.Hour, Dir: dir}); err == nil {
+		t.Error("New() loaded Ed25519 keys for RS256")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unsupported algorithm", Options{Algorithm: "HS256", RotateEvery: time.Hour}},
+		{"unknown algorithm", Options{Algorithm: "none", RotateEvery: time.Hour}},
+		{"no rotation", Options{Algorithm: "EdDSA"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := New(tt.opts); err == nil {
+			t.Errorf("%s: New() succeeded", tt.name)
+		}
+	}
+}
+
+func TestKeyfuncRejects(t *testing.T) {
+	k, err := New(Options{Algorithm: "EdDSA", RotateEvery: time.Hour, Retain: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := New(Options{Algorithm: "EdDSA", RotateEvery: time.Hour, Retain: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noKid := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.StandardClaims{Subject: "1"})
+	noKidToken, err := noKid.SignedString(k.current.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{Subject: "1"}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown key", sign(t, other)},
+		{"no kid", noKidToken},
+		{"other algorithm", hmacToken},
+	}
+
+	for _, tt := range tests {
+		if _, err := jwt.Parse(tt.token, k.Keyfunc); err == nil {
+			t.Errorf("%s: token verifies", tt.name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+type SMTPInfo struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer sends messages through an SMTP server.
+type SMTPMailer struct {
+	info SMTPInfo
+}
+
+func NewSMTPMailer(info SMTPInfo) *SMTPMailer {
+	return &SMTPMailer{info: info}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.info.Username != "" {
+		auth = smtp.PlainAuth("", m.info.Username, m.info.Password, m.info.Host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.info.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	addr := fmt.Sprintf("%s:%d", m.info.Host, m.info.Port)
+
+	return smtp.SendMail(addr, auth, m.info.From, []string{msg.To}, []byte(b.String()))
+}
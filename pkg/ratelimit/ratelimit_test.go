@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Second, Burst: 3}
+
+	type take struct {
+		after      time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "burst",
+			takes: []take{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill",
+			takes: []take{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+				{250 * time.Millisecond, true, 0, 0},
+				{time.Second, true, 1, 0},
+			},
+		},
+		{
+			name: "refill stops at burst",
+			takes: []take{
+				{0, true, 2, 0},
+				{time.Minute, true, 2, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		now := time.Unix(1700000000, 0)
+
+		s := NewMemoryStore()
+		s.now = func() time.Time { return now }
+
+		for i, want := range tt.takes {
+			now = now.Add(want.after)
+
+			res, err := s.Take(context.Background(), "key", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Allowed != want.allowed || res.Remaining != want.remaining || res.RetryAfter != want.retryAfter {
+				t.Errorf("%s: take %d = allowed %v, remaining %d, retry after %s; want %v, %d, %s",
+					tt.name, i, res.Allowed, res.Remaining, res.RetryAfter, want.allowed, want.remaining, want.retryAfter)
+			}
+
+			if res.Limit != 3 {
+				t.Errorf("%s: take %d limit = %d, want 3", tt.name, i, res.Limit)
+			}
+		}
+	}
+}
+
+func TestMemoryStoreKeys(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	for _, key := range []string{"a", "b"} {
+		res, err := s.Take(context.Background(), key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !res.Allowed {
+			t.Errorf("first request of %q was rejected", key)
+		}
+	}
+
+	if res, _ := s.Take(context.Background(), "a", limit); res.Allowed {
+		t.Error("second request of \"a\" was allowed")
+	}
+}
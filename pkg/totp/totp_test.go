@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, base32 encoded.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// The RFC lists 8 digit codes, 6 digit codes are their last digits.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != tt.code {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		skew   int64
+		step   int64
+		ok     bool
+	}{
+		{"current step", rfcSecret, "050471", now, 0, Step(now), true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "050471", now, 0, Step(now), true},
+		{"previous step within skew", rfcSecret, "050471", now.Add(30 * time.Second), 1, Step(now), true},
+		{"previous step without skew", rfcSecret, "050471", now.Add(30 * time.Second), 0, 0, false},
+		{"outside skew", rfcSecret, "050471", now.Add(90 * time.Second), 1, 0, false},
+		{"wrong code", rfcSecret, "123456", now, 1, 0, false},
+		{"wrong length", rfcSecret, "50471", now, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		step, ok := Validate(tt.secret, tt.code, tt.at, tt.skew)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: Validate() = %d, %v, want %d, %v", tt.name, step, ok, tt.step, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE user_revocations;
+DROP TABLE revoked_tokens;
+ALTER TABLE users DROP COLUMN admin;
+ALTER TABLE users DROP COLUMN disabled_at;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN disabled_at timestamp;
+ALTER TABLE users ADD COLUMN admin boolean not null default false;
+
+CREATE TABLE revoked_tokens (
+    jti varchar(64) not null unique,
+    user_id int not null,
+    expires_at timestamp not null
+);
+
+CREATE TABLE user_revocations (
+    user_id int not null unique,
+    revoked_at timestamp not null
+);
@@ -0,0 +1,2 @@
+DROP TABLE user_tokens;
+ALTER TABLE users DROP COLUMN verified_at;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN verified_at timestamp;
+UPDATE users SET verified_at = registered_at;
+
+CREATE TABLE user_tokens (
+    id serial not null unique,
+    user_id int not null,
+    kind varchar(32) not null,
+    token_hash varchar(64) not null unique,
+    expires_at timestamp not null
+);
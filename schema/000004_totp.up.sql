@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN totp_secret varchar(64) not null default '';
+ALTER TABLE users ADD COLUMN totp_enabled boolean not null default false;
+ALTER TABLE users ADD COLUMN totp_last_step bigint not null default 0;
+
+CREATE TABLE recovery_codes (
+    id serial not null unique,
+    user_id int not null,
+    code_hash varchar(64) not null
+);
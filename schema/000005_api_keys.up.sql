@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+    id serial not null unique,
+    user_id int not null,
+    name varchar(255) not null,
+    prefix varchar(16) not null,
+    key_hash varchar(64) not null unique,
+    scopes text[] not null,
+    expires_at timestamp,
+    last_used_at timestamp,
+    created_at timestamp not null default now(),
+    revoked_at timestamp
+);
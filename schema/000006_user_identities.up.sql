@@ -0,0 +1,9 @@
+CREATE TABLE user_identities (
+    id serial not null unique,
+    user_id int not null,
+    provider varchar(64) not null,
+    subject varchar(255) not null,
+    email varchar(255) not null,
+    created_at timestamp not null default now(),
+    unique (provider, subject)
+);
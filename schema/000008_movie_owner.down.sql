@@ -0,0 +1 @@
+ALTER TABLE movies DROP COLUMN user_id;
\ No newline at end of file
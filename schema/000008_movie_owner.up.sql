@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN user_id int;
@@ -0,0 +1,5 @@
+DELETE FROM movies WHERE library_id IS NOT NULL;
+ALTER TABLE movies DROP COLUMN library_id;
+DROP TABLE library_invitations;
+DROP TABLE library_members;
+DROP TABLE libraries;
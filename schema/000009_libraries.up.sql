@@ -0,0 +1,25 @@
+CREATE TABLE libraries (
+    id serial not null unique,
+    name varchar(255) not null,
+    created_at timestamp not null default now()
+);
+
+CREATE TABLE library_members (
+    library_id int not null,
+    user_id int not null,
+    role varchar(16) not null,
+    joined_at timestamp not null default now(),
+    unique (library_id, user_id)
+);
+
+CREATE TABLE library_invitations (
+    id serial not null unique,
+    library_id int not null,
+    email varchar(255) not null,
+    role varchar(16) not null,
+    token_hash varchar(64) not null unique,
+    invited_by int not null,
+    expires_at timestamp not null
+);
+
+ALTER TABLE movies ADD COLUMN library_id int;
@@ -0,0 +1,2 @@
+DROP TABLE watch_events;
+DROP TABLE watch_states;
\ No newline at end of file
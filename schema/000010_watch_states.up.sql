@@ -0,0 +1,19 @@
+CREATE TABLE watch_states (
+    user_id int not null,
+    movie_id int not null,
+    status varchar(16) not null,
+    progress int,
+    rating int,
+    notes text not null default '',
+    updated_at timestamp not null default now(),
+    unique (user_id, movie_id)
+);
+
+CREATE TABLE watch_events (
+    id serial not null unique,
+    user_id int not null,
+    movie_id int not null,
+    watched_at timestamp not null
+);
+
+CREATE INDEX watch_events_user_movie ON watch_events (user_id, movie_id);
@@ -0,0 +1,4 @@
+DROP TABLE movie_tags;
+DROP TABLE tags;
+DROP TABLE movie_genres;
+DROP TABLE genres;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE genres (
+    id serial not null unique,
+    name varchar(64) not null unique
+);
+
+CREATE TABLE movie_genres (
+    movie_id int not null,
+    genre_id int not null,
+    unique (movie_id, genre_id)
+);
+
+CREATE TABLE tags (
+    id serial not null unique,
+    name varchar(64) not null unique
+);
+
+CREATE TABLE movie_tags (
+    movie_id int not null,
+    tag_id int not null,
+    unique (movie_id, tag_id)
+);
+
+CREATE INDEX movie_tags_tag ON movie_tags (tag_id);
@@ -0,0 +1,4 @@
+DROP TABLE episode_watches;
+DROP TABLE episodes;
+DROP TABLE seasons;
+ALTER TABLE movies DROP COLUMN type;
\ No newline at end of file
@@ -0,0 +1,25 @@
+ALTER TABLE movies ADD COLUMN type varchar(16) not null default 'movie';
+
+CREATE TABLE seasons (
+    id serial not null unique,
+    movie_id int not null,
+    number int not null,
+    title varchar(255) not null default '',
+    unique (movie_id, number)
+);
+
+CREATE TABLE episodes (
+    id serial not null unique,
+    season_id int not null,
+    number int not null,
+    title varchar(255) not null default '',
+    air_date date,
+    unique (season_id, number)
+);
+
+CREATE TABLE episode_watches (
+    user_id int not null,
+    episode_id int not null,
+    watched_at timestamp not null default now(),
+    unique (user_id, episode_id)
+);
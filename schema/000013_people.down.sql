@@ -0,0 +1,2 @@
+DROP TABLE movie_credits;
+DROP TABLE people;
\ No newline at end of file
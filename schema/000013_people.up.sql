@@ -0,0 +1,19 @@
+CREATE TABLE people (
+    id serial not null unique,
+    name varchar(255) not null,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX people_name ON people (lower(name));
+
+CREATE TABLE movie_credits (
+    id serial not null unique,
+    movie_id int not null,
+    person_id int not null,
+    role varchar(16) not null,
+    character varchar(255) not null default '',
+    job varchar(255) not null default ''
+);
+
+CREATE INDEX movie_credits_movie ON movie_credits (movie_id);
+CREATE INDEX movie_credits_person ON movie_credits (person_id);
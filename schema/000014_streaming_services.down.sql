@@ -0,0 +1,4 @@
+-- movies keep the canonical service names
+ALTER TABLE movies DROP COLUMN streaming_service_id;
+DROP TABLE streaming_service_aliases;
+DROP TABLE streaming_services;
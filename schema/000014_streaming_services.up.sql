@@ -0,0 +1,66 @@
+CREATE TABLE streaming_services (
+    id serial not null unique,
+    name varchar(255) not null unique,
+    regions varchar(2)[] not null default '{}',
+    created_at timestamp not null default now()
+);
+
+-- aliases are stored as keys: lowercase letters, digits and "+"
+CREATE TABLE streaming_service_aliases (
+    service_id int not null,
+    alias varchar(255) not null unique
+);
+
+INSERT INTO streaming_services (name, regions) VALUES
+    ('Netflix', '{}'),
+    ('Amazon Prime Video', '{}'),
+    ('Disney+', '{}'),
+    ('Hulu', '{US}'),
+    ('Max', '{}'),
+    ('Apple TV+', '{}'),
+    ('Paramount+', '{}'),
+    ('Peacock', '{US}');
+
+INSERT INTO streaming_service_aliases (service_id, alias)
+SELECT s.id, a.alias FROM streaming_services s JOIN (VALUES
+    ('Netflix', 'netflix'),
+    ('Netflix', 'nflx'),
+    ('Amazon Prime Video', 'amazonprimevideo'),
+    ('Amazon Prime Video', 'amazonprime'),
+    ('Amazon Prime Video', 'primevideo'),
+    ('Amazon Prime Video', 'prime'),
+    ('Disney+', 'disney+'),
+    ('Disney+', 'disneyplus'),
+    ('Hulu', 'hulu'),
+    ('Max', 'max'),
+    ('Max', 'hbomax'),
+    ('Max', 'hbo'),
+    ('Apple TV+', 'appletv+'),
+    ('Apple TV+', 'appletvplus'),
+    ('Apple TV+', 'appletv'),
+    ('Paramount+', 'paramount+'),
+    ('Paramount+', 'paramountplus'),
+    ('Peacock', 'peacock')
+) AS a (name, alias) ON a.name = s.name;
+
+-- every other service saved on movies becomes a catalog entry named after its
+-- most used spelling
+INSERT INTO streaming_services (name)
+SELECT DISTINCT ON (key) name FROM (
+    SELECT trim(streaming_service) AS name,
+        lower(regexp_replace(streaming_service, '[^[:alnum:]+]', '', 'g')) AS key,
+        count(*) AS uses
+    FROM movies GROUP BY streaming_service
+) spellings
+WHERE key <> '' AND key NOT IN (SELECT alias FROM streaming_service_aliases)
+ORDER BY key, uses DESC, name;
+
+INSERT INTO streaming_service_aliases (service_id, alias)
+SELECT s.id, lower(regexp_replace(s.name, '[^[:alnum:]+]', '', 'g')) FROM streaming_services s
+WHERE NOT EXISTS (SELECT 1 FROM streaming_service_aliases a WHERE a.service_id = s.id);
+
+ALTER TABLE movies ADD COLUMN streaming_service_id int;
+
+UPDATE movies m SET streaming_service_id = s.id, streaming_service = s.name
+FROM streaming_service_aliases a JOIN streaming_services s ON s.id = a.service_id
+WHERE a.alias = lower(regexp_replace(m.streaming_service, '[^[:alnum:]+]', '', 'g'));
@@ -0,0 +1,13 @@
+ALTER TABLE movies ADD COLUMN release varchar(255) not null default '';
+
+UPDATE movies SET release = CASE release_precision
+    WHEN 'year' THEN to_char(release_date, 'YYYY')
+    ELSE to_char(release_date, 'YYYY-MM-DD')
+END WHERE release_date IS NOT NULL;
+
+UPDATE movies m SET release = e.release FROM release_import_errors e WHERE e.movie_id = m.id;
+
+DROP TABLE release_import_errors;
+DROP TABLE movie_releases;
+ALTER TABLE movies DROP COLUMN release_precision;
+ALTER TABLE movies DROP COLUMN release_date;
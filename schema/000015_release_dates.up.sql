@@ -0,0 +1,60 @@
+ALTER TABLE movies ADD COLUMN release_date date;
+ALTER TABLE movies ADD COLUMN release_precision varchar(4);
+
+CREATE INDEX movies_release_date ON movies (release_date);
+
+CREATE TABLE movie_releases (
+    movie_id int not null,
+    country varchar(2) not null,
+    release_date date not null,
+    release_precision varchar(4) not null,
+    unique (movie_id, country)
+);
+
+-- releases that couldn't be parsed or are ambiguous, they are also reported
+-- as notices
+CREATE TABLE release_import_errors (
+    movie_id int not null,
+    release varchar(255) not null,
+    created_at timestamp not null default now()
+);
+
+DO $$
+DECLARE
+    m record;
+    value text;
+BEGIN
+    FOR m IN SELECT id, release FROM movies WHERE trim(release) <> '' LOOP
+        value := trim(m.release);
+        BEGIN
+            IF value ~ '^\d{4}$' THEN
+                UPDATE movies SET release_date = make_date(value::int, 1, 1), release_precision = 'year' WHERE id = m.id;
+            ELSIF value ~ '^\d{4}-\d{1,2}-\d{1,2}$' THEN
+                UPDATE movies SET release_date = to_date(value, 'YYYY-MM-DD'), release_precision = 'day' WHERE id = m.id;
+            ELSIF value ~ '^\d{1,2}\.\d{1,2}\.\d{4}$' THEN
+                UPDATE movies SET release_date = to_date(value, 'DD.MM.YYYY'), release_precision = 'day' WHERE id = m.id;
+            ELSIF value ~ '^\d{1,2}/\d{1,2}/\d{4}$' THEN
+                -- the order of day and month is only known if one of them is
+                -- above 12 or both are equal
+                IF split_part(value, '/', 1)::int > 12 THEN
+                    UPDATE movies SET release_date = to_date(value, 'DD/MM/YYYY'), release_precision = 'day' WHERE id = m.id;
+                ELSIF split_part(value, '/', 2)::int > 12 OR split_part(value, '/', 1)::int = split_part(value, '/', 2)::int THEN
+                    UPDATE movies SET release_date = to_date(value, 'MM/DD/YYYY'), release_precision = 'day' WHERE id = m.id;
+                ELSE
+                    RAISE EXCEPTION 'ambiguous date';
+                END IF;
+            ELSIF value ~* '^[a-z]+ \d{1,2},? \d{4}$' THEN
+                UPDATE movies SET release_date = to_date(replace(value, ',', ''), 'FMMonth DD YYYY'), release_precision = 'day' WHERE id = m.id;
+            ELSIF value ~* '^\d{1,2} [a-z]+ \d{4}$' THEN
+                UPDATE movies SET release_date = to_date(value, 'DD FMMonth YYYY'), release_precision = 'day' WHERE id = m.id;
+            ELSE
+                RAISE EXCEPTION 'unknown format';
+            END IF;
+        EXCEPTION WHEN others THEN
+            INSERT INTO release_import_errors (movie_id, release) VALUES (m.id, m.release);
+            RAISE NOTICE 'movie %: can''t parse release "%"', m.id, m.release;
+        END;
+    END LOOP;
+END $$;
+
+ALTER TABLE movies DROP COLUMN release;
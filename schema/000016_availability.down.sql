@@ -0,0 +1 @@
+DROP TABLE availabilities;
\ No newline at end of file
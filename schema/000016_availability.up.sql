@@ -0,0 +1,15 @@
+CREATE TABLE availabilities (
+    id serial not null unique,
+    movie_id int not null,
+    service_id int not null,
+    region varchar(2) not null,
+    kind varchar(16) not null,
+    price numeric(10, 2),
+    currency varchar(3),
+    available_from date,
+    available_until date,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX availabilities_movie ON availabilities (movie_id);
+CREATE INDEX availabilities_region_service ON availabilities (region, service_id);
@@ -0,0 +1,3 @@
+ALTER TABLE movies DROP COLUMN runtime;
+
+DROP TABLE user_subscriptions;
@@ -0,0 +1,9 @@
+CREATE TABLE user_subscriptions (
+    user_id int not null,
+    service_id int not null,
+    region varchar(2) not null,
+    created_at timestamp not null default now(),
+    primary key (user_id, service_id, region)
+);
+
+ALTER TABLE movies ADD COLUMN runtime int;
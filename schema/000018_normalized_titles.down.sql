@@ -0,0 +1,3 @@
+DROP INDEX movies_duplicates_idx;
+
+ALTER TABLE movies DROP COLUMN normalized_title;
@@ -0,0 +1,7 @@
+-- normalized_title follows domain.NormalizeTitle, duplicate lookups compare
+-- its length before computing title similarity
+ALTER TABLE movies ADD COLUMN normalized_title text GENERATED ALWAYS AS (
+    regexp_replace(btrim(regexp_replace(lower(title), '[^[:alnum:]]+', ' ', 'g')), '^(the|a|an) ', '')
+) STORED;
+
+CREATE INDEX movies_duplicates_idx ON movies (type, char_length(normalized_title)) WHERE library_id IS NULL;
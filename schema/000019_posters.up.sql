@@ -0,0 +1,10 @@
+CREATE TABLE movie_posters (
+    movie_id int not null unique,
+    key varchar(255) not null,
+    content_type varchar(32) not null,
+    width int not null,
+    height int not null,
+    size int not null,
+    sizes varchar(32)[] not null,
+    updated_at timestamp not null default now()
+);